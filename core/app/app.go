@@ -6,7 +6,12 @@ import (
 	"github.com/iotaledger/hive.go/core/app/plugins/profiling"
 	"github.com/iotaledger/inx-app/core/inx"
 	"github.com/iotaledger/inx-indexer/core/indexer"
+	"github.com/iotaledger/inx-indexer/plugins/auth"
+	"github.com/iotaledger/inx-indexer/plugins/outbox"
 	"github.com/iotaledger/inx-indexer/plugins/prometheus"
+	"github.com/iotaledger/inx-indexer/plugins/scheduler"
+	"github.com/iotaledger/inx-indexer/plugins/tracing"
+	"github.com/iotaledger/inx-indexer/plugins/webhooks"
 )
 
 var (
@@ -27,7 +32,12 @@ func App() *app.App {
 		}...),
 		app.WithPlugins([]*app.Plugin{
 			profiling.Plugin,
+			tracing.Plugin,
 			prometheus.Plugin,
+			scheduler.Plugin,
+			webhooks.Plugin,
+			outbox.Plugin,
+			auth.Plugin,
 		}...),
 	)
 }
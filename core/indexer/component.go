@@ -9,25 +9,29 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/dig"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 
 	"github.com/iotaledger/hive.go/core/app"
 	"github.com/iotaledger/hive.go/core/app/pkg/shutdown"
+	"github.com/iotaledger/hive.go/core/generics/options"
 	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/inx-app/pkg/httpserver"
 	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
 	"github.com/iotaledger/inx-indexer/pkg/daemon"
-	"github.com/iotaledger/inx-indexer/pkg/database"
 	"github.com/iotaledger/inx-indexer/pkg/indexer"
 	"github.com/iotaledger/inx-indexer/pkg/server"
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
 	inx "github.com/iotaledger/inx/go"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 const (
-	DBVersion uint32 = 2
+	DBVersion uint32 = 3
 )
 
 const (
@@ -56,6 +60,7 @@ type dependencies struct {
 	Indexer         *indexer.Indexer
 	ShutdownHandler *shutdown.ShutdownHandler
 	Echo            *echo.Echo
+	Authenticator   *auth.Authenticator `optional:"true"`
 }
 
 var (
@@ -68,29 +73,21 @@ func provide(c *dig.Container) error {
 	if err := c.Provide(func() (*indexer.Indexer, error) {
 		CoreComponent.LogInfo("Setting up database ...")
 
-		engine, err := database.EngineFromString(ParamsIndexer.Database.Engine)
+		dbParams, err := ParamsIndexer.DatabaseParams()
 		if err != nil {
 			return nil, err
 		}
 
-		dbParams := database.Params{
-			Engine: engine,
+		if err := indexer.CheckMaxQueryCost(ParamsRestAPI.QueryCost.MaxCost, ParamsRestAPI.MaxPageSize); err != nil {
+			return nil, fmt.Errorf("invalid restAPI.queryCost.maxCost: %w", err)
 		}
 
-		//nolint:exhaustive // we already checked the values is one of the valid ones
-		switch engine {
-		case database.EngineSQLite:
-			dbParams.Path = ParamsIndexer.Database.SQLite.Path
-
-		case database.EnginePostgreSQL:
-			dbParams.Host = ParamsIndexer.Database.PostgreSQL.Host
-			dbParams.Port = ParamsIndexer.Database.PostgreSQL.Port
-			dbParams.Database = ParamsIndexer.Database.PostgreSQL.Database
-			dbParams.Username = ParamsIndexer.Database.PostgreSQL.Username
-			dbParams.Password = ParamsIndexer.Database.PostgreSQL.Password
-		}
-
-		return indexer.NewIndexer(dbParams, CoreComponent.Logger())
+		return indexer.NewIndexer(dbParams, CoreComponent.Logger(),
+			indexer.WithMaxReadReplicaLag(ParamsIndexer.Database.PostgreSQL.ReadReplicas.MaxLag),
+			indexer.WithMaxQueryDuration(ParamsRestAPI.MaxQueryDuration),
+			indexer.WithMaxQueryCost(ParamsRestAPI.QueryCost.MaxCost, ParamsRestAPI.QueryCost.ReducePageSize),
+			indexer.WithMaxExportDuration(ParamsRestAPI.Export.MaxDuration),
+		)
 	}); err != nil {
 		return err
 	}
@@ -132,8 +129,14 @@ func run() error {
 		CoreComponent.LogInfo("Starting LedgerUpdates ... done")
 
 		if err := deps.NodeBridge.ListenToLedgerUpdates(ctx, indexerStatus.LedgerIndex+1, 0, func(update *nodebridge.LedgerUpdate) error {
+			// every milestone is a trace of its own, its span is not part of the long-running context of the daemon
+			updateCtx, span := tracing.Tracer().Start(context.Background(), "LedgerUpdate", trace.WithAttributes(
+				attribute.Int64("indexer.milestone_index", int64(update.MilestoneIndex)),
+			))
+			defer span.End()
+
 			ts := time.Now()
-			if err := deps.Indexer.UpdatedLedger(update); err != nil {
+			if err := deps.Indexer.UpdatedLedger(updateCtx, update); err != nil {
 				return err
 			}
 
@@ -152,20 +155,34 @@ func run() error {
 
 	// create a background worker that handles the API
 	if err := CoreComponent.Daemon().BackgroundWorker("API", func(ctx context.Context) {
-		CoreComponent.LogInfo("Starting API")
+		CoreComponent.LogInfo("Starting API server ...")
 
-		// we need to wait until the indexer is initialized before starting the API or the daemon is canceled before that is done.
-		select {
-		case <-ctx.Done():
-			return
-		case <-indexerInitWait:
+		serverOpts := []options.Option[server.IndexerServer]{
+			server.WithCursorSigningKey([]byte(ParamsRestAPI.CursorSigningKey)),
+			server.WithReadiness(deps.NodeBridge.ConfirmedMilestoneIndex, ParamsRestAPI.Readiness.MaxLag),
+		}
+		if ParamsRestAPI.SlowQueries.Enabled {
+			serverOpts = append(serverOpts, server.WithSlowQueryLog(CoreComponent.Logger().Named("SlowQueries"), ParamsRestAPI.SlowQueries.Threshold, ParamsRestAPI.SlowQueries.MaxFingerprints))
+		}
+		if ParamsRestAPI.Cache.Enabled {
+			serverOpts = append(serverOpts, server.WithResponseCache(ParamsRestAPI.Cache.MaxEntries))
+		}
+		if ParamsRestAPI.Export.Enabled {
+			serverOpts = append(serverOpts, server.WithExport(ParamsRestAPI.Export.MaxConcurrent))
+		}
+		if deps.Authenticator != nil {
+			serverOpts = append(serverOpts, server.WithAuthenticator(deps.Authenticator))
+		}
+		if ParamsRestAPI.GraphQL.Enabled {
+			serverOpts = append(serverOpts, server.WithGraphQL(ParamsRestAPI.GraphQL.MaxDepth, ParamsRestAPI.GraphQL.MaxComplexity))
 		}
-		CoreComponent.LogInfo("Starting API ... done")
-
-		CoreComponent.LogInfo("Starting API server ...")
 
-		_ = server.NewIndexerServer(deps.Indexer, deps.Echo.Group(""), deps.NodeBridge.ProtocolParameters().Bech32HRP, ParamsRestAPI.MaxPageSize)
+		indexerServer, err := server.NewIndexerServer(deps.Indexer, deps.Echo.Group(""), deps.NodeBridge.ProtocolParameters().Bech32HRP, ParamsRestAPI.MaxPageSize, serverOpts...)
+		if err != nil {
+			CoreComponent.LogErrorfAndExit("Creating API server failed: %s", err)
+		}
 
+		// the API is started before the indexer is initialized, so the health routes are reachable while the initial ledger is imported.
 		go func() {
 			CoreComponent.LogInfof("You can now access the API using: http://%s", ParamsRestAPI.BindAddress)
 			if err := deps.Echo.Start(ParamsRestAPI.BindAddress); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -173,28 +190,21 @@ func run() error {
 			}
 		}()
 
-		ctxRegister, cancelRegister := context.WithTimeout(ctx, 5*time.Second)
-
-		advertisedAddress := ParamsRestAPI.BindAddress
-		if ParamsRestAPI.AdvertiseAddress != "" {
-			advertisedAddress = ParamsRestAPI.AdvertiseAddress
-		}
-
-		if err := deps.NodeBridge.RegisterAPIRoute(ctxRegister, APIRoute, advertisedAddress); err != nil {
-			CoreComponent.LogErrorfAndExit("Registering INX api route failed: %s", err)
+		// the output routes and the route at the node are enabled once the indexer is initialized or the daemon is canceled before that is done.
+		select {
+		case <-ctx.Done():
+		case <-indexerInitWait:
+			indexerServer.MarkInitialized()
+			registerAPIRoute(ctx)
+			CoreComponent.LogInfo("Starting API server ... done")
 		}
-		cancelRegister()
 
-		CoreComponent.LogInfo("Starting API server ... done")
 		<-ctx.Done()
 		CoreComponent.LogInfo("Stopping API ...")
 
-		ctxUnregister, cancelUnregister := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancelUnregister()
-
-		//nolint:contextcheck // false positive
-		if err := deps.NodeBridge.UnregisterAPIRoute(ctxUnregister, APIRoute); err != nil {
-			CoreComponent.LogWarnf("Unregistering INX api route failed: %s", err)
+		if indexerServer.Initialized() {
+			//nolint:contextcheck // false positive
+			unregisterAPIRoute()
 		}
 
 		shutdownCtx, shutdownCtxCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -213,6 +223,29 @@ func run() error {
 	return nil
 }
 
+func registerAPIRoute(ctx context.Context) {
+	ctxRegister, cancelRegister := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelRegister()
+
+	advertisedAddress := ParamsRestAPI.BindAddress
+	if ParamsRestAPI.AdvertiseAddress != "" {
+		advertisedAddress = ParamsRestAPI.AdvertiseAddress
+	}
+
+	if err := deps.NodeBridge.RegisterAPIRoute(ctxRegister, APIRoute, advertisedAddress); err != nil {
+		CoreComponent.LogErrorfAndExit("Registering INX api route failed: %s", err)
+	}
+}
+
+func unregisterAPIRoute() {
+	ctxUnregister, cancelUnregister := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelUnregister()
+
+	if err := deps.NodeBridge.UnregisterAPIRoute(ctxUnregister, APIRoute); err != nil {
+		CoreComponent.LogWarnf("Unregistering INX api route failed: %s", err)
+	}
+}
+
 func checkIndexerStatus(ctx context.Context) (*indexer.Status, error) {
 	var status *indexer.Status
 	var err error
@@ -289,6 +322,10 @@ func checkIndexerStatus(ctx context.Context) (*indexer.Status, error) {
 		if err := deps.Indexer.AutoMigrate(); err != nil {
 			return nil, err
 		}
+		CoreComponent.LogInfo("Updating query planner statistics")
+		if err := deps.Indexer.Analyze(); err != nil {
+			return nil, err
+		}
 		CoreComponent.LogInfof("Importing initial ledger with %d outputs at index %d took %s", count, status.LedgerIndex, duration.Truncate(time.Millisecond))
 	} else {
 		CoreComponent.LogInfo("Checking database schema")
@@ -309,6 +346,9 @@ func fillIndexer(ctx context.Context, indexer *indexer.Indexer, protoParams *iot
 		return 0, err
 	}
 
+	ctx, span := tracing.Tracer().Start(ctx, "ImportInitialLedger")
+	defer span.End()
+
 	importerCtx, importCancel := context.WithCancel(ctx)
 	defer importCancel()
 
@@ -382,6 +422,10 @@ func fillIndexer(ctx context.Context, indexer *indexer.Indexer, protoParams *iot
 	if err := importer.Finalize(ledgerIndex, protoParams, DBVersion); err != nil {
 		return 0, err
 	}
+	span.SetAttributes(
+		attribute.Int64("indexer.ledger_index", int64(ledgerIndex)),
+		attribute.Int("indexer.outputs", countReceive),
+	)
 
 	return countReceive, nil
 }
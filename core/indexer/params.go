@@ -1,7 +1,10 @@
 package indexer
 
 import (
+	"time"
+
 	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/inx-indexer/pkg/database"
 )
 
 type ParametersIndexer struct {
@@ -11,6 +14,9 @@ type ParametersIndexer struct {
 		SQLite struct {
 			// Path defines the path to the database folder
 			Path string `default:"database" usage:"the path to the database folder"`
+
+			// BusyTimeout defines how long a query waits for a locked database before it fails
+			BusyTimeout time.Duration `default:"60s" usage:"how long a query waits for a locked database before it fails"`
 		} `name:"sqlite"`
 		PostgreSQL struct {
 			// Database name
@@ -23,14 +29,89 @@ type ParametersIndexer struct {
 			Password string `default:"" usage:"database password"`
 
 			// Database host
-			Host string `default:"localhost" usage:"database host"`
+			Host string `default:"localhost" usage:"database host (or the directory of a unix socket)"`
 
 			// Database port
 			Port uint `default:"5432" usage:"database port"`
+
+			// SSLMode defines the SSL mode of the connection
+			SSLMode string `name:"sslMode" default:"prefer" usage:"the SSL mode of the connection (disable, allow, prefer, require, verify-ca, verify-full)"`
+
+			// SSLRootCertPath defines the path to the CA certificate used to verify the server
+			SSLRootCertPath string `name:"sslRootCertPath" default:"" usage:"the path to the CA certificate used to verify the server"`
+
+			// SSLCertPath defines the path to the client certificate
+			SSLCertPath string `name:"sslCertPath" default:"" usage:"the path to the client certificate"`
+
+			// SSLKeyPath defines the path to the private key of the client certificate
+			SSLKeyPath string `name:"sslKeyPath" default:"" usage:"the path to the private key of the client certificate"`
+
+			// StatementTimeout defines the maximum duration of a single statement of the API queries (0 = unlimited)
+			StatementTimeout time.Duration `default:"0s" usage:"the maximum duration of a single statement of the API queries (0 = unlimited)"`
+
+			ReadReplicas struct {
+				// Hosts defines the addresses (host:port) of the read replicas
+				Hosts []string `default:"" usage:"the addresses (host:port) of the read replicas used for the API queries"`
+
+				// MaxLag defines the maximum amount of milestones a read replica may lag behind before the primary is queried instead
+				MaxLag uint32 `default:"2" usage:"the maximum amount of milestones a read replica may lag behind before the primary is queried instead"`
+			} `name:"readReplicas"`
 		} `name:"postgresql"`
+
+		Pool struct {
+			// MaxOpenConnections defines the maximum number of open connections to the database (0 = unlimited)
+			MaxOpenConnections int `default:"0" usage:"the maximum number of open connections to the database (0 = unlimited)"`
+
+			// MaxIdleConnections defines the maximum number of idle connections kept in the pool
+			MaxIdleConnections int `default:"2" usage:"the maximum number of idle connections kept in the pool"`
+
+			// ConnectionMaxLifetime defines the maximum amount of time a connection may be reused (0 = unlimited)
+			ConnectionMaxLifetime time.Duration `default:"0s" usage:"the maximum amount of time a connection may be reused (0 = unlimited)"`
+
+			// ConnectionMaxIdleTime defines the maximum amount of time a connection may be idle (0 = unlimited)
+			ConnectionMaxIdleTime time.Duration `default:"0s" usage:"the maximum amount of time a connection may be idle (0 = unlimited)"`
+		} `name:"pool"`
 	} `name:"db"`
 }
 
+// DatabaseParams converts the configured database parameters to the params used by the database package.
+func (p *ParametersIndexer) DatabaseParams() (database.Params, error) {
+	engine, err := database.EngineFromString(p.Database.Engine)
+	if err != nil {
+		return database.Params{}, err
+	}
+
+	dbParams := database.Params{
+		Engine:                engine,
+		MaxOpenConnections:    p.Database.Pool.MaxOpenConnections,
+		MaxIdleConnections:    p.Database.Pool.MaxIdleConnections,
+		ConnectionMaxLifetime: p.Database.Pool.ConnectionMaxLifetime,
+		ConnectionMaxIdleTime: p.Database.Pool.ConnectionMaxIdleTime,
+	}
+
+	//nolint:exhaustive // we already checked the values is one of the valid ones
+	switch engine {
+	case database.EngineSQLite:
+		dbParams.Path = p.Database.SQLite.Path
+		dbParams.BusyTimeout = p.Database.SQLite.BusyTimeout
+
+	case database.EnginePostgreSQL:
+		dbParams.Host = p.Database.PostgreSQL.Host
+		dbParams.Port = p.Database.PostgreSQL.Port
+		dbParams.Database = p.Database.PostgreSQL.Database
+		dbParams.Username = p.Database.PostgreSQL.Username
+		dbParams.Password = p.Database.PostgreSQL.Password
+		dbParams.SSLMode = p.Database.PostgreSQL.SSLMode
+		dbParams.SSLRootCert = p.Database.PostgreSQL.SSLRootCertPath
+		dbParams.SSLCert = p.Database.PostgreSQL.SSLCertPath
+		dbParams.SSLKey = p.Database.PostgreSQL.SSLKeyPath
+		dbParams.StatementTimeout = p.Database.PostgreSQL.StatementTimeout
+		dbParams.ReadReplicas = p.Database.PostgreSQL.ReadReplicas.Hosts
+	}
+
+	return dbParams, nil
+}
+
 // ParametersRestAPI contains the definition of the parameters used by the Indexer HTTP server.
 type ParametersRestAPI struct {
 	// BindAddress defines the bind address on which the Indexer HTTP server listens.
@@ -42,6 +123,58 @@ type ParametersRestAPI struct {
 	// MaxPageSize defines the maximum number of results that may be returned for each page
 	MaxPageSize int `default:"1000" usage:"the maximum number of results that may be returned for each page"`
 
+	// CursorSigningKey defines the key used to sign the cursors returned by the API
+	CursorSigningKey string `default:"" usage:"the key used to sign the cursors returned by the API (random if empty, must be shared by all instances behind a load balancer)"`
+
+	// MaxQueryDuration defines the maximum duration of a query before it is canceled (0 = unlimited)
+	MaxQueryDuration time.Duration `default:"10s" usage:"the maximum duration of a query before it is canceled (0 = unlimited)"`
+
+	QueryCost struct {
+		// MaxCost defines the maximum estimated amount of rows a query may read (0 = unlimited), the default is indexer.DefaultMaxQueryCost
+		MaxCost uint64 `default:"10000" usage:"the maximum estimated amount of rows a query may read, the page size times the rows read per result of the filters, it has to allow 10 rows per result for the maximum page size of the API, the API keys and the JWTs (0 = unlimited)"`
+		// ReducePageSize defines whether the page size of too expensive queries is reduced instead of rejecting them, the default is indexer.DefaultReducePageSize
+		ReducePageSize bool `default:"true" usage:"whether the page size of too expensive queries is reduced instead of rejecting them, clients then get smaller pages than they asked for"`
+	} `name:"queryCost"`
+
+	Cache struct {
+		// Enabled defines whether the responses of the current ledger index are cached
+		Enabled bool `default:"true" usage:"whether the responses of the current ledger index are cached, the cache is cleared with every milestone"`
+		// MaxEntries defines the maximum amount of cached responses
+		MaxEntries int `default:"1000" usage:"the maximum amount of cached responses, the least recently used one is dropped"`
+	} `name:"cache"`
+
+	Export struct {
+		// Enabled defines whether the CSV and NDJSON export routes are enabled
+		Enabled bool `default:"false" usage:"whether the CSV and NDJSON export routes are enabled, every export reads all matching rows of a table"`
+		// MaxDuration defines the maximum duration of an export before it is canceled (0 = unlimited)
+		MaxDuration time.Duration `default:"10m" usage:"the maximum duration of an export before it is canceled (0 = unlimited)"`
+		// MaxConcurrent defines the maximum amount of exports that run at the same time (0 = unlimited)
+		MaxConcurrent int `default:"2" usage:"the maximum amount of exports that run at the same time, further exports are rejected with 429 (0 = unlimited)"`
+	} `name:"export"`
+
+	SlowQueries struct {
+		// Enabled defines whether the slow queries are logged and aggregated
+		Enabled bool `default:"false" usage:"whether the queries that took longer than the threshold are logged and aggregated at the slow queries route"`
+		// Threshold defines the minimum duration of a query to be logged as slow query
+		Threshold time.Duration `default:"100ms" usage:"the minimum duration of a query to be logged as slow query"`
+		// MaxFingerprints defines the maximum amount of combinations of route and filters that are aggregated
+		MaxFingerprints int `default:"1000" usage:"the maximum amount of combinations of route and filters that are aggregated, the least recently seen one is dropped"`
+	} `name:"slowQueries"`
+
+	Readiness struct {
+		// MaxLag defines the maximum amount of milestones the indexer may lag behind the confirmed milestone of the node before it is not ready
+		MaxLag uint32 `default:"5" usage:"the maximum amount of milestones the indexer may lag behind the confirmed milestone of the node before the readiness route fails"`
+	} `name:"readiness"`
+
+	GraphQL struct {
+		// Enabled defines whether the GraphQL endpoint is enabled
+		Enabled bool `default:"false" usage:"whether the GraphQL endpoint is enabled"`
+		// MaxDepth defines the maximum depth of a GraphQL query
+		MaxDepth int `default:"10" usage:"the maximum depth of a GraphQL query"`
+		// MaxComplexity defines the maximum complexity of a GraphQL query
+		MaxComplexity int `default:"5000" usage:"the maximum complexity of a GraphQL query, every field counts one and the fields of connections count once per requested item"`
+	} `name:"graphQL"`
+
 	// DebugRequestLoggerEnabled defines whether the debug logging for requests should be enabled
 	DebugRequestLoggerEnabled bool `default:"false" usage:"whether the debug logging for requests should be enabled"`
 }
@@ -54,5 +187,5 @@ var params = &app.ComponentParams{
 		"indexer": ParamsIndexer,
 		"restAPI": ParamsRestAPI,
 	},
-	Masked: nil,
+	Masked: []string{"restAPI.cursorSigningKey"},
 }
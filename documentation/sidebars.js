@@ -24,6 +24,36 @@ module.exports = {
                     id: 'how_to/query_outputs',
                     label: 'Query the Indexer for Outputs',
                 },
+                {
+                    type: 'doc',
+                    id: 'how_to/scheduled_events',
+                    label: 'Scheduled Events for Timelocks and Expirations',
+                },
+                {
+                    type: 'doc',
+                    id: 'how_to/webhooks',
+                    label: 'Receive Outputs with Webhooks',
+                },
+                {
+                    type: 'doc',
+                    id: 'how_to/outbox',
+                    label: 'Stream Ledger Changes with the Outbox',
+                },
+                {
+                    type: 'doc',
+                    id: 'how_to/tracing',
+                    label: 'Trace Ledger Updates and Queries',
+                },
+                {
+                    type: 'doc',
+                    id: 'how_to/auth',
+                    label: 'Authenticate and Rate Limit Clients',
+                },
+                {
+                    type: 'doc',
+                    id: 'how_to/migrate_database',
+                    label: 'Move the Database with a Dump',
+                },
             ]
         },
         {
@@ -0,0 +1,435 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+)
+
+const (
+	// HeaderAPIKey is the header that contains the API key of a request.
+	// The key or a JWT can also be sent as bearer token in the Authorization header.
+	HeaderAPIKey = "X-API-Key"
+
+	// ReasonMissingCredentials is the reason of a rejected request without an API key or JWT.
+	ReasonMissingCredentials = "missingCredentials"
+	// ReasonInvalidCredentials is the reason of a rejected request with an unknown API key or an invalid JWT.
+	ReasonInvalidCredentials = "invalidCredentials"
+	// ReasonRateLimited is the reason of a rejected request of a client that exceeded its rate limit.
+	ReasonRateLimited = "rateLimited"
+
+	// jwtClientPrefix is the prefix of the names of the clients authenticated by a JWT,
+	// so a subject can not share the rate limit of an API key with the same name.
+	jwtClientPrefix = "jwt:"
+
+	// limiterIdleTimeout is the time after which the rate limiter of an idle client is dropped.
+	limiterIdleTimeout = 10 * time.Minute
+)
+
+// RejectedRequest is a request that was rejected by the Authenticator.
+type RejectedRequest struct {
+	// Client is the name of the client, empty if the request was not authenticated.
+	Client string
+	// Reason is the reason the request was rejected.
+	Reason string
+}
+
+// Events are the events issued by the Authenticator.
+type Events struct {
+	// RequestRejected is triggered for every request that was rejected.
+	RequestRejected *event.Event[*RejectedRequest]
+}
+
+func newEvents() *Events {
+	return &Events{
+		RequestRejected: event.New[*RejectedRequest](),
+	}
+}
+
+// KeyParameters defines an API key and the limits of the client that uses it.
+type KeyParameters struct {
+	// Name is the unique name of the key.
+	Name string
+	// Key is the secret the client sends with its requests.
+	Key string
+	// RateLimit is the amount of requests per second the client may send, 0 means unlimited.
+	RateLimit float64
+	// Burst is the amount of requests the client may send at once.
+	Burst int
+	// MaxPageSize is the maximum amount of results per page for the client, 0 means the default of the API is used.
+	MaxPageSize int
+}
+
+// jwtClaims are the claims of the JWTs accepted by the Authenticator.
+// The limits are optional, the defaults of the Authenticator are used for missing ones.
+type jwtClaims struct {
+	jwt.StandardClaims
+	RateLimit   *float64 `json:"rateLimit,omitempty"`
+	Burst       *int     `json:"burst,omitempty"`
+	MaxPageSize *int     `json:"maxPageSize,omitempty"`
+}
+
+// limiterEntry is the rate limiter of a client.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Authenticator checks the API keys and JWTs of the requests and enforces the rate limits of the clients.
+// The API keys are read from the config and from the api_keys table of the database.
+type Authenticator struct {
+	*logger.WrappedLogger
+
+	// Events are the events issued by the Authenticator.
+	Events *Events
+
+	store *store
+
+	// configKeys are the clients of the keys of the config, by the hash of their key.
+	configKeys map[string]*Client
+
+	keysLock sync.RWMutex
+	// keys are the clients of the keys of the config and the database, by the hash of their key.
+	keys map[string]*Client
+
+	limitersLock sync.Mutex
+	limiters     map[string]*limiterEntry
+
+	// jwtSecret is the key of the HMAC signature of the JWTs, JWTs are not accepted if it is empty.
+	jwtSecret []byte
+	// jwtDefaults are the limits of the clients authenticated by a JWT without limits in its claims.
+	jwtDefaults Client
+	// jwtMaximums are the highest limits the claims of a JWT can grant, 0 means no maximum.
+	jwtMaximums Client
+	// refreshInterval is the interval in which the keys are read from the database.
+	refreshInterval time.Duration
+}
+
+// WithKeys adds the given API keys, e.g. from the config file.
+func WithKeys(keys []KeyParameters) options.Option[Authenticator] {
+	return func(a *Authenticator) {
+		for _, key := range keys {
+			a.configKeys[HashKey(key.Key)] = &Client{
+				Name:        key.Name,
+				RateLimit:   key.RateLimit,
+				Burst:       key.Burst,
+				MaxPageSize: key.MaxPageSize,
+			}
+		}
+	}
+}
+
+// WithJWT accepts JWTs signed with HS256 and the given secret.
+// The subject of the JWT identifies the client, the given limits apply if the claims do not contain others.
+func WithJWT(secret []byte, rateLimit float64, burst int, maxPageSize int) options.Option[Authenticator] {
+	return func(a *Authenticator) {
+		a.jwtSecret = secret
+		a.jwtDefaults = Client{
+			RateLimit:   rateLimit,
+			Burst:       burst,
+			MaxPageSize: maxPageSize,
+		}
+	}
+}
+
+// WithJWTMaximums limits the rate limit and burst claims of the JWTs and the page size of their clients.
+// A rate limit claim of 0 is limited as well, a JWT can not disable the rate limit if there is a maximum.
+func WithJWTMaximums(rateLimit float64, burst int, maxPageSize int) options.Option[Authenticator] {
+	return func(a *Authenticator) {
+		a.jwtMaximums = Client{
+			RateLimit:   rateLimit,
+			Burst:       burst,
+			MaxPageSize: maxPageSize,
+		}
+	}
+}
+
+// WithRefreshInterval sets the interval in which the keys are read from the database.
+func WithRefreshInterval(interval time.Duration) options.Option[Authenticator] {
+	return func(a *Authenticator) {
+		a.refreshInterval = interval
+	}
+}
+
+// NewAuthenticator creates the table of the API keys in the given database and loads the keys.
+func NewAuthenticator(db *gorm.DB, log *logger.Logger, opts ...options.Option[Authenticator]) (*Authenticator, error) {
+	s, err := newStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	a := options.Apply(&Authenticator{
+		WrappedLogger:   logger.NewWrappedLogger(log),
+		Events:          newEvents(),
+		store:           s,
+		configKeys:      make(map[string]*Client),
+		keys:            make(map[string]*Client),
+		limiters:        make(map[string]*limiterEntry),
+		refreshInterval: time.Minute,
+	}, opts)
+
+	names := make(map[string]struct{}, len(a.configKeys))
+	for _, client := range a.configKeys {
+		if client.Name == "" {
+			return nil, errors.New("API key without name")
+		}
+		if _, exists := names[client.Name]; exists {
+			return nil, errors.Errorf("duplicate API key name: %s", client.Name)
+		}
+		names[client.Name] = struct{}{}
+	}
+	if _, exists := a.configKeys[HashKey("")]; exists {
+		return nil, errors.New("API key without key")
+	}
+
+	if err := a.refreshKeys(); err != nil {
+		return nil, errors.Wrap(err, "loading API keys failed")
+	}
+
+	return a, nil
+}
+
+// Keys returns the amount of API keys that are accepted.
+func (a *Authenticator) Keys() int {
+	a.keysLock.RLock()
+	defer a.keysLock.RUnlock()
+
+	return len(a.keys)
+}
+
+// refreshKeys reads the keys from the database and merges them with the keys of the config.
+// The keys of the config take precedence over keys with the same name in the database.
+func (a *Authenticator) refreshKeys() error {
+	rows, err := a.store.keys()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*Client, len(a.configKeys)+len(rows))
+	names := make(map[string]struct{}, len(keys))
+	for hash, client := range a.configKeys {
+		keys[hash] = client
+		names[client.Name] = struct{}{}
+	}
+
+	for _, row := range rows {
+		if _, exists := names[row.Name]; exists {
+			a.LogWarnf("Ignoring API key %s of the database, a key with the same name exists in the config", row.Name)
+
+			continue
+		}
+		keys[strings.ToLower(row.KeyHash)] = &Client{
+			Name:        row.Name,
+			RateLimit:   row.RateLimit,
+			Burst:       row.Burst,
+			MaxPageSize: row.MaxPageSize,
+		}
+	}
+
+	a.keysLock.Lock()
+	defer a.keysLock.Unlock()
+
+	a.keys = keys
+
+	return nil
+}
+
+// pruneLimiters drops the rate limiters of the clients that were idle long enough to refill their bucket.
+func (a *Authenticator) pruneLimiters(now time.Time) {
+	a.limitersLock.Lock()
+	defer a.limitersLock.Unlock()
+
+	for name, entry := range a.limiters {
+		if now.Sub(entry.lastSeen) > limiterIdleTimeout && entry.limiter.TokensAt(now) >= float64(entry.limiter.Burst()) {
+			delete(a.limiters, name)
+		}
+	}
+}
+
+// Run reads the keys from the database and drops the rate limiters of idle clients until the context is canceled.
+func (a *Authenticator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := a.refreshKeys(); err != nil {
+				a.LogWarnf("Reading API keys from the database failed: %s", err)
+			}
+			a.pruneLimiters(now)
+		}
+	}
+}
+
+// credentials returns the API key or JWT of the request.
+func credentials(c echo.Context) string {
+	if key := c.Request().Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+
+	authorization := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(authorization) > len("bearer ") && strings.EqualFold(authorization[:len("bearer ")], "bearer ") {
+		return strings.TrimSpace(authorization[len("bearer "):])
+	}
+
+	return ""
+}
+
+// authenticate returns the client of the given API key or JWT.
+func (a *Authenticator) authenticate(token string) (*Client, error) {
+	a.keysLock.RLock()
+	client, exists := a.keys[HashKey(token)]
+	a.keysLock.RUnlock()
+	if exists {
+		return client, nil
+	}
+
+	if len(a.jwtSecret) == 0 || strings.Count(token, ".") != 2 {
+		return nil, errors.New("unknown API key")
+	}
+
+	claims := &jwtClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.Errorf("unexpected signing method: %s", token.Method.Alg())
+		}
+
+		return a.jwtSecret, nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "invalid JWT")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("invalid JWT: missing subject")
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, errors.New("invalid JWT: missing expiration")
+	}
+
+	client = &Client{
+		Name:        jwtClientPrefix + claims.Subject,
+		RateLimit:   a.jwtDefaults.RateLimit,
+		Burst:       a.jwtDefaults.Burst,
+		MaxPageSize: a.jwtDefaults.MaxPageSize,
+	}
+	maximums := a.jwtMaximums
+	if claims.RateLimit != nil {
+		client.RateLimit = *claims.RateLimit
+		// a rate limit of 0 is unlimited
+		if maximums.RateLimit > 0 && (client.RateLimit <= 0 || client.RateLimit > maximums.RateLimit) {
+			client.RateLimit = maximums.RateLimit
+		}
+	}
+	if claims.Burst != nil {
+		client.Burst = *claims.Burst
+		if maximums.Burst > 0 && client.Burst > maximums.Burst {
+			client.Burst = maximums.Burst
+		}
+	}
+	if claims.MaxPageSize != nil {
+		client.MaxPageSize = *claims.MaxPageSize
+	}
+	if maximums.MaxPageSize > 0 && client.MaxPageSize > maximums.MaxPageSize {
+		client.MaxPageSize = maximums.MaxPageSize
+	}
+
+	return client, nil
+}
+
+// allow takes a token from the bucket of the client.
+// It returns the time until the next request is allowed if the bucket is empty.
+func (a *Authenticator) allow(client *Client, now time.Time) (bool, time.Duration) {
+	if client.RateLimit <= 0 {
+		return true, 0
+	}
+
+	limit := rate.Limit(client.RateLimit)
+	burst := client.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	a.limitersLock.Lock()
+	entry, exists := a.limiters[client.Name]
+	if !exists {
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit, burst)}
+		a.limiters[client.Name] = entry
+	}
+	entry.lastSeen = now
+	a.limitersLock.Unlock()
+
+	// the limits of a key might have been changed since the limiter was created
+	if entry.limiter.Limit() != limit {
+		entry.limiter.SetLimitAt(now, limit)
+	}
+	if entry.limiter.Burst() != burst {
+		entry.limiter.SetBurstAt(now, burst)
+	}
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (a *Authenticator) reject(clientName string, reason string) {
+	a.Events.RequestRejected.Trigger(&RejectedRequest{
+		Client: clientName,
+		Reason: reason,
+	})
+}
+
+// Middleware rejects the requests without a valid API key or JWT and the requests of clients that exceeded their rate limit.
+// The client of an accepted request is added to the context of the request.
+func (a *Authenticator) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := credentials(c)
+			if token == "" {
+				a.reject("", ReasonMissingCredentials)
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+				return errors.WithMessagef(echo.ErrUnauthorized, "missing API key, use the %s header or a bearer token", HeaderAPIKey)
+			}
+
+			client, err := a.authenticate(token)
+			if err != nil {
+				a.reject("", ReasonInvalidCredentials)
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+				return errors.WithMessage(echo.ErrUnauthorized, err.Error())
+			}
+
+			if allowed, retryAfter := a.allow(client, time.Now()); !allowed {
+				a.reject(client.Name, ReasonRateLimited)
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+				return errors.WithMessagef(echo.ErrTooManyRequests, "rate limit of %g requests per second exceeded", client.RateLimit)
+			}
+
+			c.SetRequest(c.Request().WithContext(WithClient(c.Request().Context(), client)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func newTestAuthenticator() *Authenticator {
+	return &Authenticator{
+		keys: map[string]*Client{
+			HashKey("key"): {Name: "explorer", RateLimit: 50},
+		},
+		limiters:  make(map[string]*limiterEntry),
+		jwtSecret: []byte("secret"),
+		jwtDefaults: Client{
+			RateLimit: 10,
+			Burst:     20,
+		},
+		jwtMaximums: Client{
+			RateLimit:   100,
+			Burst:       200,
+			MaxPageSize: 1000,
+		},
+	}
+}
+
+func signTestJWT(t *testing.T, method jwt.SigningMethod, secret string, claims *jwtClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := newTestAuthenticator()
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	standardClaims := jwt.StandardClaims{Subject: "wallet", ExpiresAt: expiresAt}
+	float := func(f float64) *float64 { return &f }
+	integer := func(i int) *int { return &i }
+
+	tests := []struct {
+		name   string
+		token  string
+		client Client
+	}{
+		{
+			name:   "API key",
+			token:  "key",
+			client: Client{Name: "explorer", RateLimit: 50},
+		},
+		{
+			name:   "JWT with the default limits",
+			token:  signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{StandardClaims: standardClaims}),
+			client: Client{Name: "jwt:wallet", RateLimit: 10, Burst: 20},
+		},
+		{
+			name: "JWT with limits below the maximums",
+			token: signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{
+				StandardClaims: standardClaims,
+				RateLimit:      float(50),
+				Burst:          integer(100),
+				MaxPageSize:    integer(500),
+			}),
+			client: Client{Name: "jwt:wallet", RateLimit: 50, Burst: 100, MaxPageSize: 500},
+		},
+		{
+			name: "JWT with limits above the maximums",
+			token: signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{
+				StandardClaims: standardClaims,
+				RateLimit:      float(1e9),
+				Burst:          integer(1e9),
+				MaxPageSize:    integer(1e9),
+			}),
+			client: Client{Name: "jwt:wallet", RateLimit: 100, Burst: 200, MaxPageSize: 1000},
+		},
+		{
+			name: "JWT without a rate limit",
+			token: signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{
+				StandardClaims: standardClaims,
+				RateLimit:      float(0),
+			}),
+			client: Client{Name: "jwt:wallet", RateLimit: 100, Burst: 20},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			client, err := a.authenticate(test.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *client != test.client {
+				t.Errorf("expected client %+v, got %+v", test.client, *client)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejected(t *testing.T) {
+	a := newTestAuthenticator()
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		message string
+	}{
+		{
+			name:    "unknown API key",
+			token:   "other key",
+			message: "unknown API key",
+		},
+		{
+			name:    "other secret",
+			token:   signTestJWT(t, jwt.SigningMethodHS256, "other secret", &jwtClaims{StandardClaims: jwt.StandardClaims{Subject: "wallet", ExpiresAt: expiresAt}}),
+			message: "signature is invalid",
+		},
+		{
+			name:    "other signing method",
+			token:   signTestJWT(t, jwt.SigningMethodHS512, "secret", &jwtClaims{StandardClaims: jwt.StandardClaims{Subject: "wallet", ExpiresAt: expiresAt}}),
+			message: "unexpected signing method",
+		},
+		{
+			name:    "missing subject",
+			token:   signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: expiresAt}}),
+			message: "missing subject",
+		},
+		{
+			name:    "missing expiration",
+			token:   signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{StandardClaims: jwt.StandardClaims{Subject: "wallet"}}),
+			message: "missing expiration",
+		},
+		{
+			name:    "expired",
+			token:   signTestJWT(t, jwt.SigningMethodHS256, "secret", &jwtClaims{StandardClaims: jwt.StandardClaims{Subject: "wallet", ExpiresAt: time.Now().Add(-time.Hour).Unix()}}),
+			message: "token is expired",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := a.authenticate(test.token)
+			if err == nil {
+				t.Fatal("expected the credentials to be rejected")
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error %q, got %q", test.message, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	contextKeyClient contextKey = iota
+)
+
+// Client is an authenticated client of the API.
+type Client struct {
+	// Name identifies the client, it is the name of the API key or the subject of the JWT.
+	Name string
+	// RateLimit is the amount of requests per second the client may send, 0 means unlimited.
+	RateLimit float64
+	// Burst is the amount of requests the client may send at once.
+	Burst int
+	// MaxPageSize is the maximum amount of results per page for the client, 0 means the default of the API is used.
+	MaxPageSize int
+}
+
+// WithClient returns a copy of the context that contains the given client.
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, contextKeyClient, client)
+}
+
+// ClientFromContext returns the client of the request, nil if the request was not authenticated.
+func ClientFromContext(ctx context.Context) *Client {
+	client, ok := ctx.Value(contextKeyClient).(*Client)
+	if !ok {
+		return nil
+	}
+
+	return client
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"gorm.io/gorm"
+)
+
+// apiKey is an API key stored in the database.
+// Only the hash of the key is stored, so the keys can not be read from the database.
+type apiKey struct {
+	Name string `gorm:"primaryKey;notnull"`
+	// KeyHash is the hex encoded SHA-256 hash of the key.
+	KeyHash     string `gorm:"notnull;uniqueIndex:api_keys_key_hash"`
+	RateLimit   float64
+	Burst       int
+	MaxPageSize int
+}
+
+// HashKey returns the hex encoded SHA-256 hash of an API key, as it is stored in the database.
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// store reads the API keys from the database.
+type store struct {
+	db *gorm.DB
+}
+
+func newStore(db *gorm.DB) (*store, error) {
+	if err := db.AutoMigrate(&apiKey{}); err != nil {
+		return nil, err
+	}
+
+	return &store{db: db}, nil
+}
+
+func (s *store) keys() ([]*apiKey, error) {
+	var rows []*apiKey
+	if err := s.db.Order("name asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -2,7 +2,12 @@ package daemon
 
 const (
 	PriorityDisconnectINX = iota // no dependencies
+	PriorityStopTracing
 	PriorityStopIndexer
+	PriorityStopWebhooks
+	PriorityStopScheduler
+	PriorityStopOutbox
+	PriorityStopAuth
 	PriorityStopIndexerAPI
 	PriorityStopPrometheus
 )
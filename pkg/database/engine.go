@@ -2,8 +2,10 @@ package database
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,14 +32,45 @@ type databaseInfo struct {
 	Engine string `toml:"databaseEngine"`
 }
 
+const (
+	// DefaultSQLiteBusyTimeout is used if no busy timeout is given for SQLite.
+	DefaultSQLiteBusyTimeout = 60 * time.Second
+)
+
 type Params struct {
-	Engine   Engine
-	Path     string
+	Engine Engine
+
+	// SQLite
+	Path        string
+	BusyTimeout time.Duration
+
+	// PostgreSQL
+	// Host can either be a hostname, an IP address or the directory of a unix socket.
 	Host     string
 	Port     uint
 	Database string
 	Username string
 	Password string
+
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or verify-full.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// StatementTimeout is the maximum duration of a statement of the read transactions of the API queries.
+	// It is not part of the connection string, so the writer, the import of the ledger and the index builds are not limited.
+	StatementTimeout time.Duration
+
+	// Connection pool
+	MaxOpenConnections    int
+	MaxIdleConnections    int
+	ConnectionMaxLifetime time.Duration
+	ConnectionMaxIdleTime time.Duration
+
+	// ReadReplicas contains the "host:port" addresses of PostgreSQL read replicas.
+	// The replicas share the database name and credentials with the primary.
+	ReadReplicas []string
 }
 
 // EngineFromString parses an engine from a string.
@@ -161,7 +194,7 @@ func CheckEngine(dbPath string, createDatabaseIfNotExists bool, dbEngine ...Engi
 				//nolint:stylecheck,revive // this error message is shown to the user
 				return EngineUnknown, fmt.Errorf(`database engine does not match the configuration: '%v' != '%v'
 
-If you want to use another database engine, you can use the tool './hornet tool db-migration' to convert the current database.`, dbEngineFromInfoFile, dbEngine[0])
+If you want to use another database engine, you can write a dump of the current database with the tool 'db-export' and load it with the tool 'db-import'.`, dbEngineFromInfoFile, dbEngine[0])
 			}
 		}
 
@@ -228,15 +261,57 @@ func NewWithDefaultSettings(dbParams Params, createDatabaseIfNotExists bool, log
 	//nolint:exhaustive // false positive
 	switch targetEngine {
 	case EngineSQLite, EngineAuto:
+		busyTimeout := dbParams.BusyTimeout
+		if busyTimeout == 0 {
+			busyTimeout = DefaultSQLiteBusyTimeout
+		}
+
 		dbFile := filepath.Join(dbParams.Path, "indexer.db")
-		dbDialector = sqlite.Open(fmt.Sprintf("file:%s?&_journal_mode=WAL&_busy_timeout=60000", dbFile))
+		dbDialector = sqlite.Open(fmt.Sprintf("file:%s?&_journal_mode=WAL&_busy_timeout=%d", dbFile, busyTimeout.Milliseconds()))
 	case EnginePostgreSQL:
-		dsn := fmt.Sprintf("host='%s' user='%s' password='%s' dbname='%s' port=%d", dbParams.Host, dbParams.Username, dbParams.Password, dbParams.Database, dbParams.Port)
-		dbDialector = postgres.Open(dsn)
+		dbDialector = postgres.Open(postgresDSN(dbParams, dbParams.Host, dbParams.Port))
 	default:
 		return nil, EngineUnknown, fmt.Errorf("unknown database engine: %s, supported engines: sqlite, postgres", targetEngine)
 	}
 
+	db, err := openWithDefaultSettings(dbDialector, dbParams, log)
+	if err != nil {
+		return nil, EngineUnknown, err
+	}
+
+	return db, targetEngine, nil
+}
+
+// NewReadReplicasWithDefaultSettings opens a connection to every read replica configured in the given params.
+// Read replicas are only supported for PostgreSQL.
+func NewReadReplicasWithDefaultSettings(dbParams Params, log *logger.Logger) ([]*gorm.DB, error) {
+
+	if len(dbParams.ReadReplicas) == 0 {
+		return nil, nil
+	}
+
+	if dbParams.Engine != EnginePostgreSQL {
+		return nil, fmt.Errorf("read replicas are not supported by database engine: %s", dbParams.Engine)
+	}
+
+	replicas := make([]*gorm.DB, 0, len(dbParams.ReadReplicas))
+	for _, address := range dbParams.ReadReplicas {
+		host, port, err := splitHostPort(address, dbParams.Port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read replica address (%s): %w", address, err)
+		}
+
+		db, err := openWithDefaultSettings(postgres.Open(postgresDSN(dbParams, host, port)), dbParams, log)
+		if err != nil {
+			return nil, fmt.Errorf("opening read replica (%s) failed: %w", address, err)
+		}
+		replicas = append(replicas, db)
+	}
+
+	return replicas, nil
+}
+
+func openWithDefaultSettings(dbDialector gorm.Dialector, dbParams Params, log *logger.Logger) (*gorm.DB, error) {
 	db, err := gorm.Open(dbDialector, &gorm.Config{
 		Logger: gormLogger.New(newLogger(log), gormLogger.Config{
 			SlowThreshold:             100 * time.Millisecond,
@@ -246,8 +321,81 @@ func NewWithDefaultSettings(dbParams Params, createDatabaseIfNotExists bool, log
 		}),
 	})
 	if err != nil {
-		return nil, EngineUnknown, err
+		return nil, err
 	}
 
-	return db, targetEngine, nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// zero values keep the defaults of database/sql
+	if dbParams.MaxOpenConnections > 0 {
+		sqlDB.SetMaxOpenConns(dbParams.MaxOpenConnections)
+	}
+	if dbParams.MaxIdleConnections > 0 {
+		sqlDB.SetMaxIdleConns(dbParams.MaxIdleConnections)
+	}
+	if dbParams.ConnectionMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(dbParams.ConnectionMaxLifetime)
+	}
+	if dbParams.ConnectionMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(dbParams.ConnectionMaxIdleTime)
+	}
+
+	return db, nil
+}
+
+// postgresDSN builds a keyword/value connection string for the given host.
+// A host starting with a slash is interpreted as the directory of a unix socket.
+func postgresDSN(dbParams Params, host string, port uint) string {
+	keyValues := []string{
+		"host=" + quoteDSNValue(host),
+		"port=" + strconv.FormatUint(uint64(port), 10),
+		"user=" + quoteDSNValue(dbParams.Username),
+		"password=" + quoteDSNValue(dbParams.Password),
+		"dbname=" + quoteDSNValue(dbParams.Database),
+	}
+
+	if dbParams.SSLMode != "" {
+		keyValues = append(keyValues, "sslmode="+quoteDSNValue(dbParams.SSLMode))
+	}
+	if dbParams.SSLRootCert != "" {
+		keyValues = append(keyValues, "sslrootcert="+quoteDSNValue(dbParams.SSLRootCert))
+	}
+	if dbParams.SSLCert != "" {
+		keyValues = append(keyValues, "sslcert="+quoteDSNValue(dbParams.SSLCert))
+	}
+	if dbParams.SSLKey != "" {
+		keyValues = append(keyValues, "sslkey="+quoteDSNValue(dbParams.SSLKey))
+	}
+
+	return strings.Join(keyValues, " ")
+}
+
+// quoteDSNValue quotes a value of a keyword/value connection string.
+// Single quotes and backslashes within the value are escaped with a backslash.
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// splitHostPort splits an address of the form "host:port" or "host".
+// The default port is used if the address does not contain a port.
+func splitHostPort(address string, defaultPort uint) (string, uint, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		var addrErr *net.AddrError
+		if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+			return address, defaultPort, nil
+		}
+
+		return "", 0, err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return host, uint(port), nil
 }
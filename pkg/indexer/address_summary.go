@@ -0,0 +1,127 @@
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// AddressSummary contains the amount of outputs that are related to an address.
+type AddressSummary struct {
+	// LedgerIndex is the ledger index at which all counts were taken.
+	LedgerIndex uint32
+	// BasicOutputs is the amount of basic outputs owned by the address.
+	BasicOutputs int64
+	// NFTOutputs is the amount of NFT outputs owned by the address.
+	NFTOutputs int64
+	// AliasOutputsAsStateController is the amount of alias outputs the address is the state controller of.
+	AliasOutputsAsStateController int64
+	// AliasOutputsAsGovernor is the amount of alias outputs the address is the governor of.
+	AliasOutputsAsGovernor int64
+	// FoundryOutputs is the amount of foundry outputs controlled by the (alias) address.
+	FoundryOutputs int64
+
+	// The following counts include basic and NFT outputs.
+
+	// Timelocked is the amount of outputs owned by the address that are still timelocked.
+	Timelocked int64
+	// Expiring is the amount of outputs owned by the address that did not expire yet.
+	Expiring int64
+	// ExpirationReturn is the amount of outputs that return to the address once they expire.
+	ExpirationReturn int64
+	// StorageDepositReturn is the amount of outputs owned by the address that have to return a storage deposit.
+	StorageDepositReturn int64
+	// StorageDepositReturnAddress is the amount of outputs that return a storage deposit to the address.
+	StorageDepositReturnAddress int64
+}
+
+// addressSummaryCount is a count of the address summary together with the query it is taken from.
+type addressSummaryCount struct {
+	count  *int64
+	models []interface{}
+	query  func(query *gorm.DB) *gorm.DB
+}
+
+// AddressSummary counts the outputs that are related to the given address.
+// Timelocks and expirations are pending if they end after the given time.
+// All counts are taken in the same snapshot of the database, so they belong to the same ledger index.
+func (i *Indexer) AddressSummary(ctx context.Context, address iotago.Address, at time.Time) (*AddressSummary, error) {
+	addr, err := addressBytesForAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	outputModels := []interface{}{&basicOutput{}, &nft{}}
+
+	summary := &AddressSummary{}
+	counts := []addressSummaryCount{
+		{&summary.BasicOutputs, []interface{}{&basicOutput{}}, func(query *gorm.DB) *gorm.DB {
+			return query.Where("address = ?", addr[:])
+		}},
+		{&summary.NFTOutputs, []interface{}{&nft{}}, func(query *gorm.DB) *gorm.DB {
+			return query.Where("address = ?", addr[:])
+		}},
+		{&summary.AliasOutputsAsStateController, []interface{}{&alias{}}, func(query *gorm.DB) *gorm.DB {
+			return query.Where("state_controller = ?", addr[:])
+		}},
+		{&summary.AliasOutputsAsGovernor, []interface{}{&alias{}}, func(query *gorm.DB) *gorm.DB {
+			return query.Where("governor = ?", addr[:])
+		}},
+		{&summary.FoundryOutputs, []interface{}{&foundry{}}, func(query *gorm.DB) *gorm.DB {
+			return query.Where("alias_address = ?", addr[:])
+		}},
+		{&summary.Timelocked, outputModels, func(query *gorm.DB) *gorm.DB {
+			return query.Where("address = ? AND timelock_time > ?", addr[:], at)
+		}},
+		{&summary.Expiring, outputModels, func(query *gorm.DB) *gorm.DB {
+			return query.Where("address = ? AND expiration_time > ?", addr[:], at)
+		}},
+		{&summary.ExpirationReturn, outputModels, func(query *gorm.DB) *gorm.DB {
+			return query.Where("expiration_return_address = ? AND expiration_time > ?", addr[:], at)
+		}},
+		{&summary.StorageDepositReturn, outputModels, func(query *gorm.DB) *gorm.DB {
+			return query.Where("address = ? AND storage_deposit_return IS NOT NULL", addr[:])
+		}},
+		{&summary.StorageDepositReturnAddress, outputModels, func(query *gorm.DB) *gorm.DB {
+			return query.Where("storage_deposit_return_address = ?", addr[:])
+		}},
+	}
+
+	if i.maxQueryDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.maxQueryDuration)
+		defer cancel()
+	}
+
+	ts := time.Now()
+	ledgerIndex, err := i.readTransactionWithFallback(ctx, func(tx *gorm.DB) (uint32, error) {
+		for _, c := range counts {
+			*c.count = 0
+			for _, model := range c.models {
+				var count int64
+				if err := c.query(tx.Model(model)).Count(&count).Error; err != nil {
+					return 0, err
+				}
+				*c.count += count
+			}
+		}
+
+		return ledgerIndexFromDatabase(tx), nil
+	})
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			i.Events.QueryTimedOut.Trigger(time.Since(ts))
+
+			return nil, ErrQueryTimeout
+		}
+
+		return nil, err
+	}
+	summary.LedgerIndex = ledgerIndex
+
+	return summary, nil
+}
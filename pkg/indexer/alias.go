@@ -1,20 +1,24 @@
 package indexer
 
 import (
+	"context"
 	"time"
 
+	"gorm.io/gorm"
+
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 type alias struct {
-	AliasID          aliasIDBytes  `gorm:"primaryKey;notnull"`
-	OutputID         outputIDBytes `gorm:"unique;notnull"`
-	NativeTokenCount uint32        `gorm:"notnull;type:integer"`
-	StateController  addressBytes  `gorm:"notnull;index:alias_state_controller"`
-	Governor         addressBytes  `gorm:"notnull;index:alias_governor"`
-	Issuer           addressBytes  `gorm:"index:alias_issuer"`
-	Sender           addressBytes  `gorm:"index:alias_sender"`
-	CreatedAt        time.Time     `gorm:"notnull;index:alias_created_at"`
+	AliasID          aliasIDBytes  `gorm:"primaryKey;notnull" json:"aliasId"`
+	OutputID         outputIDBytes `gorm:"unique;notnull;index:alias_created_at,priority:2;index:alias_state_controller,priority:3;index:alias_governor,priority:3;index:alias_issuer,priority:3;index:alias_sender,priority:3" json:"outputId"`
+	NativeTokenCount uint32        `gorm:"notnull;type:integer" json:"nativeTokenCount"`
+	StateController  addressBytes  `gorm:"notnull;index:alias_state_controller,priority:1" json:"stateController"`
+	Governor         addressBytes  `gorm:"notnull;index:alias_governor,priority:1" json:"governor"`
+	Issuer           addressBytes  `gorm:"index:alias_issuer,priority:1" json:"issuer"`
+	Sender           addressBytes  `gorm:"index:alias_sender,priority:1" json:"sender"`
+	// all indexes end in (created_at, output_id), so they also serve the order of the paginated queries
+	CreatedAt time.Time `gorm:"notnull;index:alias_created_at,priority:1;index:alias_state_controller,priority:2;index:alias_governor,priority:2;index:alias_issuer,priority:2;index:alias_sender,priority:2" json:"createdAt"`
 }
 
 type AliasFilterOptions struct {
@@ -23,16 +27,21 @@ type AliasFilterOptions struct {
 	maxNativeTokenCount *uint32
 	stateController     *iotago.Address
 	governor            *iotago.Address
-	issuer              *iotago.Address
-	sender              *iotago.Address
+	issuer              []iotago.Address
+	notIssuer           []iotago.Address
+	sender              []iotago.Address
+	notSender           []iotago.Address
 	pageSize            uint32
 	cursor              *string
+	backward            bool
 	createdBefore       *time.Time
 	createdAfter        *time.Time
 }
 
 type AliasFilterOption func(*AliasFilterOptions)
 
+type AliasFilterExpression = FilterExpression[AliasFilterOption]
+
 func AliasHasNativeTokens(value bool) AliasFilterOption {
 	return func(args *AliasFilterOptions) {
 		args.hasNativeTokens = &value
@@ -65,13 +74,25 @@ func AliasGovernor(address iotago.Address) AliasFilterOption {
 
 func AliasSender(address iotago.Address) AliasFilterOption {
 	return func(args *AliasFilterOptions) {
-		args.sender = &address
+		args.sender = append(args.sender, address)
+	}
+}
+
+func AliasNotSender(address iotago.Address) AliasFilterOption {
+	return func(args *AliasFilterOptions) {
+		args.notSender = append(args.notSender, address)
 	}
 }
 
 func AliasIssuer(address iotago.Address) AliasFilterOption {
 	return func(args *AliasFilterOptions) {
-		args.issuer = &address
+		args.issuer = append(args.issuer, address)
+	}
+}
+
+func AliasNotIssuer(address iotago.Address) AliasFilterOption {
+	return func(args *AliasFilterOptions) {
+		args.notIssuer = append(args.notIssuer, address)
 	}
 }
 
@@ -84,6 +105,14 @@ func AliasPageSize(pageSize uint32) AliasFilterOption {
 func AliasCursor(cursor string) AliasFilterOption {
 	return func(args *AliasFilterOptions) {
 		args.cursor = &cursor
+		args.backward = false
+	}
+}
+
+func AliasPrevCursor(cursor string) AliasFilterOption {
+	return func(args *AliasFilterOptions) {
+		args.cursor = &cursor
+		args.backward = true
 	}
 }
 
@@ -109,18 +138,15 @@ func aliasFilterOptions(optionalOptions []AliasFilterOption) *AliasFilterOptions
 	return result
 }
 
-func (i *Indexer) AliasOutput(aliasID *iotago.AliasID) *IndexerResult {
+func (i *Indexer) AliasOutput(ctx context.Context, aliasID *iotago.AliasID) *IndexerResult {
 	query := i.db.Model(&alias{}).
 		Where("alias_id = ?", aliasID[:]).
 		Limit(1)
 
-	return i.combineOutputIDFilteredQuery(query, 0, nil)
+	return i.combineOutputIDFilteredQuery(ctx, query, 0, nil, false)
 }
 
-func (i *Indexer) AliasOutputsWithFilters(filter ...AliasFilterOption) *IndexerResult {
-	opts := aliasFilterOptions(filter)
-	query := i.db.Model(&alias{})
-
+func aliasConditions(query *gorm.DB, opts *AliasFilterOptions) (*gorm.DB, error) {
 	if opts.hasNativeTokens != nil {
 		if *opts.hasNativeTokens {
 			query = query.Where("native_token_count > 0")
@@ -140,7 +166,7 @@ func (i *Indexer) AliasOutputsWithFilters(filter ...AliasFilterOption) *IndexerR
 	if opts.stateController != nil {
 		addr, err := addressBytesForAddress(*opts.stateController)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("state_controller = ?", addr[:])
 	}
@@ -148,25 +174,25 @@ func (i *Indexer) AliasOutputsWithFilters(filter ...AliasFilterOption) *IndexerR
 	if opts.governor != nil {
 		addr, err := addressBytesForAddress(*opts.governor)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("governor = ?", addr[:])
 	}
 
-	if opts.sender != nil {
-		addr, err := addressBytesForAddress(*opts.sender)
+	if len(opts.sender) > 0 || len(opts.notSender) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "sender", opts.sender, opts.notSender)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("sender = ?", addr[:])
 	}
 
-	if opts.issuer != nil {
-		addr, err := addressBytesForAddress(*opts.issuer)
+	if len(opts.issuer) > 0 || len(opts.notIssuer) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "issuer", opts.issuer, opts.notIssuer)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("issuer = ?", addr[:])
 	}
 
 	if opts.createdBefore != nil {
@@ -177,5 +203,65 @@ func (i *Indexer) AliasOutputsWithFilters(filter ...AliasFilterOption) *IndexerR
 		query = query.Where("created_at > ?", *opts.createdAfter)
 	}
 
-	return i.combineOutputIDFilteredQuery(query, opts.pageSize, opts.cursor)
+	return query, nil
+}
+
+func (i *Indexer) AliasOutputsWithFilters(ctx context.Context, filter ...AliasFilterOption) *IndexerResult {
+	opts := aliasFilterOptions(filter)
+
+	query, err := aliasConditions(i.db.Model(&alias{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(filtersQueryCost(opts.filterNames()), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query, pageSize, opts.cursor, opts.backward)
+}
+
+// AliasOutputsWithFilterExpression returns the alias outputs matching the given filter expression and filters.
+// Only the paging related filters are useful in combination with an expression, all others can be part of the expression.
+func (i *Indexer) AliasOutputsWithFilterExpression(ctx context.Context, expr *AliasFilterExpression, filter ...AliasFilterOption) *IndexerResult {
+	opts := aliasFilterOptions(filter)
+
+	query, err := aliasConditions(i.db.Model(&alias{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	condition, err := filterExpressionCondition(i.db, expr, func(query *gorm.DB, filter []AliasFilterOption) (*gorm.DB, error) {
+		return aliasConditions(query, aliasFilterOptions(filter))
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(expressionQueryCost(expr, opts.filterNames(), func(filter []AliasFilterOption) []string {
+		return aliasFilterOptions(filter).filterNames()
+	}), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query.Where(condition), pageSize, opts.cursor, opts.backward)
+}
+
+// AliasIDs returns the aliasIDs of the given alias outputs. Outputs that are not unspent anymore are not part of the result.
+func (i *Indexer) AliasIDs(ctx context.Context, outputIDs iotago.OutputIDs) (map[iotago.OutputID]iotago.AliasID, error) {
+	ids, err := i.idsForOutputIDs(ctx, &alias{}, "alias_id", outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[iotago.OutputID]iotago.AliasID, len(ids))
+	for outputID, idBytes := range ids {
+		id := iotago.AliasID{}
+		copy(id[:], idBytes)
+		result[outputID] = id
+	}
+
+	return result, nil
 }
@@ -1,30 +1,35 @@
 package indexer
 
 import (
+	"context"
 	"time"
 
+	"gorm.io/gorm"
+
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 type basicOutput struct {
-	OutputID                    outputIDBytes `gorm:"primaryKey;notnull"`
-	NativeTokenCount            uint32        `gorm:"notnull;type:integer"`
-	Sender                      addressBytes  `gorm:"index:basic_outputs_sender_tag"`
-	Tag                         []byte        `gorm:"index:basic_outputs_sender_tag"`
-	Address                     addressBytes  `gorm:"notnull;index:basic_outputs_address"`
-	StorageDepositReturn        *uint64
-	StorageDepositReturnAddress addressBytes `gorm:"index:basic_outputs_storage_deposit_return_address"`
-	TimelockTime                *time.Time
-	ExpirationTime              *time.Time
-	ExpirationReturnAddress     addressBytes `gorm:"index:basic_outputs_expiration_return_address"`
-	CreatedAt                   time.Time    `gorm:"notnull;index:basic_outputs_created_at"`
+	OutputID                    outputIDBytes `gorm:"primaryKey;notnull;index:basic_outputs_created_at,priority:2;index:basic_outputs_address,priority:3;index:basic_outputs_sender,priority:3;index:basic_outputs_sender_tag,priority:4;index:basic_outputs_tag,priority:3;index:basic_outputs_storage_deposit_return_address,priority:3;index:basic_outputs_expiration_return_address,priority:3" json:"outputId"`
+	NativeTokenCount            uint32        `gorm:"notnull;type:integer" json:"nativeTokenCount"`
+	Sender                      addressBytes  `gorm:"index:basic_outputs_sender,priority:1;index:basic_outputs_sender_tag,priority:1" json:"sender"`
+	Tag                         []byte        `gorm:"index:basic_outputs_sender_tag,priority:2;index:basic_outputs_tag,priority:1" json:"tag"`
+	Address                     addressBytes  `gorm:"notnull;index:basic_outputs_address,priority:1" json:"address"`
+	StorageDepositReturn        *uint64       `json:"storageDepositReturn"`
+	StorageDepositReturnAddress addressBytes  `gorm:"index:basic_outputs_storage_deposit_return_address,priority:1" json:"storageDepositReturnAddress"`
+	TimelockTime                *time.Time    `gorm:"index:basic_outputs_timelock_time" json:"timelockTime"`
+	ExpirationTime              *time.Time    `gorm:"index:basic_outputs_expiration_time" json:"expirationTime"`
+	ExpirationReturnAddress     addressBytes  `gorm:"index:basic_outputs_expiration_return_address,priority:1" json:"expirationReturnAddress"`
+	// all indexes end in (created_at, output_id), so they also serve the order of the paginated queries
+	CreatedAt time.Time `gorm:"notnull;index:basic_outputs_created_at,priority:1;index:basic_outputs_address,priority:2;index:basic_outputs_sender,priority:2;index:basic_outputs_sender_tag,priority:3;index:basic_outputs_tag,priority:2;index:basic_outputs_storage_deposit_return_address,priority:2;index:basic_outputs_expiration_return_address,priority:2" json:"createdAt"`
 }
 
 type BasicOutputFilterOptions struct {
 	hasNativeTokens                  *bool
 	minNativeTokenCount              *uint32
 	maxNativeTokenCount              *uint32
-	unlockableByAddress              *iotago.Address
+	unlockableByAddress              []iotago.Address
+	notUnlockableByAddress           []iotago.Address
 	hasStorageDepositReturnCondition *bool
 	storageDepositReturnAddress      *iotago.Address
 	hasExpirationCondition           *bool
@@ -34,16 +39,23 @@ type BasicOutputFilterOptions struct {
 	hasTimelockCondition             *bool
 	timelockedBefore                 *time.Time
 	timelockedAfter                  *time.Time
-	sender                           *iotago.Address
-	tag                              []byte
+	sender                           []iotago.Address
+	notSender                        []iotago.Address
+	tag                              [][]byte
+	notTag                           [][]byte
+	tagPrefix                        [][]byte
+	tagContains                      [][]byte
 	pageSize                         uint32
 	cursor                           *string
+	backward                         bool
 	createdBefore                    *time.Time
 	createdAfter                     *time.Time
 }
 
 type BasicOutputFilterOption func(*BasicOutputFilterOptions)
 
+type BasicOutputFilterExpression = FilterExpression[BasicOutputFilterOption]
+
 func BasicOutputHasNativeTokens(value bool) BasicOutputFilterOption {
 	return func(args *BasicOutputFilterOptions) {
 		args.hasNativeTokens = &value
@@ -64,7 +76,13 @@ func BasicOutputMaxNativeTokenCount(value uint32) BasicOutputFilterOption {
 
 func BasicOutputUnlockableByAddress(address iotago.Address) BasicOutputFilterOption {
 	return func(args *BasicOutputFilterOptions) {
-		args.unlockableByAddress = &address
+		args.unlockableByAddress = append(args.unlockableByAddress, address)
+	}
+}
+
+func BasicOutputNotUnlockableByAddress(address iotago.Address) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		args.notUnlockableByAddress = append(args.notUnlockableByAddress, address)
 	}
 }
 
@@ -124,13 +142,45 @@ func BasicOutputExpirationReturnAddress(address iotago.Address) BasicOutputFilte
 
 func BasicOutputSender(address iotago.Address) BasicOutputFilterOption {
 	return func(args *BasicOutputFilterOptions) {
-		args.sender = &address
+		args.sender = append(args.sender, address)
+	}
+}
+
+func BasicOutputNotSender(address iotago.Address) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		args.notSender = append(args.notSender, address)
 	}
 }
 
 func BasicOutputTag(tag []byte) BasicOutputFilterOption {
 	return func(args *BasicOutputFilterOptions) {
-		args.tag = tag
+		if len(tag) > 0 {
+			args.tag = append(args.tag, tag)
+		}
+	}
+}
+
+func BasicOutputNotTag(tag []byte) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		if len(tag) > 0 {
+			args.notTag = append(args.notTag, tag)
+		}
+	}
+}
+
+func BasicOutputTagPrefix(prefix []byte) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		if len(prefix) > 0 {
+			args.tagPrefix = append(args.tagPrefix, prefix)
+		}
+	}
+}
+
+func BasicOutputTagContains(substring string) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		if len(substring) > 0 {
+			args.tagContains = append(args.tagContains, []byte(substring))
+		}
 	}
 }
 
@@ -143,6 +193,14 @@ func BasicOutputPageSize(pageSize uint32) BasicOutputFilterOption {
 func BasicOutputCursor(cursor string) BasicOutputFilterOption {
 	return func(args *BasicOutputFilterOptions) {
 		args.cursor = &cursor
+		args.backward = false
+	}
+}
+
+func BasicOutputPrevCursor(cursor string) BasicOutputFilterOption {
+	return func(args *BasicOutputFilterOptions) {
+		args.cursor = &cursor
+		args.backward = true
 	}
 }
 
@@ -168,10 +226,7 @@ func basicOutputFilterOptions(optionalOptions []BasicOutputFilterOption) *BasicO
 	return result
 }
 
-func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *IndexerResult {
-	opts := basicOutputFilterOptions(filters)
-	query := i.db.Model(&basicOutput{})
-
+func basicOutputConditions(query *gorm.DB, opts *BasicOutputFilterOptions) (*gorm.DB, error) {
 	if opts.hasNativeTokens != nil {
 		if *opts.hasNativeTokens {
 			query = query.Where("native_token_count > 0")
@@ -188,12 +243,12 @@ func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *I
 		query = query.Where("native_token_count <= ?", *opts.maxNativeTokenCount)
 	}
 
-	if opts.unlockableByAddress != nil {
-		addr, err := addressBytesForAddress(*opts.unlockableByAddress)
+	if len(opts.unlockableByAddress) > 0 || len(opts.notUnlockableByAddress) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "address", opts.unlockableByAddress, opts.notUnlockableByAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("address = ?", addr[:])
 	}
 
 	if opts.hasStorageDepositReturnCondition != nil {
@@ -207,7 +262,7 @@ func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *I
 	if opts.storageDepositReturnAddress != nil {
 		addr, err := addressBytesForAddress(*opts.storageDepositReturnAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("storage_deposit_return_address = ?", addr[:])
 	}
@@ -223,17 +278,17 @@ func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *I
 	if opts.expirationReturnAddress != nil {
 		addr, err := addressBytesForAddress(*opts.expirationReturnAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("expiration_return_address = ?", addr[:])
 	}
 
 	if opts.expiresBefore != nil {
-		query = query.Where("expiration_time < ?", *opts.expiresBefore)
+		query = filterByUnlockConditionTime(query, "expiration_time < ?", *opts.expiresBefore)
 	}
 
 	if opts.expiresAfter != nil {
-		query = query.Where("expiration_time > ?", *opts.expiresAfter)
+		query = filterByUnlockConditionTime(query, "expiration_time > ?", *opts.expiresAfter)
 	}
 
 	if opts.hasTimelockCondition != nil {
@@ -245,24 +300,24 @@ func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *I
 	}
 
 	if opts.timelockedBefore != nil {
-		query = query.Where("timelock_time < ?", *opts.timelockedBefore)
+		query = filterByUnlockConditionTime(query, "timelock_time < ?", *opts.timelockedBefore)
 	}
 
 	if opts.timelockedAfter != nil {
-		query = query.Where("timelock_time > ?", *opts.timelockedAfter)
+		query = filterByUnlockConditionTime(query, "timelock_time > ?", *opts.timelockedAfter)
 	}
 
-	if opts.sender != nil {
-		addr, err := addressBytesForAddress(*opts.sender)
+	if len(opts.sender) > 0 || len(opts.notSender) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "sender", opts.sender, opts.notSender)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("sender = ?", addr[:])
 	}
 
-	if opts.tag != nil && len(opts.tag) > 0 {
-		query = query.Where("tag = ?", opts.tag)
-	}
+	query = filterByValues(query, "tag", opts.tag, opts.notTag)
+	query = filterByPrefixes(query, "tag", opts.tagPrefix)
+	query = filterBySubstrings(query, "tag", opts.tagContains)
 
 	if opts.createdBefore != nil {
 		query = query.Where("created_at < ?", *opts.createdBefore)
@@ -272,5 +327,48 @@ func (i *Indexer) BasicOutputsWithFilters(filters ...BasicOutputFilterOption) *I
 		query = query.Where("created_at > ?", *opts.createdAfter)
 	}
 
-	return i.combineOutputIDFilteredQuery(query, opts.pageSize, opts.cursor)
+	return query, nil
+}
+
+func (i *Indexer) BasicOutputsWithFilters(ctx context.Context, filters ...BasicOutputFilterOption) *IndexerResult {
+	opts := basicOutputFilterOptions(filters)
+
+	query, err := basicOutputConditions(i.db.Model(&basicOutput{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(filtersQueryCost(opts.filterNames()), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query, pageSize, opts.cursor, opts.backward)
+}
+
+// BasicOutputsWithFilterExpression returns the basic outputs matching the given filter expression and filters.
+// Only the paging related filters are useful in combination with an expression, all others can be part of the expression.
+func (i *Indexer) BasicOutputsWithFilterExpression(ctx context.Context, expr *BasicOutputFilterExpression, filters ...BasicOutputFilterOption) *IndexerResult {
+	opts := basicOutputFilterOptions(filters)
+
+	query, err := basicOutputConditions(i.db.Model(&basicOutput{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	condition, err := filterExpressionCondition(i.db, expr, func(query *gorm.DB, filters []BasicOutputFilterOption) (*gorm.DB, error) {
+		return basicOutputConditions(query, basicOutputFilterOptions(filters))
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(expressionQueryCost(expr, opts.filterNames(), func(filters []BasicOutputFilterOption) []string {
+		return basicOutputFilterOptions(filters).filterNames()
+	}), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query.Where(condition), pageSize, opts.cursor, opts.backward)
 }
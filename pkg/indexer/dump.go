@@ -0,0 +1,408 @@
+package indexer
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const (
+	// DumpFormat identifies the files written by WriteDump.
+	DumpFormat = "inx-indexer-dump"
+	// DumpVersion is the version of the dump format, it changes if the layout of the file or the JSON names of the columns change.
+	// Changes of the tables are covered by the database version of the status.
+	DumpVersion uint32 = 2
+
+	// dumpBatchSize is the amount of rows that are inserted at once while a dump is loaded.
+	dumpBatchSize = 1000
+)
+
+var (
+	// ErrInvalidDump is returned if a file is not a dump of the indexer or it is incomplete.
+	ErrInvalidDump = errors.New("invalid dump")
+)
+
+// DumpTable is a table of a dump.
+type DumpTable struct {
+	// Name is the name of the table.
+	Name string `json:"name"`
+	// Rows is the amount of rows of the table in the dump.
+	Rows int64 `json:"rows"`
+}
+
+// DumpHeader is the first line of a dump.
+// It is followed by a line with the JSON encoded row for every row of the tables, in the order of the tables.
+type DumpHeader struct {
+	// Format is always DumpFormat.
+	Format string `json:"format"`
+	// Version is the version of the dump format.
+	Version uint32 `json:"version"`
+	// CreatedAt is the time the dump was written.
+	CreatedAt time.Time `json:"createdAt"`
+	// Engine is the database engine the dump was written from.
+	Engine string `json:"engine"`
+	// LedgerIndex is the ledger index all rows of the dump were read at.
+	LedgerIndex uint32 `json:"ledgerIndex"`
+	// ProtocolVersion is the protocol version of the network.
+	ProtocolVersion byte `json:"protocolVersion"`
+	// NetworkName is the name of the network.
+	NetworkName string `json:"networkName"`
+	// DatabaseVersion is the version of the tables, a dump can only be loaded by an indexer with the same version.
+	DatabaseVersion uint32 `json:"databaseVersion"`
+	// Tables are the tables of the dump in the order of their rows.
+	Tables []*DumpTable `json:"tables"`
+	// ExcludedTables are the tables of the database that are not part of the dump.
+	ExcludedTables []string `json:"excludedTables"`
+}
+
+// dumpExcludedTables are the tables of the other components that share the database with the indexer.
+// They contain the hashes of the API keys and the secrets of the webhooks, which must not end up in a dump that is used to seed
+// other instances, and webhook deliveries that belong to the hooks. LoadDump rejects dumps that contain them.
+var dumpExcludedTables = []string{"api_keys", "webhooks", "webhook_deliveries"}
+
+// dumpTable writes and loads the rows of a table of the dump.
+// The rows are encoded with the JSON names of the fields of the model, so every field needs a json tag.
+type dumpTable struct {
+	model interface{}
+	// optional tables are only written if they exist in the database.
+	optional bool
+	// write writes all rows of the table as JSON lines and returns the amount of written rows.
+	write func(tx *gorm.DB, encoder *json.Encoder) (int64, error)
+	// load inserts the given amount of rows read from the decoder.
+	load func(db *gorm.DB, decoder *json.Decoder, rows int64) error
+}
+
+func newDumpTable[T any](optional bool) *dumpTable {
+	return &dumpTable{
+		model:    new(T),
+		optional: optional,
+		write: func(tx *gorm.DB, encoder *json.Encoder) (int64, error) {
+			rows, err := tx.Model(new(T)).Rows()
+			if err != nil {
+				return 0, err
+			}
+			defer rows.Close()
+
+			var count int64
+			for rows.Next() {
+				row := new(T)
+				if err := tx.ScanRows(rows, row); err != nil {
+					return 0, err
+				}
+
+				if err := encoder.Encode(row); err != nil {
+					return 0, err
+				}
+				count++
+			}
+
+			return count, rows.Err()
+		},
+		load: func(db *gorm.DB, decoder *json.Decoder, rows int64) error {
+			batch := make([]*T, 0, dumpBatchSize)
+			for loaded := int64(0); loaded < rows; loaded++ {
+				row := new(T)
+				if err := decoder.Decode(row); err != nil {
+					return errors.Wrapf(ErrInvalidDump, "reading row %d failed: %s", loaded+1, err)
+				}
+				localTimes(row)
+
+				batch = append(batch, row)
+				if len(batch) < dumpBatchSize && loaded+1 < rows {
+					continue
+				}
+
+				if err := db.Create(&batch).Error; err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+
+			return nil
+		},
+	}
+}
+
+// dumpTables are the tables of a dump besides the status, in the order they are written.
+// The outbox is part of the dump if it was enabled.
+var dumpTables = []*dumpTable{
+	newDumpTable[basicOutput](false),
+	newDumpTable[nft](false),
+	newDumpTable[foundry](false),
+	newDumpTable[alias](false),
+	newDumpTable[outboxEntry](true),
+}
+
+// localTimes converts the times of a loaded row to the local time zone, which is used for all times the indexer writes.
+// SQLite compares the times as text, so all of them have to be stored in the same zone.
+func localTimes(row interface{}) {
+	value := reflect.ValueOf(row).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		switch field := value.Field(i).Addr().Interface().(type) {
+		case *time.Time:
+			*field = field.Local()
+		case **time.Time:
+			if *field != nil {
+				t := (*field).Local()
+				*field = &t
+			}
+		}
+	}
+}
+
+func (i *Indexer) dumpTableName(table *dumpTable) (string, error) {
+	stmt := &gorm.Statement{DB: i.db}
+	if err := stmt.Parse(table.model); err != nil {
+		return "", err
+	}
+
+	return stmt.Schema.Table, nil
+}
+
+// WriteDump writes all tables and the status of the indexer to w as gzip compressed JSON lines.
+// The rows are read from a single snapshot of the database, so the dump matches the ledger index of the status.
+// The dump does not depend on the database engine, it can be loaded into every engine with LoadDump.
+func (i *Indexer) WriteDump(ctx context.Context, w io.Writer, onTable func(table *DumpTable)) (*DumpHeader, error) {
+	compressor := gzip.NewWriter(w)
+	encoder := json.NewEncoder(compressor)
+
+	var header *DumpHeader
+	if _, err := readTransaction(ctx, i.db, func(tx *gorm.DB) (uint32, error) {
+		status, err := statusFromDatabase(tx)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return 0, errors.New("the indexer contains no ledger, the import of the initial ledger did not finish")
+			}
+
+			return 0, err
+		}
+
+		header = &DumpHeader{
+			Format:          DumpFormat,
+			Version:         DumpVersion,
+			CreatedAt:       time.Now().UTC(),
+			Engine:          string(i.engine),
+			LedgerIndex:     status.LedgerIndex,
+			ProtocolVersion: status.ProtocolVersion,
+			NetworkName:     status.NetworkName,
+			DatabaseVersion: status.DatabaseVersion,
+		}
+
+		tables := make([]*dumpTable, 0, len(dumpTables))
+		for _, table := range dumpTables {
+			if table.optional && !tx.Migrator().HasTable(table.model) {
+				continue
+			}
+
+			name, err := i.dumpTableName(table)
+			if err != nil {
+				return 0, err
+			}
+
+			// the amounts are part of the header, so an incomplete dump is detected while it is loaded
+			var rows int64
+			if err := tx.Model(table.model).Count(&rows).Error; err != nil {
+				return 0, err
+			}
+
+			tables = append(tables, table)
+			header.Tables = append(header.Tables, &DumpTable{Name: name, Rows: rows})
+		}
+
+		header.ExcludedTables = make([]string, 0, len(dumpExcludedTables))
+		for _, name := range dumpExcludedTables {
+			if tx.Migrator().HasTable(name) {
+				header.ExcludedTables = append(header.ExcludedTables, name)
+			}
+		}
+
+		if err := encoder.Encode(header); err != nil {
+			return 0, err
+		}
+
+		for index, table := range tables {
+			rows, err := table.write(tx, encoder)
+			if err != nil {
+				return 0, errors.Wrapf(err, "writing table %s failed", header.Tables[index].Name)
+			}
+			if rows != header.Tables[index].Rows {
+				return 0, errors.Errorf("table %s changed while it was written: %d rows instead of %d", header.Tables[index].Name, rows, header.Tables[index].Rows)
+			}
+
+			if onTable != nil {
+				onTable(header.Tables[index])
+			}
+		}
+
+		return status.LedgerIndex, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// ReadDumpHeader reads the header of a dump and checks whether it is a dump of a supported version.
+func ReadDumpHeader(r io.Reader) (*DumpHeader, *json.Decoder, error) {
+	decompressor, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, errors.Wrapf(ErrInvalidDump, "reading the gzip header failed: %s", err)
+	}
+
+	decoder := json.NewDecoder(decompressor)
+	header := &DumpHeader{}
+	if err := decoder.Decode(header); err != nil {
+		return nil, nil, errors.Wrapf(ErrInvalidDump, "reading the header failed: %s", err)
+	}
+
+	if header.Format != DumpFormat {
+		return nil, nil, errors.Wrapf(ErrInvalidDump, "unknown format: %s", header.Format)
+	}
+	if header.Version != DumpVersion {
+		return nil, nil, errors.Wrapf(ErrInvalidDump, "unsupported version: %d, supported version: %d", header.Version, DumpVersion)
+	}
+
+	return header, decoder, nil
+}
+
+func (i *Indexer) checkDumpTableEmpty(ctx context.Context, table *dumpTable, dumped *DumpTable) error {
+	if dumped.Rows == 0 {
+		return nil
+	}
+
+	if table.optional && !i.db.Migrator().HasTable(table.model) {
+		return nil
+	}
+
+	var rows int64
+	if err := i.db.WithContext(ctx).Model(table.model).Count(&rows).Error; err != nil {
+		return err
+	}
+	if rows > 0 {
+		return errors.Errorf("table %s is not empty, the rows of the dump can not be loaded into it", dumped.Name)
+	}
+
+	return nil
+}
+
+// CheckDumpOutbox checks whether the outbox messages of the dump can be loaded.
+// The outbox is kept if the indexer is cleared, so its unpublished messages must not be mixed with the ones of the dump.
+func (i *Indexer) CheckDumpOutbox(ctx context.Context, header *DumpHeader) error {
+	for _, table := range dumpTables {
+		if !table.optional {
+			continue
+		}
+
+		name, err := i.dumpTableName(table)
+		if err != nil {
+			return err
+		}
+
+		for _, dumped := range header.Tables {
+			if dumped.Name != name {
+				continue
+			}
+
+			if err := i.checkDumpTableEmpty(ctx, table, dumped); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadDump loads a dump written by WriteDump into the tables of the indexer, which must be empty if the dump contains rows for them.
+// The indexes are dropped while the rows are inserted and rebuilt afterwards.
+// The status is written last, so a failed load leaves an indexer without ledger, which imports the ledger again on start.
+func (i *Indexer) LoadDump(ctx context.Context, header *DumpHeader, decoder *json.Decoder, onTable func(table *DumpTable)) error {
+	tablesByName := make(map[string]*dumpTable, len(dumpTables))
+	for _, table := range dumpTables {
+		name, err := i.dumpTableName(table)
+		if err != nil {
+			return err
+		}
+		tablesByName[name] = table
+	}
+
+	for _, dumped := range header.Tables {
+		for _, excluded := range dumpExcludedTables {
+			if dumped.Name == excluded {
+				return errors.Wrapf(ErrInvalidDump, "table %s must not be part of a dump", dumped.Name)
+			}
+		}
+
+		table, exists := tablesByName[dumped.Name]
+		if !exists {
+			return errors.Wrapf(ErrInvalidDump, "unknown table: %s", dumped.Name)
+		}
+
+		if table.optional {
+			if err := i.db.AutoMigrate(table.model); err != nil {
+				return err
+			}
+		}
+
+		if err := i.checkDumpTableEmpty(ctx, table, dumped); err != nil {
+			return err
+		}
+	}
+
+	if err := i.DropIndexes(); err != nil {
+		return err
+	}
+
+	// use a session without logger and hooks to reduce the amount of work that needs to be done by gorm.
+	dbSession := i.db.WithContext(ctx).Session(&gorm.Session{
+		SkipHooks:              true,
+		SkipDefaultTransaction: true,
+		Logger:                 gormLogger.Discard,
+	})
+
+	for _, dumped := range header.Tables {
+		if err := tablesByName[dumped.Name].load(dbSession, decoder, dumped.Rows); err != nil {
+			return errors.Wrapf(err, "loading table %s failed", dumped.Name)
+		}
+
+		if onTable != nil {
+			onTable(dumped)
+		}
+	}
+
+	var trailing json.RawMessage
+	if err := decoder.Decode(&trailing); !errors.Is(err, io.EOF) {
+		return errors.Wrap(ErrInvalidDump, "the dump contains more rows than its header")
+	}
+
+	if err := i.AutoMigrate(); err != nil {
+		return err
+	}
+
+	if err := i.Analyze(); err != nil {
+		return err
+	}
+
+	status := &Status{
+		ID:              1,
+		LedgerIndex:     header.LedgerIndex,
+		ProtocolVersion: header.ProtocolVersion,
+		NetworkName:     header.NetworkName,
+		DatabaseVersion: header.DatabaseVersion,
+	}
+
+	return i.db.WithContext(ctx).Clauses(clause.OnConflict{
+		UpdateAll: true,
+	}).Create(status).Error
+}
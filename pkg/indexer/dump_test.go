@@ -0,0 +1,167 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/iotaledger/inx-indexer/pkg/database"
+)
+
+// dumpTableRows returns the rows of a table as JSON lines, ordered by the primary key.
+func dumpTableRows(t *testing.T, idx *Indexer, table *dumpTable) []string {
+	t.Helper()
+
+	stmt := &gorm.Statement{DB: idx.db}
+	if err := stmt.Parse(table.model); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := table.write(idx.db.Order(stmt.Schema.PrioritizedPrimaryField.DBName), json.NewEncoder(&buffer)); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSpace(buffer.String()), "\n")
+}
+
+func writeTestDump(t *testing.T, idx *Indexer) (*DumpHeader, []byte) {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	header, err := idx.WriteDump(context.Background(), &buffer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return header, buffer.Bytes()
+}
+
+func loadTestDump(idx *Indexer, dump []byte) error {
+	header, decoder, err := ReadDumpHeader(bytes.NewReader(dump))
+	if err != nil {
+		return err
+	}
+
+	return idx.LoadDump(context.Background(), header, decoder, nil)
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	source := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	if err := source.EnableOutbox(); err != nil {
+		t.Fatal(err)
+	}
+	// more basic outputs than fit into a batch of the load
+	generateTestLedger(t, source, 2*dumpBatchSize+100, 6)
+	if err := source.db.Create(&outboxEntry{MilestoneIndex: testLedgerIndex + 1, Payload: []byte(`{"milestoneIndex":1001}`)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// a table of another component that shares the database
+	if err := source.db.Exec("CREATE TABLE api_keys (name TEXT PRIMARY KEY, key_hash TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	header, dump := writeTestDump(t, source)
+	if !reflect.DeepEqual(header.ExcludedTables, []string{"api_keys"}) {
+		t.Errorf("expected the excluded table api_keys, got %v", header.ExcludedTables)
+	}
+
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			target := newTestIndexer(t, dbParams)
+			if err := loadTestDump(target, dump); err != nil {
+				t.Fatal(err)
+			}
+
+			sourceStatus, err := source.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			targetStatus, err := target.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *targetStatus != *sourceStatus {
+				t.Errorf("expected status %+v, got %+v", sourceStatus, targetStatus)
+			}
+
+			for index, table := range dumpTables {
+				name := header.Tables[index].Name
+				expected := dumpTableRows(t, source, table)
+				rows := dumpTableRows(t, target, table)
+				if int64(len(rows)) != header.Tables[index].Rows {
+					t.Errorf("expected %d rows in table %s, got %d", header.Tables[index].Rows, name, len(rows))
+				}
+				if !reflect.DeepEqual(rows, expected) {
+					t.Errorf("expected the rows of table %s to be identical", name)
+				}
+			}
+
+			// the tables are not empty anymore
+			if err := loadTestDump(target, dump); err == nil {
+				t.Error("expected the dump not to be loaded into tables with rows")
+			}
+		})
+	}
+}
+
+func TestDumpInvalid(t *testing.T) {
+	source := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	generateTestLedger(t, source, 100, 7)
+	_, dump := writeTestDump(t, source)
+
+	t.Run("truncated", func(t *testing.T) {
+		target := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+		if err := loadTestDump(target, dump[:len(dump)/2]); err == nil {
+			t.Error("expected a truncated dump to be rejected")
+		}
+	})
+
+	t.Run("excluded table", func(t *testing.T) {
+		header, decoder, err := ReadDumpHeader(bytes.NewReader(dump))
+		if err != nil {
+			t.Fatal(err)
+		}
+		header.Tables = append(header.Tables, &DumpTable{Name: "webhooks", Rows: 1})
+
+		target := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+		if err := target.LoadDump(context.Background(), header, decoder, nil); !errors.Is(err, ErrInvalidDump) {
+			t.Errorf("expected a dump with the webhooks to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("not a dump", func(t *testing.T) {
+		if _, _, err := ReadDumpHeader(strings.NewReader("garbage")); !errors.Is(err, ErrInvalidDump) {
+			t.Errorf("expected an invalid dump, got %v", err)
+		}
+	})
+}
+
+// TestDumpTableModels checks that the rows of the dump do not depend on the names of the Go fields,
+// and that the excluded tables match the table names gorm derives for the models of the other components.
+func TestDumpTableModels(t *testing.T) {
+	for _, table := range dumpTables {
+		model := reflect.TypeOf(table.model).Elem()
+		for i := 0; i < model.NumField(); i++ {
+			field := model.Field(i)
+			if name := field.Tag.Get("json"); name == "" || name == "-" {
+				t.Errorf("expected a json tag for field %s of %s", field.Name, model.Name())
+			}
+		}
+	}
+
+	naming := schema.NamingStrategy{}
+	for index, model := range []string{"apiKey", "webhook", "webhookDelivery"} {
+		if name := naming.TableName(model); name != dumpExcludedTables[index] {
+			t.Errorf("expected the excluded table %s for model %s, got %s", name, model, dumpExcludedTables[index])
+		}
+	}
+}
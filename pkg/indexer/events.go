@@ -0,0 +1,26 @@
+package indexer
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+)
+
+// Events are the events issued by the Indexer.
+type Events struct {
+	// QueryTimedOut is triggered with the elapsed time if a query exceeded the maximum query duration.
+	QueryTimedOut *event.Event[time.Duration]
+	// LedgerUpdated is triggered after the changes of a milestone were committed to the database.
+	LedgerUpdated *event.Event[*nodebridge.LedgerUpdate]
+	// LedgerUpdateDuration is triggered with the time it took to commit the changes of a milestone.
+	LedgerUpdateDuration *event.Event[time.Duration]
+}
+
+func newEvents() *Events {
+	return &Events{
+		QueryTimedOut:        event.New[time.Duration](),
+		LedgerUpdated:        event.New[*nodebridge.LedgerUpdate](),
+		LedgerUpdateDuration: event.New[time.Duration](),
+	}
+}
@@ -0,0 +1,259 @@
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// errReadReplicaLagging is returned by an export on a read replica that lags behind the primary.
+	errReadReplicaLagging = errors.New("read replica is lagging behind")
+)
+
+// ExportedOutput is an unspent output together with the indexed fields of its table.
+// Fields that do not exist for the type of the output or are not set are nil.
+type ExportedOutput struct {
+	OutputID   iotago.OutputID
+	OutputType iotago.OutputType
+	CreatedAt  time.Time
+	// ID is the alias, NFT or foundry ID of the output, nil for basic outputs.
+	ID                          []byte
+	NativeTokenCount            uint32
+	Address                     iotago.Address
+	StateController             iotago.Address
+	Governor                    iotago.Address
+	AliasAddress                iotago.Address
+	Issuer                      iotago.Address
+	Sender                      iotago.Address
+	Tag                         []byte
+	StorageDepositReturn        *uint64
+	StorageDepositReturnAddress iotago.Address
+	TimelockTime                *time.Time
+	ExpirationTime              *time.Time
+	ExpirationReturnAddress     iotago.Address
+}
+
+// ExportWriter receives the outputs of an export.
+type ExportWriter interface {
+	// Begin is called with the ledger index of the export before the first output is written.
+	Begin(ledgerIndex uint32) error
+	// Write is called for every output in the order of the cursors.
+	Write(output *ExportedOutput) error
+}
+
+// exportRow is a row of any of the output tables, the columns that do not exist in a table stay empty.
+type exportRow struct {
+	OutputID                    outputIDBytes
+	AliasID                     aliasIDBytes
+	NFTID                       nftIDBytes
+	FoundryID                   foundryIDBytes
+	NativeTokenCount            uint32
+	Address                     addressBytes
+	StateController             addressBytes
+	Governor                    addressBytes
+	AliasAddress                addressBytes
+	Issuer                      addressBytes
+	Sender                      addressBytes
+	Tag                         []byte
+	StorageDepositReturn        *uint64
+	StorageDepositReturnAddress addressBytes
+	TimelockTime                *time.Time
+	ExpirationTime              *time.Time
+	ExpirationReturnAddress     addressBytes
+	CreatedAt                   time.Time
+}
+
+// optionalAddressFromBytes deserializes an address column that might be empty.
+func optionalAddressFromBytes(addr addressBytes) (iotago.Address, error) {
+	if len(addr) == 0 {
+		//nolint:nilnil // nil address means the column is not set
+		return nil, nil
+	}
+
+	return addressFromBytes(addr)
+}
+
+func (r *exportRow) exportedOutput(outputType iotago.OutputType) (*ExportedOutput, error) {
+	output := &ExportedOutput{
+		OutputID:             r.OutputID.ID(),
+		OutputType:           outputType,
+		CreatedAt:            r.CreatedAt,
+		NativeTokenCount:     r.NativeTokenCount,
+		Tag:                  r.Tag,
+		StorageDepositReturn: r.StorageDepositReturn,
+		TimelockTime:         r.TimelockTime,
+		ExpirationTime:       r.ExpirationTime,
+	}
+
+	switch outputType {
+	case iotago.OutputAlias:
+		output.ID = r.AliasID
+	case iotago.OutputNFT:
+		output.ID = r.NFTID
+	case iotago.OutputFoundry:
+		output.ID = r.FoundryID
+	}
+
+	for _, column := range []struct {
+		value  addressBytes
+		target *iotago.Address
+	}{
+		{r.Address, &output.Address},
+		{r.StateController, &output.StateController},
+		{r.Governor, &output.Governor},
+		{r.AliasAddress, &output.AliasAddress},
+		{r.Issuer, &output.Issuer},
+		{r.Sender, &output.Sender},
+		{r.StorageDepositReturnAddress, &output.StorageDepositReturnAddress},
+		{r.ExpirationReturnAddress, &output.ExpirationReturnAddress},
+	} {
+		address, err := optionalAddressFromBytes(column.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid address of output %s", output.OutputID.ToHex())
+		}
+		*column.target = address
+	}
+
+	return output, nil
+}
+
+// ExportBasicOutputs writes all basic outputs matching the given filters to the writer.
+// The paging related filters are ignored, the outputs are written in the order of the cursors.
+func (i *Indexer) ExportBasicOutputs(ctx context.Context, writer ExportWriter, filters ...BasicOutputFilterOption) error {
+	opts := basicOutputFilterOptions(filters)
+
+	return i.exportOutputs(ctx, writer, iotago.OutputBasic, func(db *gorm.DB) (*gorm.DB, error) {
+		return basicOutputConditions(db.Model(&basicOutput{}), opts)
+	})
+}
+
+// ExportAliasOutputs writes all alias outputs matching the given filters to the writer.
+// The paging related filters are ignored, the outputs are written in the order of the cursors.
+func (i *Indexer) ExportAliasOutputs(ctx context.Context, writer ExportWriter, filters ...AliasFilterOption) error {
+	opts := aliasFilterOptions(filters)
+
+	return i.exportOutputs(ctx, writer, iotago.OutputAlias, func(db *gorm.DB) (*gorm.DB, error) {
+		return aliasConditions(db.Model(&alias{}), opts)
+	})
+}
+
+// ExportNFTOutputs writes all NFT outputs matching the given filters to the writer.
+// The paging related filters are ignored, the outputs are written in the order of the cursors.
+func (i *Indexer) ExportNFTOutputs(ctx context.Context, writer ExportWriter, filters ...NFTFilterOption) error {
+	opts := nftFilterOptions(filters)
+
+	return i.exportOutputs(ctx, writer, iotago.OutputNFT, func(db *gorm.DB) (*gorm.DB, error) {
+		return nftConditions(db.Model(&nft{}), opts)
+	})
+}
+
+// ExportFoundryOutputs writes all foundry outputs matching the given filters to the writer.
+// The paging related filters are ignored, the outputs are written in the order of the cursors.
+func (i *Indexer) ExportFoundryOutputs(ctx context.Context, writer ExportWriter, filters ...FoundryFilterOption) error {
+	opts := foundryFilterOptions(filters)
+
+	return i.exportOutputs(ctx, writer, iotago.OutputFoundry, func(db *gorm.DB) (*gorm.DB, error) {
+		return foundryConditions(db.Model(&foundry{}), opts)
+	})
+}
+
+// exportOutputs runs the export on a read replica if configured.
+// The primary is used instead if the replica failed or lags behind before the first output was written,
+// afterwards the export can not be repeated anymore.
+func (i *Indexer) exportOutputs(ctx context.Context, writer ExportWriter, outputType iotago.OutputType, conditions func(db *gorm.DB) (*gorm.DB, error)) error {
+	ctx, span := tracing.Tracer().Start(ctx, "exportOutputs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("indexer.output_type", int(outputType)))
+
+	if i.maxExportDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.maxExportDuration)
+		defer cancel()
+	}
+
+	err := func() error {
+		if replica := i.nextReadReplica(); replica != nil {
+			span.SetAttributes(attribute.Bool("indexer.read_replica", true))
+
+			begun, err := i.exportTransaction(ctx, replica, writer, outputType, conditions, true)
+			if err == nil || begun || ctx.Err() != nil {
+				return err
+			}
+
+			i.LogDebugf("Exporting from read replica failed, falling back to primary: %s", err)
+		}
+
+		_, err := i.exportTransaction(ctx, i.db, writer, outputType, conditions, false)
+
+		return err
+	}()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ErrQueryTimeout
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// exportTransaction writes the outputs of a read-only snapshot of the database to the writer,
+// so the ledger index passed to Begin matches all written outputs.
+// It returns whether Begin was called, since the export can not be repeated on another database afterwards.
+func (i *Indexer) exportTransaction(ctx context.Context, db *gorm.DB, writer ExportWriter, outputType iotago.OutputType, conditions func(db *gorm.DB) (*gorm.DB, error), isReplica bool) (bool, error) {
+	var begun bool
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ledgerIndex := ledgerIndexFromDatabase(tx)
+		if isReplica && i.readReplicaLagging(ledgerIndex) {
+			return errReadReplicaLagging
+		}
+
+		query, err := conditions(tx)
+		if err != nil {
+			return err
+		}
+
+		rows, err := query.Order("created_at asc, output_id asc").Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		begun = true
+		if err := writer.Begin(ledgerIndex); err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var row exportRow
+			if err := tx.ScanRows(rows, &row); err != nil {
+				return err
+			}
+
+			output, err := row.exportedOutput(outputType)
+			if err != nil {
+				return err
+			}
+
+			if err := writer.Write(output); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	}, readTxOptions)
+
+	return begun, err
+}
@@ -0,0 +1,113 @@
+package indexer
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidFilterExpression = errors.New("invalid filter expression")
+)
+
+// FilterExpression is a boolean expression over the filter options of an output type.
+// Exactly one of And, Or, Not or Filters has to be set. All Filters of a node have to match.
+type FilterExpression[T any] struct {
+	And     []*FilterExpression[T]
+	Or      []*FilterExpression[T]
+	Not     *FilterExpression[T]
+	Filters []T
+}
+
+// filterExpressionOperator combines conditions with a boolean operator.
+// Every condition is put in parentheses, since the conditions of the filters are plain SQL strings.
+type filterExpressionOperator struct {
+	operator   string
+	conditions []clause.Expression
+}
+
+func (o filterExpressionOperator) Build(builder clause.Builder) {
+	builder.WriteByte('(')
+	for idx, condition := range o.conditions {
+		if idx > 0 {
+			builder.WriteString(" " + o.operator + " ")
+		}
+		builder.WriteByte('(')
+		condition.Build(builder)
+		builder.WriteByte(')')
+	}
+	builder.WriteByte(')')
+}
+
+// filterExpressionNegation negates a condition.
+// A condition on a NULL column evaluates to NULL, which would also be filtered out by the negation,
+// so the result of the condition is treated as false in that case.
+type filterExpressionNegation struct {
+	condition clause.Expression
+}
+
+func (n filterExpressionNegation) Build(builder clause.Builder) {
+	builder.WriteString("NOT COALESCE((")
+	n.condition.Build(builder)
+	builder.WriteString("), FALSE)")
+}
+
+// filterExpressionCondition compiles the expression to a condition. The filters of the leaves are applied
+// with the given function, that adds the same conditions to the query as the "WithFilters" queries.
+func filterExpressionCondition[T any](db *gorm.DB, expr *FilterExpression[T], applyFilters func(*gorm.DB, []T) (*gorm.DB, error)) (clause.Expression, error) {
+	if expr == nil {
+		return nil, errors.WithMessage(ErrInvalidFilterExpression, "empty expression")
+	}
+
+	set := 0
+	for _, isSet := range []bool{len(expr.And) > 0, len(expr.Or) > 0, expr.Not != nil, len(expr.Filters) > 0} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.WithMessage(ErrInvalidFilterExpression, "exactly one of and, or, not or filters has to be set")
+	}
+
+	compileOperator := func(operator string, exprs []*FilterExpression[T]) (clause.Expression, error) {
+		conditions := make([]clause.Expression, 0, len(exprs))
+		for _, e := range exprs {
+			condition, err := filterExpressionCondition(db, e, applyFilters)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+		}
+
+		return filterExpressionOperator{operator: operator, conditions: conditions}, nil
+	}
+
+	switch {
+	case len(expr.And) > 0:
+		return compileOperator("AND", expr.And)
+
+	case len(expr.Or) > 0:
+		return compileOperator("OR", expr.Or)
+
+	case expr.Not != nil:
+		condition, err := filterExpressionCondition(db, expr.Not, applyFilters)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterExpressionNegation{condition: condition}, nil
+
+	default:
+		query, err := applyFilters(db.Session(&gorm.Session{NewDB: true}), expr.Filters)
+		if err != nil {
+			return nil, err
+		}
+
+		where, ok := query.Statement.Clauses["WHERE"].Expression.(clause.Where)
+		if !ok || len(where.Exprs) == 0 {
+			return nil, errors.WithMessage(ErrInvalidFilterExpression, "filters without conditions")
+		}
+
+		return clause.And(where.Exprs...), nil
+	}
+}
@@ -0,0 +1,223 @@
+package indexer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// outputIDSet is the set of the output IDs of a query result.
+type outputIDSet map[iotago.OutputID]struct{}
+
+func newOutputIDSet(t *testing.T, result *IndexerResult) outputIDSet {
+	t.Helper()
+
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Cursor != nil {
+		t.Fatal("expected all outputs to fit on a single page")
+	}
+
+	set := make(outputIDSet, len(result.OutputIDs))
+	for _, outputID := range result.OutputIDs {
+		set[outputID] = struct{}{}
+	}
+
+	return set
+}
+
+func (s outputIDSet) and(other outputIDSet) outputIDSet {
+	result := make(outputIDSet)
+	for outputID := range s {
+		if _, exists := other[outputID]; exists {
+			result[outputID] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+func (s outputIDSet) or(other outputIDSet) outputIDSet {
+	result := make(outputIDSet)
+	for outputID := range s {
+		result[outputID] = struct{}{}
+	}
+	for outputID := range other {
+		result[outputID] = struct{}{}
+	}
+
+	return result
+}
+
+func (s outputIDSet) without(other outputIDSet) outputIDSet {
+	result := make(outputIDSet)
+	for outputID := range s {
+		if _, exists := other[outputID]; !exists {
+			result[outputID] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+func basicOutputFilters(filters ...BasicOutputFilterOption) *BasicOutputFilterExpression {
+	return &BasicOutputFilterExpression{Filters: filters}
+}
+
+// TestFilterExpression compares the results of compiled expressions with the results of the filters they are built from.
+func TestFilterExpression(t *testing.T) {
+	const pageSize = 1000
+
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			idx := newTestIndexer(t, dbParams)
+			ledger := generateTestLedger(t, idx, 500, 2)
+			ctx := context.Background()
+
+			filtered := func(filters ...BasicOutputFilterOption) outputIDSet {
+				return newOutputIDSet(t, idx.BasicOutputsWithFilters(ctx, append(filters, BasicOutputPageSize(pageSize))...))
+			}
+
+			middle := time.Unix(testFirstTimestamp+5, 0)
+			all := filtered()
+			timelock := filtered(BasicOutputHasTimelockCondition(true))
+			expiration := filtered(BasicOutputHasExpirationCondition(true))
+			expiresBefore := filtered(BasicOutputExpiresBefore(middle))
+			timelockFreeLater := filtered(BasicOutputHasTimelockCondition(false), BasicOutputCreatedAfter(middle))
+
+			for name, set := range map[string]outputIDSet{"timelock": timelock, "expiration": expiration, "expiresBefore": expiresBefore} {
+				if len(set) == 0 || len(set) == len(all) {
+					t.Fatalf("expected the %s filter to match some of the outputs, got %d of %d", name, len(set), len(all))
+				}
+			}
+
+			tests := []struct {
+				name     string
+				expr     *BasicOutputFilterExpression
+				filters  []BasicOutputFilterOption
+				expected outputIDSet
+			}{
+				{
+					name:     "filters",
+					expr:     basicOutputFilters(BasicOutputHasTimelockCondition(false), BasicOutputCreatedAfter(middle)),
+					expected: timelockFreeLater,
+				},
+				{
+					name: "and",
+					expr: &BasicOutputFilterExpression{And: []*BasicOutputFilterExpression{
+						basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+						basicOutputFilters(BasicOutputHasExpirationCondition(true)),
+					}},
+					expected: timelock.and(expiration),
+				},
+				{
+					name: "or",
+					expr: &BasicOutputFilterExpression{Or: []*BasicOutputFilterExpression{
+						basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+						basicOutputFilters(BasicOutputHasExpirationCondition(true)),
+					}},
+					expected: timelock.or(expiration),
+				},
+				{
+					name:     "not",
+					expr:     &BasicOutputFilterExpression{Not: basicOutputFilters(BasicOutputHasTimelockCondition(true))},
+					expected: all.without(timelock),
+				},
+				{
+					// the outputs without an expiration have a NULL expiration time, they do not expire before the time
+					name:     "not on a column that can be NULL",
+					expr:     &BasicOutputFilterExpression{Not: basicOutputFilters(BasicOutputExpiresBefore(middle))},
+					expected: all.without(expiresBefore),
+				},
+				{
+					name: "nested",
+					expr: &BasicOutputFilterExpression{Or: []*BasicOutputFilterExpression{
+						{And: []*BasicOutputFilterExpression{
+							basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+							{Not: basicOutputFilters(BasicOutputHasExpirationCondition(true))},
+						}},
+						{Not: &BasicOutputFilterExpression{Or: []*BasicOutputFilterExpression{
+							basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+							basicOutputFilters(BasicOutputExpiresBefore(middle)),
+						}}},
+					}},
+					expected: timelock.without(expiration).or(all.without(timelock.or(expiresBefore))),
+				},
+				{
+					name:     "combined with the filters of the query",
+					expr:     &BasicOutputFilterExpression{Not: basicOutputFilters(BasicOutputHasTimelockCondition(true))},
+					filters:  []BasicOutputFilterOption{BasicOutputHasExpirationCondition(true)},
+					expected: expiration.without(timelock),
+				},
+				{
+					name: "address",
+					expr: &BasicOutputFilterExpression{Or: []*BasicOutputFilterExpression{
+						basicOutputFilters(BasicOutputUnlockableByAddress(ledger.addresses[0])),
+						basicOutputFilters(BasicOutputUnlockableByAddress(ledger.addresses[1])),
+					}},
+					expected: filtered(BasicOutputUnlockableByAddress(ledger.addresses[0])).or(filtered(BasicOutputUnlockableByAddress(ledger.addresses[1]))),
+				},
+			}
+
+			for _, test := range tests {
+				test := test
+				t.Run(test.name, func(t *testing.T) {
+					result := idx.BasicOutputsWithFilterExpression(ctx, test.expr, append(test.filters, BasicOutputPageSize(pageSize))...)
+					if got := newOutputIDSet(t, result); !reflect.DeepEqual(got, test.expected) {
+						t.Errorf("expected %d outputs, got %d", len(test.expected), len(got))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestFilterExpressionInvalid(t *testing.T) {
+	idx := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+
+	tests := []struct {
+		name string
+		expr *BasicOutputFilterExpression
+	}{
+		{
+			name: "empty",
+			expr: &BasicOutputFilterExpression{},
+		},
+		{
+			name: "more than one operator",
+			expr: &BasicOutputFilterExpression{
+				Not:     basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+				Filters: []BasicOutputFilterOption{BasicOutputHasExpirationCondition(true)},
+			},
+		},
+		{
+			name: "filters without conditions",
+			expr: basicOutputFilters(BasicOutputPageSize(10)),
+		},
+		{
+			name: "invalid operand",
+			expr: &BasicOutputFilterExpression{And: []*BasicOutputFilterExpression{
+				basicOutputFilters(BasicOutputHasTimelockCondition(true)),
+				{Not: &BasicOutputFilterExpression{}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			result := idx.BasicOutputsWithFilterExpression(context.Background(), test.expr)
+			if !errors.Is(result.Error, ErrInvalidFilterExpression) {
+				t.Errorf("expected an invalid filter expression error, got %v", result.Error)
+			}
+		})
+	}
+}
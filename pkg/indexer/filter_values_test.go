@@ -0,0 +1,166 @@
+package indexer
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+type filterValuesTest[T any] struct {
+	name      string
+	filters   []T
+	outputIDs []byte
+}
+
+// filterValuesOutputIDs returns the sorted first bytes of the output IDs of a result.
+func filterValuesOutputIDs(t *testing.T, result *IndexerResult) []byte {
+	t.Helper()
+
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	outputIDs := make([]byte, 0, len(result.OutputIDs))
+	for _, outputID := range result.OutputIDs {
+		outputIDs = append(outputIDs, outputID[0])
+	}
+	sort.Slice(outputIDs, func(i, j int) bool { return outputIDs[i] < outputIDs[j] })
+
+	return outputIDs
+}
+
+// TestRepeatedFilterOptions checks that repeating a multi-value filter option adds its value with "or",
+// instead of replacing the earlier value, and that the negated options exclude from the matches of the other filters.
+func TestRepeatedFilterOptions(t *testing.T) {
+	addressA := &iotago.Ed25519Address{0xa}
+	addressB := &iotago.Ed25519Address{0xb}
+	addressC := &iotago.Ed25519Address{0xc}
+	senderX := &iotago.Ed25519Address{0x1}
+	senderY := &iotago.Ed25519Address{0x2}
+
+	basicOutput := func(address iotago.Address, sender iotago.Address) iotago.Output {
+		return &iotago.BasicOutput{
+			Amount:     1_000_000,
+			Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: address}},
+			Features:   iotago.Features{&iotago.SenderFeature{Address: sender}},
+		}
+	}
+
+	nftOutput := func(outputID byte, address iotago.Address, issuer iotago.Address) iotago.Output {
+		return &iotago.NFTOutput{
+			Amount:            1_000_000,
+			NFTID:             iotago.NFTID{outputID},
+			Conditions:        iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: address}},
+			ImmutableFeatures: iotago.Features{&iotago.IssuerFeature{Address: issuer}},
+		}
+	}
+
+	outputs := map[iotago.OutputID]iotago.Output{
+		{1}:  basicOutput(addressA, senderX),
+		{2}:  basicOutput(addressB, senderY),
+		{3}:  basicOutput(addressC, senderX),
+		{4}:  basicOutput(addressA, senderY),
+		{11}: nftOutput(11, addressA, senderX),
+		{12}: nftOutput(12, addressB, senderY),
+		{13}: nftOutput(13, addressC, senderX),
+	}
+
+	basicOutputTests := []filterValuesTest[BasicOutputFilterOption]{
+		{
+			name:      "address",
+			filters:   []BasicOutputFilterOption{BasicOutputUnlockableByAddress(addressA)},
+			outputIDs: []byte{1, 4},
+		},
+		{
+			name:      "repeated address",
+			filters:   []BasicOutputFilterOption{BasicOutputUnlockableByAddress(addressA), BasicOutputUnlockableByAddress(addressB)},
+			outputIDs: []byte{1, 2, 4},
+		},
+		{
+			name:      "repeated sender",
+			filters:   []BasicOutputFilterOption{BasicOutputSender(senderX), BasicOutputSender(senderY)},
+			outputIDs: []byte{1, 2, 3, 4},
+		},
+		{
+			name:      "repeated not address",
+			filters:   []BasicOutputFilterOption{BasicOutputNotUnlockableByAddress(addressA), BasicOutputNotUnlockableByAddress(addressC)},
+			outputIDs: []byte{2},
+		},
+		{
+			name: "repeated address and not sender",
+			filters: []BasicOutputFilterOption{
+				BasicOutputUnlockableByAddress(addressA),
+				BasicOutputUnlockableByAddress(addressB),
+				BasicOutputNotSender(senderY),
+			},
+			outputIDs: []byte{1},
+		},
+		{
+			name:      "sender and not address",
+			filters:   []BasicOutputFilterOption{BasicOutputSender(senderX), BasicOutputNotUnlockableByAddress(addressA)},
+			outputIDs: []byte{3},
+		},
+		{
+			name:    "address and the same not address",
+			filters: []BasicOutputFilterOption{BasicOutputUnlockableByAddress(addressA), BasicOutputNotUnlockableByAddress(addressA)},
+		},
+	}
+
+	nftTests := []filterValuesTest[NFTFilterOption]{
+		{
+			name:      "repeated issuer",
+			filters:   []NFTFilterOption{NFTIssuer(senderX), NFTIssuer(senderY)},
+			outputIDs: []byte{11, 12, 13},
+		},
+		{
+			name:      "issuer and not address",
+			filters:   []NFTFilterOption{NFTIssuer(senderX), NFTNotUnlockableByAddress(addressA)},
+			outputIDs: []byte{13},
+		},
+		{
+			name:      "not issuer and repeated address",
+			filters:   []NFTFilterOption{NFTNotIssuer(senderY), NFTUnlockableByAddress(addressA), NFTUnlockableByAddress(addressB)},
+			outputIDs: []byte{11},
+		},
+	}
+
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			idx := newTestIndexer(t, dbParams)
+
+			importer := idx.ImportTransaction(context.Background())
+			for outputID, output := range outputs {
+				if err := importer.AddOutput(outputID, output, testFirstTimestamp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := importer.Finalize(testLedgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, test := range basicOutputTests {
+				test := test
+				t.Run(test.name, func(t *testing.T) {
+					outputIDs := filterValuesOutputIDs(t, idx.BasicOutputsWithFilters(context.Background(), append(test.filters, BasicOutputPageSize(100))...))
+					if !reflect.DeepEqual(outputIDs, append([]byte{}, test.outputIDs...)) {
+						t.Errorf("expected the outputs %v, got %v", test.outputIDs, outputIDs)
+					}
+				})
+			}
+
+			for _, test := range nftTests {
+				test := test
+				t.Run("nft "+test.name, func(t *testing.T) {
+					outputIDs := filterValuesOutputIDs(t, idx.NFTOutputsWithFilters(context.Background(), append(test.filters, NFTPageSize(100))...))
+					if !reflect.DeepEqual(outputIDs, append([]byte{}, test.outputIDs...)) {
+						t.Errorf("expected the outputs %v, got %v", test.outputIDs, outputIDs)
+					}
+				})
+			}
+		})
+	}
+}
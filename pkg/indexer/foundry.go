@@ -1,17 +1,21 @@
 package indexer
 
 import (
+	"context"
 	"time"
 
+	"gorm.io/gorm"
+
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 type foundry struct {
-	FoundryID        foundryIDBytes `gorm:"primaryKey;notnull"`
-	OutputID         outputIDBytes  `gorm:"unique;notnull"`
-	NativeTokenCount uint32         `gorm:"notnull;type:integer"`
-	AliasAddress     addressBytes   `gorm:"notnull;index:foundries_alias_address"`
-	CreatedAt        time.Time      `gorm:"notnull;index:foundries_created_at"`
+	FoundryID        foundryIDBytes `gorm:"primaryKey;notnull" json:"foundryId"`
+	OutputID         outputIDBytes  `gorm:"unique;notnull;index:foundries_created_at,priority:2;index:foundries_alias_address,priority:3" json:"outputId"`
+	NativeTokenCount uint32         `gorm:"notnull;type:integer" json:"nativeTokenCount"`
+	AliasAddress     addressBytes   `gorm:"notnull;index:foundries_alias_address,priority:1" json:"aliasAddress"`
+	// all indexes end in (created_at, output_id), so they also serve the order of the paginated queries
+	CreatedAt time.Time `gorm:"notnull;index:foundries_created_at,priority:1;index:foundries_alias_address,priority:2" json:"createdAt"`
 }
 
 type FoundryFilterOptions struct {
@@ -21,12 +25,15 @@ type FoundryFilterOptions struct {
 	aliasAddress        *iotago.AliasAddress
 	pageSize            uint32
 	cursor              *string
+	backward            bool
 	createdBefore       *time.Time
 	createdAfter        *time.Time
 }
 
 type FoundryFilterOption func(*FoundryFilterOptions)
 
+type FoundryFilterExpression = FilterExpression[FoundryFilterOption]
+
 func FoundryHasNativeTokens(value bool) FoundryFilterOption {
 	return func(args *FoundryFilterOptions) {
 		args.hasNativeTokens = &value
@@ -60,6 +67,14 @@ func FoundryPageSize(pageSize uint32) FoundryFilterOption {
 func FoundryCursor(cursor string) FoundryFilterOption {
 	return func(args *FoundryFilterOptions) {
 		args.cursor = &cursor
+		args.backward = false
+	}
+}
+
+func FoundryPrevCursor(cursor string) FoundryFilterOption {
+	return func(args *FoundryFilterOptions) {
+		args.cursor = &cursor
+		args.backward = true
 	}
 }
 
@@ -85,18 +100,15 @@ func foundryFilterOptions(optionalOptions []FoundryFilterOption) *FoundryFilterO
 	return result
 }
 
-func (i *Indexer) FoundryOutput(foundryID *iotago.FoundryID) *IndexerResult {
+func (i *Indexer) FoundryOutput(ctx context.Context, foundryID *iotago.FoundryID) *IndexerResult {
 	query := i.db.Model(&foundry{}).
 		Where("foundry_id = ?", foundryID[:]).
 		Limit(1)
 
-	return i.combineOutputIDFilteredQuery(query, 0, nil)
+	return i.combineOutputIDFilteredQuery(ctx, query, 0, nil, false)
 }
 
-func (i *Indexer) FoundryOutputsWithFilters(filters ...FoundryFilterOption) *IndexerResult {
-	opts := foundryFilterOptions(filters)
-	query := i.db.Model(&foundry{})
-
+func foundryConditions(query *gorm.DB, opts *FoundryFilterOptions) (*gorm.DB, error) {
 	if opts.hasNativeTokens != nil {
 		if *opts.hasNativeTokens {
 			query = query.Where("native_token_count > 0")
@@ -116,7 +128,7 @@ func (i *Indexer) FoundryOutputsWithFilters(filters ...FoundryFilterOption) *Ind
 	if opts.aliasAddress != nil {
 		addr, err := addressBytesForAddress(opts.aliasAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("alias_address = ?", addr[:])
 	}
@@ -129,5 +141,65 @@ func (i *Indexer) FoundryOutputsWithFilters(filters ...FoundryFilterOption) *Ind
 		query = query.Where("created_at > ?", *opts.createdAfter)
 	}
 
-	return i.combineOutputIDFilteredQuery(query, opts.pageSize, opts.cursor)
+	return query, nil
+}
+
+func (i *Indexer) FoundryOutputsWithFilters(ctx context.Context, filters ...FoundryFilterOption) *IndexerResult {
+	opts := foundryFilterOptions(filters)
+
+	query, err := foundryConditions(i.db.Model(&foundry{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(filtersQueryCost(opts.filterNames()), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query, pageSize, opts.cursor, opts.backward)
+}
+
+// FoundryOutputsWithFilterExpression returns the foundry outputs matching the given filter expression and filters.
+// Only the paging related filters are useful in combination with an expression, all others can be part of the expression.
+func (i *Indexer) FoundryOutputsWithFilterExpression(ctx context.Context, expr *FoundryFilterExpression, filters ...FoundryFilterOption) *IndexerResult {
+	opts := foundryFilterOptions(filters)
+
+	query, err := foundryConditions(i.db.Model(&foundry{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	condition, err := filterExpressionCondition(i.db, expr, func(query *gorm.DB, filters []FoundryFilterOption) (*gorm.DB, error) {
+		return foundryConditions(query, foundryFilterOptions(filters))
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(expressionQueryCost(expr, opts.filterNames(), func(filters []FoundryFilterOption) []string {
+		return foundryFilterOptions(filters).filterNames()
+	}), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query.Where(condition), pageSize, opts.cursor, opts.backward)
+}
+
+// FoundryIDs returns the foundryIDs of the given foundry outputs. Outputs that are not unspent anymore are not part of the result.
+func (i *Indexer) FoundryIDs(ctx context.Context, outputIDs iotago.OutputIDs) (map[iotago.OutputID]iotago.FoundryID, error) {
+	ids, err := i.idsForOutputIDs(ctx, &foundry{}, "foundry_id", outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[iotago.OutputID]iotago.FoundryID, len(ids))
+	for outputID, idBytes := range ids {
+		id := iotago.FoundryID{}
+		copy(id[:], idBytes)
+		result[outputID] = id
+	}
+
+	return result, nil
 }
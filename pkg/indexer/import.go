@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	"gorm.io/gorm"
@@ -14,6 +18,7 @@ import (
 	gormLogger "gorm.io/gorm/logger"
 
 	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -29,6 +34,31 @@ func typeOf[T any]() string {
 	return reflect.TypeOf(t).Elem().Name()
 }
 
+// ImportProgress is the progress of the import of the initial ledger.
+type ImportProgress struct {
+	// Running is set while an import is running.
+	Running bool
+	// OutputsAdded is the amount of outputs that were added to the import.
+	OutputsAdded uint64
+	// OutputsInserted is the amount of outputs that were inserted into the database.
+	OutputsInserted uint64
+}
+
+type importProgress struct {
+	running  atomic.Bool
+	added    atomic.Uint64
+	inserted atomic.Uint64
+}
+
+// ImportProgress returns the progress of the running or the last import of the initial ledger.
+func (i *Indexer) ImportProgress() *ImportProgress {
+	return &ImportProgress{
+		Running:         i.importProgress.running.Load(),
+		OutputsAdded:    i.importProgress.added.Load(),
+		OutputsInserted: i.importProgress.inserted.Load(),
+	}
+}
+
 type batcher[T any] struct {
 	*logger.WrappedLogger
 
@@ -66,8 +96,14 @@ func (b *batcher[T]) Run(ctx context.Context, workerCount int) {
 			b.LogInfof("[%s] started", workerName)
 			defer b.LogInfof("[%s] ended", workerName)
 
+			_, span := tracing.Tracer().Start(ctx, "importBatcher", trace.WithAttributes(attribute.String("indexer.worker", workerName)))
+			defer span.End()
+
 			batch := make([]T, 0, batchSize)
 			var count int
+			defer func() {
+				span.SetAttributes(attribute.Int("indexer.outputs", count))
+			}()
 			for item := range b.input {
 				if ctx.Err() != nil {
 					return
@@ -91,16 +127,18 @@ func (b *batcher[T]) Run(ctx context.Context, workerCount int) {
 type inserter[T any] struct {
 	*logger.WrappedLogger
 
-	name string
-	db   *gorm.DB
-	wg   sync.WaitGroup
+	name     string
+	db       *gorm.DB
+	wg       sync.WaitGroup
+	progress *importProgress
 }
 
-func newImporter[T any](db *gorm.DB, log *logger.Logger) *inserter[T] {
+func newImporter[T any](db *gorm.DB, progress *importProgress, log *logger.Logger) *inserter[T] {
 	w := &inserter[T]{
 		WrappedLogger: logger.NewWrappedLogger(log),
 		name:          typeOf[T](),
 		db:            db,
+		progress:      progress,
 	}
 
 	return w
@@ -117,22 +155,35 @@ func (i *inserter[T]) Run(ctx context.Context, workerCount int, input <-chan []T
 			i.LogInfof("[%s] started", workerName)
 			defer i.LogInfof("[%s] ended", workerName)
 
+			workerCtx, span := tracing.Tracer().Start(ctx, "importInserter", trace.WithAttributes(attribute.String("indexer.worker", workerName)))
+			defer span.End()
+
 			ts := time.Now()
 			p := message.NewPrinter(language.English)
 
 			var count int
+			defer func() {
+				span.SetAttributes(attribute.Int("indexer.outputs", count))
+			}()
 			for b := range input {
 				if ctx.Err() != nil {
 					return
 				}
 
 				batch := b
-				if err := i.db.Transaction(func(tx *gorm.DB) error {
+				batchCtx, batchSpan := tracing.Tracer().Start(workerCtx, "importBatch", trace.WithAttributes(attribute.Int("indexer.outputs", len(batch))))
+				// a batch that was already started is inserted completely, even if the import is canceled
+				if err := i.db.WithContext(tracing.Detach(batchCtx)).Transaction(func(tx *gorm.DB) error {
 					return tx.Create(batch).Error
 				}); err != nil {
+					batchSpan.RecordError(err)
+					batchSpan.SetStatus(codes.Error, err.Error())
+					batchSpan.End()
 					i.LogErrorAndExit(err)
 				}
+				batchSpan.End()
 				count += len(batch)
+				i.progress.inserted.Add(uint64(len(batch)))
 				if count > 0 && count%100_000 == 0 {
 					i.LogInfo(p.Sprintf("[%s] insert worker=%d @ %.2f per second", workerName, count, float64(count)/float64(time.Since(ts)/time.Second)))
 				}
@@ -151,10 +202,10 @@ type processor[T any] struct {
 	importer *inserter[T]
 }
 
-func newProcessor[T any](ctx context.Context, db *gorm.DB, log *logger.Logger) *processor[T] {
+func newProcessor[T any](ctx context.Context, db *gorm.DB, progress *importProgress, log *logger.Logger) *processor[T] {
 	p := &processor[T]{
 		batcher:  newBatcher[T](log),
-		importer: newImporter[T](db, log),
+		importer: newImporter[T](db, progress, log),
 	}
 	p.batcher.Run(ctx, perBatcherWorkers)
 	p.importer.Run(ctx, perImporterWorkers, p.batcher.output)
@@ -174,13 +225,20 @@ func (p *processor[T]) closeAndWait() {
 }
 
 func (i *Indexer) ImportTransaction(ctx context.Context) *ImportTransaction {
-	return newImportTransaction(ctx, i.db, i.Logger())
+	i.importProgress.added.Store(0)
+	i.importProgress.inserted.Store(0)
+	i.importProgress.running.Store(true)
+
+	return newImportTransaction(ctx, i.db, &i.importProgress, i.outboxEnabled.Load(), i.Logger())
 }
 
 type ImportTransaction struct {
 	*logger.WrappedLogger
 
-	db *gorm.DB
+	db       *gorm.DB
+	progress *importProgress
+	// outboxEnabled is set if the reset of the ledger has to be written to the outbox.
+	outboxEnabled bool
 
 	basic   *processor[*basicOutput]
 	nft     *processor[*nft]
@@ -188,7 +246,7 @@ type ImportTransaction struct {
 	foundry *processor[*foundry]
 }
 
-func newImportTransaction(ctx context.Context, db *gorm.DB, log *logger.Logger) *ImportTransaction {
+func newImportTransaction(ctx context.Context, db *gorm.DB, progress *importProgress, outboxEnabled bool, log *logger.Logger) *ImportTransaction {
 	// use a session without logger and hooks to reduce the amount of work that needs to be done by gorm.
 	dbSession := db.Session(&gorm.Session{
 		SkipHooks:              true,
@@ -199,10 +257,12 @@ func newImportTransaction(ctx context.Context, db *gorm.DB, log *logger.Logger)
 	t := &ImportTransaction{
 		WrappedLogger: logger.NewWrappedLogger(log),
 		db:            dbSession,
-		basic:         newProcessor[*basicOutput](ctx, dbSession, log),
-		nft:           newProcessor[*nft](ctx, dbSession, log),
-		alias:         newProcessor[*alias](ctx, dbSession, log),
-		foundry:       newProcessor[*foundry](ctx, dbSession, log),
+		progress:      progress,
+		outboxEnabled: outboxEnabled,
+		basic:         newProcessor[*basicOutput](ctx, dbSession, progress, log),
+		nft:           newProcessor[*nft](ctx, dbSession, progress, log),
+		alias:         newProcessor[*alias](ctx, dbSession, progress, log),
+		foundry:       newProcessor[*foundry](ctx, dbSession, progress, log),
 	}
 
 	return t
@@ -225,6 +285,7 @@ func (i *ImportTransaction) AddOutput(outputID iotago.OutputID, output iotago.Ou
 	case *foundry:
 		i.foundry.enqueue(e)
 	}
+	i.progress.added.Add(1)
 
 	return nil
 }
@@ -236,6 +297,7 @@ func (i *ImportTransaction) Finalize(ledgerIndex uint32, protoParams *iotago.Pro
 	i.nft.closeAndWait()
 	i.alias.closeAndWait()
 	i.foundry.closeAndWait()
+	i.progress.running.Store(false)
 
 	i.LogInfo("Finished insertion, update ledger index")
 
@@ -247,9 +309,19 @@ func (i *ImportTransaction) Finalize(ledgerIndex uint32, protoParams *iotago.Pro
 		NetworkName:     protoParams.NetworkName,
 		DatabaseVersion: databaseVersion,
 	}
-	i.db.Clauses(clause.OnConflict{
-		UpdateAll: true,
-	}).Create(&status)
 
-	return i.db.Error
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).Create(&status).Error; err != nil {
+			return err
+		}
+
+		// the imported ledger does not follow from the messages in the outbox
+		if i.outboxEnabled {
+			return writeOutboxResetEntry(tx, ledgerIndex)
+		}
+
+		return nil
+	})
 }
@@ -1,19 +1,30 @@
 package indexer
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
+	"github.com/iotaledger/hive.go/core/generics/options"
 	"github.com/iotaledger/hive.go/core/logger"
 	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/inx-app/pkg/nodebridge"
 	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
 	inx "github.com/iotaledger/inx/go"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 var (
-	ErrNotFound = errors.New("output not found for given filter")
+	ErrNotFound     = errors.New("output not found for given filter")
+	ErrQueryTimeout = errors.New("query exceeded the maximum query duration")
 
 	dbTables = []interface{}{
 		&Status{},
@@ -26,22 +37,100 @@ var (
 
 type Indexer struct {
 	*logger.WrappedLogger
+	Events *Events
 	db     *gorm.DB
 	engine database.Engine
+
+	// maxQueryDuration is the maximum duration of a filter query, 0 means unlimited.
+	maxQueryDuration time.Duration
+	// maxQueryCost is the maximum estimated amount of rows read by a filter query, 0 means unlimited.
+	maxQueryCost uint64
+	// reducePageSize is set if the page size of too expensive queries is reduced instead of rejecting them.
+	reducePageSize bool
+	// maxExportDuration is the maximum duration of an export, 0 means unlimited.
+	maxExportDuration time.Duration
+	// statementTimeout is the maximum duration of a statement of the read transactions on PostgreSQL, 0 means unlimited.
+	statementTimeout time.Duration
+
+	// readReplicas are used to answer the filter queries, the primary db is used as a fallback.
+	readReplicas []*gorm.DB
+	// readReplicaCounter is used to distribute the queries between the read replicas.
+	readReplicaCounter atomic.Uint32
+	// maxReadReplicaLag is the maximum amount of milestones a read replica may lag behind the primary.
+	maxReadReplicaLag uint32
+	// ledgerIndex is the latest ledger index written to the primary db.
+	ledgerIndex atomic.Uint32
+	// importProgress is the progress of the running import of the initial ledger.
+	importProgress importProgress
+	// outboxEnabled is set if the changes of every milestone are written to the outbox table.
+	outboxEnabled atomic.Bool
+	// ledgerUpdateWritersLock protects ledgerUpdateWriters.
+	ledgerUpdateWritersLock sync.RWMutex
+	// ledgerUpdateWriters write the rows of other components in the transaction of every milestone.
+	ledgerUpdateWriters []LedgerUpdateWriter
+}
+
+// LedgerUpdateWriter writes rows that belong to the changes of a milestone in the transaction of UpdatedLedger.
+// If it returns an error, the milestone is not applied.
+type LedgerUpdateWriter func(tx *gorm.DB, update *nodebridge.LedgerUpdate) error
+
+// WithMaxReadReplicaLag sets the maximum amount of milestones a read replica may lag behind
+// the primary database before queries fall back to the primary.
+func WithMaxReadReplicaLag(maxLag uint32) options.Option[Indexer] {
+	return func(i *Indexer) {
+		i.maxReadReplicaLag = maxLag
+	}
+}
+
+// WithMaxQueryDuration sets the maximum duration of a filter query.
+// Queries that take longer are canceled and return ErrQueryTimeout.
+func WithMaxQueryDuration(maxDuration time.Duration) options.Option[Indexer] {
+	return func(i *Indexer) {
+		i.maxQueryDuration = maxDuration
+	}
+}
+
+// WithMaxQueryCost sets the maximum estimated amount of rows a filter query may read.
+// The page size of too expensive queries is reduced if reducePageSize is set, otherwise they return ErrQueryTooExpensive.
+func WithMaxQueryCost(maxCost uint64, reducePageSize bool) options.Option[Indexer] {
+	return func(i *Indexer) {
+		i.maxQueryCost = maxCost
+		i.reducePageSize = reducePageSize
+	}
+}
+
+// WithMaxExportDuration sets the maximum duration of an export.
+// Exports that take longer are canceled and return ErrQueryTimeout.
+func WithMaxExportDuration(maxDuration time.Duration) options.Option[Indexer] {
+	return func(i *Indexer) {
+		i.maxExportDuration = maxDuration
+	}
 }
 
-func NewIndexer(dbParams database.Params, log *logger.Logger) (*Indexer, error) {
+func NewIndexer(dbParams database.Params, log *logger.Logger, opts ...options.Option[Indexer]) (*Indexer, error) {
 
 	db, engine, err := database.NewWithDefaultSettings(dbParams, true, log)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Indexer{
+	readReplicas, err := database.NewReadReplicasWithDefaultSettings(dbParams, log)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Indexer{
 		WrappedLogger: logger.NewWrappedLogger(log),
+		Events:        newEvents(),
 		db:            db,
 		engine:        engine,
-	}, nil
+		readReplicas:  readReplicas,
+	}
+	if engine == database.EnginePostgreSQL {
+		idx.statementTimeout = dbParams.StatementTimeout
+	}
+
+	return options.Apply(idx, opts), nil
 }
 
 func processSpent(spent *inx.LedgerSpent, tx *gorm.DB) error {
@@ -325,8 +414,70 @@ func (i *Indexer) AutoMigrate() error {
 	return i.db.AutoMigrate(dbTables...)
 }
 
-func (i *Indexer) UpdatedLedger(update *nodebridge.LedgerUpdate) error {
-	return i.db.Transaction(func(tx *gorm.DB) error {
+// Analyze updates the statistics the query planner uses to choose between the indexes.
+// Without them, the planner can not know that e.g. most outputs have no timelock,
+// and prefers sorting a big result over reading it in the order of an index.
+func (i *Indexer) Analyze() error {
+	return i.db.Exec("ANALYZE").Error
+}
+
+// TableRowCounts returns the amount of rows of the output tables.
+// On PostgreSQL the counts are the estimates of the query planner, which are updated by ANALYZE and autovacuum,
+// since counting the rows of big tables takes too long. SQLite counts the rows, so callers should cache the counts
+// instead of reading them on every request.
+func (i *Indexer) TableRowCounts(ctx context.Context) (map[string]int64, error) {
+	tableNames := make([]string, 0, len(dbTables))
+	for _, table := range dbTables {
+		if _, isStatus := table.(*Status); isStatus {
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: i.db}
+		if err := stmt.Parse(table); err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, stmt.Schema.Table)
+	}
+
+	counts := make(map[string]int64, len(tableNames))
+	if i.engine == database.EnginePostgreSQL {
+		var rows []struct {
+			Name  string
+			Count int64
+		}
+		if err := i.db.WithContext(ctx).Raw("SELECT relname AS name, GREATEST(reltuples, 0)::bigint AS count FROM pg_class WHERE relkind = 'r' AND relname IN ? AND relnamespace = current_schema()::regnamespace", tableNames).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[row.Name] = row.Count
+		}
+
+		return counts, nil
+	}
+
+	for _, tableName := range tableNames {
+		var count int64
+		if err := i.db.WithContext(ctx).Table(tableName).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		counts[tableName] = count
+	}
+
+	return counts, nil
+}
+
+// UpdatedLedger applies the changes of a milestone in a single transaction.
+// The context is only used for tracing, the transaction is not aborted if it is canceled.
+func (i *Indexer) UpdatedLedger(ctx context.Context, update *nodebridge.LedgerUpdate) error {
+	ctx, span := tracing.Tracer().Start(ctx, "UpdatedLedger", trace.WithAttributes(
+		attribute.Int64("indexer.milestone_index", int64(update.MilestoneIndex)),
+		attribute.Int("indexer.created_outputs", len(update.Created)),
+		attribute.Int("indexer.consumed_outputs", len(update.Consumed)),
+	))
+	defer span.End()
+
+	ts := time.Now()
+	if err := i.db.WithContext(tracing.Detach(ctx)).Transaction(func(tx *gorm.DB) error {
 		spentOutputs := make(map[string]struct{})
 		for _, spent := range update.Consumed {
 			outputID := spent.GetOutput().GetOutputId().GetId()
@@ -346,15 +497,90 @@ func (i *Indexer) UpdatedLedger(update *nodebridge.LedgerUpdate) error {
 			}
 		}
 
+		if i.outboxEnabled.Load() {
+			if err := writeOutboxEntry(tx, update); err != nil {
+				return err
+			}
+		}
+
+		i.ledgerUpdateWritersLock.RLock()
+		defer i.ledgerUpdateWritersLock.RUnlock()
+		for _, writer := range i.ledgerUpdateWriters {
+			if err := writer(tx, update); err != nil {
+				return err
+			}
+		}
+
 		tx.Model(&Status{}).Where("id = ?", 1).Update("ledger_index", update.MilestoneIndex)
 
 		return nil
-	})
+	}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return err
+	}
+
+	i.ledgerIndex.Store(update.MilestoneIndex)
+	i.Events.LedgerUpdateDuration.Trigger(time.Since(ts))
+	i.Events.LedgerUpdated.Trigger(update)
+
+	return nil
+}
+
+// AddLedgerUpdateWriter adds a writer that is called in the transaction of every milestone,
+// so the rows it writes are committed together with the changes of the outputs.
+func (i *Indexer) AddLedgerUpdateWriter(writer LedgerUpdateWriter) {
+	i.ledgerUpdateWritersLock.Lock()
+	defer i.ledgerUpdateWritersLock.Unlock()
+
+	i.ledgerUpdateWriters = append(i.ledgerUpdateWriters, writer)
+}
+
+// Database returns the connection to the primary database.
+// Components with their own tables use it instead of opening another connection pool.
+func (i *Indexer) Database() *gorm.DB {
+	return i.db
+}
+
+// Engine returns the engine of the primary database.
+func (i *Indexer) Engine() database.Engine {
+	return i.engine
+}
+
+// LedgerIndex returns the latest ledger index written to the database.
+func (i *Indexer) LedgerIndex() uint32 {
+	return i.ledgerIndex.Load()
 }
 
 func (i *Indexer) Status() (*Status, error) {
+	status, err := statusFromDatabase(i.db)
+	if err != nil {
+		return nil, err
+	}
+	i.ledgerIndex.Store(status.LedgerIndex)
+
+	return status, nil
+}
+
+// ReadStatus reads the status from the database, unlike Status it does not change the cached ledger index.
+func (i *Indexer) ReadStatus(ctx context.Context) (*Status, error) {
+	return statusFromDatabase(i.db.WithContext(ctx))
+}
+
+// ledgerIndexFromDatabase returns the ledger index of the status table, or 0 if the indexer is empty.
+func ledgerIndexFromDatabase(db *gorm.DB) uint32 {
+	status, err := statusFromDatabase(db)
+	if err != nil {
+		return 0
+	}
+
+	return status.LedgerIndex
+}
+
+func statusFromDatabase(db *gorm.DB) (*Status, error) {
 	status := &Status{}
-	if err := i.db.Take(&status).Error; err != nil {
+	if err := db.Take(&status).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
 		}
@@ -375,7 +601,17 @@ func (i *Indexer) Clear() error {
 }
 
 func (i *Indexer) CloseDatabase() error {
-	sqlDB, err := i.db.DB()
+	for _, replica := range i.readReplicas {
+		if err := closeDatabase(replica); err != nil {
+			return err
+		}
+	}
+
+	return closeDatabase(i.db)
+}
+
+func closeDatabase(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,245 @@
+package indexer
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// testFirstTimestamp is the creation time of the first generated output.
+	testFirstTimestamp = 1_680_000_000
+	// testOutputsPerMilestone is the amount of generated outputs that share the same creation time.
+	testOutputsPerMilestone = 50
+	// testLedgerIndex is the ledger index of the generated ledger.
+	testLedgerIndex = 1000
+)
+
+var initTestLogger sync.Once
+
+// testEngines returns the database parameters the tests run against.
+// SQLite is always tested, PostgreSQL only if INDEXER_TEST_POSTGRESQL_HOST is set,
+// the database is cleared by the tests.
+func testEngines(t *testing.T) map[database.Engine]database.Params {
+	t.Helper()
+
+	engines := map[database.Engine]database.Params{
+		database.EngineSQLite: {
+			Engine: database.EngineSQLite,
+			Path:   t.TempDir(),
+		},
+	}
+
+	if host := os.Getenv("INDEXER_TEST_POSTGRESQL_HOST"); host != "" {
+		port := uint64(5432)
+		if portStr := os.Getenv("INDEXER_TEST_POSTGRESQL_PORT"); portStr != "" {
+			var err error
+			if port, err = strconv.ParseUint(portStr, 10, 16); err != nil {
+				t.Fatalf("invalid INDEXER_TEST_POSTGRESQL_PORT: %s", err)
+			}
+		}
+
+		engines[database.EnginePostgreSQL] = database.Params{
+			Engine:   database.EnginePostgreSQL,
+			Host:     host,
+			Port:     uint(port),
+			Database: "indexer",
+			Username: "indexer",
+			Password: os.Getenv("INDEXER_TEST_POSTGRESQL_PASSWORD"),
+			SSLMode:  "disable",
+		}
+	}
+
+	return engines
+}
+
+// newTestIndexer returns an indexer with empty tables.
+func newTestIndexer(t *testing.T, dbParams database.Params) *Indexer {
+	t.Helper()
+
+	initTestLogger.Do(func() {
+		if err := logger.InitGlobalLogger(configuration.New()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	idx, err := NewIndexer(dbParams, logger.NewLogger("Indexer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = idx.CloseDatabase() })
+
+	if idx.IsInitialized() {
+		if err := idx.Clear(); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := idx.CreateTables(); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+// testLedger are the addresses and values the generated outputs are built from.
+type testLedger struct {
+	addresses []iotago.Address
+	aliases   []*iotago.AliasAddress
+	tags      [][]byte
+}
+
+func newTestLedger(rnd *rand.Rand) *testLedger {
+	ledger := &testLedger{}
+
+	for i := 0; i < 1000; i++ {
+		address := &iotago.Ed25519Address{}
+		rnd.Read(address[:])
+		ledger.addresses = append(ledger.addresses, address)
+	}
+
+	for i := 0; i < 200; i++ {
+		address := &iotago.AliasAddress{}
+		rnd.Read(address[:])
+		ledger.aliases = append(ledger.aliases, address)
+	}
+
+	for i := 0; i < 500; i++ {
+		ledger.tags = append(ledger.tags, []byte("tag-"+strconv.Itoa(i)))
+	}
+
+	return ledger
+}
+
+func (l *testLedger) address(rnd *rand.Rand) iotago.Address {
+	return l.addresses[rnd.Intn(len(l.addresses))]
+}
+
+// unixTime returns a time between the first and the last creation time of the generated outputs.
+func (l *testLedger) unixTime(rnd *rand.Rand, basicOutputs int) uint32 {
+	return uint32(testFirstTimestamp + rnd.Intn(basicOutputs/testOutputsPerMilestone+1))
+}
+
+// features returns the optional features of an output, most outputs have none of them.
+func (l *testLedger) features(rnd *rand.Rand) iotago.Features {
+	var features iotago.Features
+	if rnd.Intn(10) < 3 {
+		features = append(features, &iotago.SenderFeature{Address: l.address(rnd)})
+	}
+	if rnd.Intn(10) < 3 {
+		features = append(features, &iotago.TagFeature{Tag: l.tags[rnd.Intn(len(l.tags))]})
+	}
+
+	return features
+}
+
+// unlockConditions returns the address unlock condition and the optional unlock conditions of an output.
+func (l *testLedger) unlockConditions(rnd *rand.Rand, basicOutputs int) iotago.UnlockConditions {
+	conditions := iotago.UnlockConditions{
+		&iotago.AddressUnlockCondition{Address: l.address(rnd)},
+	}
+	if rnd.Intn(100) < 5 {
+		conditions = append(conditions, &iotago.StorageDepositReturnUnlockCondition{ReturnAddress: l.address(rnd), Amount: 50_000})
+	}
+	if rnd.Intn(100) < 5 {
+		conditions = append(conditions, &iotago.TimelockUnlockCondition{UnixTime: l.unixTime(rnd, basicOutputs)})
+	}
+	if rnd.Intn(100) < 5 {
+		conditions = append(conditions, &iotago.ExpirationUnlockCondition{ReturnAddress: l.address(rnd), UnixTime: l.unixTime(rnd, basicOutputs)})
+	}
+
+	return conditions
+}
+
+// generateTestLedger fills the tables with outputs of all types, distributed like on a real network:
+// every output has an address, while the optional features and unlock conditions are only set on some of them.
+// It returns the ledger the outputs were built from. The generated data only depends on the seed.
+func generateTestLedger(t *testing.T, idx *Indexer, basicOutputs int, seed int64) *testLedger {
+	t.Helper()
+
+	//nolint:gosec // the data only has to be reproducible, not secure.
+	rnd := rand.New(rand.NewSource(seed))
+	ledger := newTestLedger(rnd)
+
+	importer := idx.ImportTransaction(context.Background())
+	outputIndex := 0
+	addOutput := func(output iotago.Output) {
+		outputID := iotago.OutputID{}
+		rnd.Read(outputID[:])
+
+		if err := importer.AddOutput(outputID, output, uint32(testFirstTimestamp+outputIndex/testOutputsPerMilestone)); err != nil {
+			t.Fatal(err)
+		}
+		outputIndex++
+	}
+
+	for i := 0; i < basicOutputs; i++ {
+		addOutput(&iotago.BasicOutput{
+			Amount:     1_000_000,
+			Conditions: ledger.unlockConditions(rnd, basicOutputs),
+			Features:   ledger.features(rnd),
+		})
+
+		if i%4 == 0 {
+			nftOutput := &iotago.NFTOutput{
+				Amount:     1_000_000,
+				Conditions: ledger.unlockConditions(rnd, basicOutputs),
+				Features:   ledger.features(rnd),
+			}
+			rnd.Read(nftOutput.NFTID[:])
+			if rnd.Intn(2) == 0 {
+				nftOutput.ImmutableFeatures = iotago.Features{&iotago.IssuerFeature{Address: ledger.addresses[rnd.Intn(100)]}}
+			}
+			addOutput(nftOutput)
+		}
+
+		if i%10 == 0 {
+			aliasOutput := &iotago.AliasOutput{
+				Amount: 1_000_000,
+				Conditions: iotago.UnlockConditions{
+					&iotago.StateControllerAddressUnlockCondition{Address: ledger.address(rnd)},
+					&iotago.GovernorAddressUnlockCondition{Address: ledger.address(rnd)},
+				},
+			}
+			rnd.Read(aliasOutput.AliasID[:])
+			if rnd.Intn(5) == 0 {
+				aliasOutput.ImmutableFeatures = iotago.Features{&iotago.IssuerFeature{Address: ledger.addresses[rnd.Intn(100)]}}
+			}
+			if rnd.Intn(5) == 0 {
+				aliasOutput.Features = iotago.Features{&iotago.SenderFeature{Address: ledger.address(rnd)}}
+			}
+			addOutput(aliasOutput)
+		}
+
+		if i%20 == 0 {
+			addOutput(&iotago.FoundryOutput{
+				Amount:       1_000_000,
+				SerialNumber: uint32(i),
+				TokenScheme:  &iotago.SimpleTokenScheme{},
+				Conditions: iotago.UnlockConditions{
+					&iotago.ImmutableAliasUnlockCondition{Address: ledger.aliases[rnd.Intn(len(ledger.aliases))]},
+				},
+			})
+		}
+	}
+
+	if err := importer.Finalize(testLedgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.Analyze(); err != nil {
+		t.Fatal(err)
+	}
+
+	return ledger
+}
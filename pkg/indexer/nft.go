@@ -1,32 +1,37 @@
 package indexer
 
 import (
+	"context"
 	"time"
 
+	"gorm.io/gorm"
+
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
 type nft struct {
-	NFTID                       nftIDBytes    `gorm:"primaryKey;notnull"`
-	OutputID                    outputIDBytes `gorm:"unique;notnull"`
-	NativeTokenCount            uint32        `gorm:"notnull;type:integer"`
-	Issuer                      addressBytes  `gorm:"index:nfts_issuer"`
-	Sender                      addressBytes  `gorm:"index:nfts_sender_tag"`
-	Tag                         []byte        `gorm:"index:nfts_sender_tag"`
-	Address                     addressBytes  `gorm:"notnull;index:nfts_address"`
-	StorageDepositReturn        *uint64
-	StorageDepositReturnAddress addressBytes `gorm:"index:nfts_storage_deposit_return_address"`
-	TimelockTime                *time.Time
-	ExpirationTime              *time.Time
-	ExpirationReturnAddress     addressBytes `gorm:"index:nfts_expiration_return_address"`
-	CreatedAt                   time.Time    `gorm:"notnull;index:nfts_created_at"`
+	NFTID                       nftIDBytes    `gorm:"primaryKey;notnull" json:"nftId"`
+	OutputID                    outputIDBytes `gorm:"unique;notnull;index:nfts_created_at,priority:2;index:nfts_issuer,priority:3;index:nfts_sender,priority:3;index:nfts_sender_tag,priority:4;index:nfts_tag,priority:3;index:nfts_address,priority:3;index:nfts_storage_deposit_return_address,priority:3;index:nfts_expiration_return_address,priority:3" json:"outputId"`
+	NativeTokenCount            uint32        `gorm:"notnull;type:integer" json:"nativeTokenCount"`
+	Issuer                      addressBytes  `gorm:"index:nfts_issuer,priority:1" json:"issuer"`
+	Sender                      addressBytes  `gorm:"index:nfts_sender,priority:1;index:nfts_sender_tag,priority:1" json:"sender"`
+	Tag                         []byte        `gorm:"index:nfts_sender_tag,priority:2;index:nfts_tag,priority:1" json:"tag"`
+	Address                     addressBytes  `gorm:"notnull;index:nfts_address,priority:1" json:"address"`
+	StorageDepositReturn        *uint64       `json:"storageDepositReturn"`
+	StorageDepositReturnAddress addressBytes  `gorm:"index:nfts_storage_deposit_return_address,priority:1" json:"storageDepositReturnAddress"`
+	TimelockTime                *time.Time    `gorm:"index:nfts_timelock_time" json:"timelockTime"`
+	ExpirationTime              *time.Time    `gorm:"index:nfts_expiration_time" json:"expirationTime"`
+	ExpirationReturnAddress     addressBytes  `gorm:"index:nfts_expiration_return_address,priority:1" json:"expirationReturnAddress"`
+	// all indexes end in (created_at, output_id), so they also serve the order of the paginated queries
+	CreatedAt time.Time `gorm:"notnull;index:nfts_created_at,priority:1;index:nfts_issuer,priority:2;index:nfts_sender,priority:2;index:nfts_sender_tag,priority:3;index:nfts_tag,priority:2;index:nfts_address,priority:2;index:nfts_storage_deposit_return_address,priority:2;index:nfts_expiration_return_address,priority:2" json:"createdAt"`
 }
 
 type NFTFilterOptions struct {
 	hasNativeTokens                  *bool
 	minNativeTokenCount              *uint32
 	maxNativeTokenCount              *uint32
-	unlockableByAddress              *iotago.Address
+	unlockableByAddress              []iotago.Address
+	notUnlockableByAddress           []iotago.Address
 	hasStorageDepositReturnCondition *bool
 	storageDepositReturnAddress      *iotago.Address
 	hasExpirationCondition           *bool
@@ -36,17 +41,25 @@ type NFTFilterOptions struct {
 	hasTimelockCondition             *bool
 	timelockedBefore                 *time.Time
 	timelockedAfter                  *time.Time
-	issuer                           *iotago.Address
-	sender                           *iotago.Address
-	tag                              []byte
+	issuer                           []iotago.Address
+	notIssuer                        []iotago.Address
+	sender                           []iotago.Address
+	notSender                        []iotago.Address
+	tag                              [][]byte
+	notTag                           [][]byte
+	tagPrefix                        [][]byte
+	tagContains                      [][]byte
 	pageSize                         uint32
 	cursor                           *string
+	backward                         bool
 	createdBefore                    *time.Time
 	createdAfter                     *time.Time
 }
 
 type NFTFilterOption func(*NFTFilterOptions)
 
+type NFTFilterExpression = FilterExpression[NFTFilterOption]
+
 func NFTHasNativeTokens(value bool) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
 		args.hasNativeTokens = &value
@@ -67,7 +80,13 @@ func NFTMaxNativeTokenCount(value uint32) NFTFilterOption {
 
 func NFTUnlockableByAddress(address iotago.Address) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
-		args.unlockableByAddress = &address
+		args.unlockableByAddress = append(args.unlockableByAddress, address)
+	}
+}
+
+func NFTNotUnlockableByAddress(address iotago.Address) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		args.notUnlockableByAddress = append(args.notUnlockableByAddress, address)
 	}
 }
 
@@ -127,19 +146,57 @@ func NFTTimelockedAfter(time time.Time) NFTFilterOption {
 
 func NFTIssuer(address iotago.Address) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
-		args.issuer = &address
+		args.issuer = append(args.issuer, address)
+	}
+}
+
+func NFTNotIssuer(address iotago.Address) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		args.notIssuer = append(args.notIssuer, address)
 	}
 }
 
 func NFTSender(address iotago.Address) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
-		args.sender = &address
+		args.sender = append(args.sender, address)
+	}
+}
+
+func NFTNotSender(address iotago.Address) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		args.notSender = append(args.notSender, address)
 	}
 }
 
 func NFTTag(tag []byte) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
-		args.tag = tag
+		if len(tag) > 0 {
+			args.tag = append(args.tag, tag)
+		}
+	}
+}
+
+func NFTNotTag(tag []byte) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		if len(tag) > 0 {
+			args.notTag = append(args.notTag, tag)
+		}
+	}
+}
+
+func NFTTagPrefix(prefix []byte) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		if len(prefix) > 0 {
+			args.tagPrefix = append(args.tagPrefix, prefix)
+		}
+	}
+}
+
+func NFTTagContains(substring string) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		if len(substring) > 0 {
+			args.tagContains = append(args.tagContains, []byte(substring))
+		}
 	}
 }
 
@@ -152,6 +209,14 @@ func NFTPageSize(pageSize uint32) NFTFilterOption {
 func NFTCursor(cursor string) NFTFilterOption {
 	return func(args *NFTFilterOptions) {
 		args.cursor = &cursor
+		args.backward = false
+	}
+}
+
+func NFTPrevCursor(cursor string) NFTFilterOption {
+	return func(args *NFTFilterOptions) {
+		args.cursor = &cursor
+		args.backward = true
 	}
 }
 
@@ -177,18 +242,15 @@ func nftFilterOptions(optionalOptions []NFTFilterOption) *NFTFilterOptions {
 	return result
 }
 
-func (i *Indexer) NFTOutput(nftID *iotago.NFTID) *IndexerResult {
+func (i *Indexer) NFTOutput(ctx context.Context, nftID *iotago.NFTID) *IndexerResult {
 	query := i.db.Model(&nft{}).
 		Where("nft_id = ?", nftID[:]).
 		Limit(1)
 
-	return i.combineOutputIDFilteredQuery(query, 0, nil)
+	return i.combineOutputIDFilteredQuery(ctx, query, 0, nil, false)
 }
 
-func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResult {
-	opts := nftFilterOptions(filters)
-	query := i.db.Model(&nft{})
-
+func nftConditions(query *gorm.DB, opts *NFTFilterOptions) (*gorm.DB, error) {
 	if opts.hasNativeTokens != nil {
 		if *opts.hasNativeTokens {
 			query = query.Where("native_token_count > 0")
@@ -205,12 +267,12 @@ func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResu
 		query = query.Where("native_token_count <= ?", *opts.maxNativeTokenCount)
 	}
 
-	if opts.unlockableByAddress != nil {
-		addr, err := addressBytesForAddress(*opts.unlockableByAddress)
+	if len(opts.unlockableByAddress) > 0 || len(opts.notUnlockableByAddress) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "address", opts.unlockableByAddress, opts.notUnlockableByAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("address = ?", addr[:])
 	}
 
 	if opts.hasStorageDepositReturnCondition != nil {
@@ -224,7 +286,7 @@ func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResu
 	if opts.storageDepositReturnAddress != nil {
 		addr, err := addressBytesForAddress(*opts.storageDepositReturnAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("storage_deposit_return_address = ?", addr[:])
 	}
@@ -240,17 +302,17 @@ func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResu
 	if opts.expirationReturnAddress != nil {
 		addr, err := addressBytesForAddress(*opts.expirationReturnAddress)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
 		query = query.Where("expiration_return_address = ?", addr[:])
 	}
 
 	if opts.expiresBefore != nil {
-		query = query.Where("expiration_time < ?", *opts.expiresBefore)
+		query = filterByUnlockConditionTime(query, "expiration_time < ?", *opts.expiresBefore)
 	}
 
 	if opts.expiresAfter != nil {
-		query = query.Where("expiration_time > ?", *opts.expiresAfter)
+		query = filterByUnlockConditionTime(query, "expiration_time > ?", *opts.expiresAfter)
 	}
 
 	if opts.hasTimelockCondition != nil {
@@ -262,32 +324,32 @@ func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResu
 	}
 
 	if opts.timelockedBefore != nil {
-		query = query.Where("timelock_time < ?", *opts.timelockedBefore)
+		query = filterByUnlockConditionTime(query, "timelock_time < ?", *opts.timelockedBefore)
 	}
 
 	if opts.timelockedAfter != nil {
-		query = query.Where("timelock_time > ?", *opts.timelockedAfter)
+		query = filterByUnlockConditionTime(query, "timelock_time > ?", *opts.timelockedAfter)
 	}
 
-	if opts.issuer != nil {
-		addr, err := addressBytesForAddress(*opts.issuer)
+	if len(opts.issuer) > 0 || len(opts.notIssuer) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "issuer", opts.issuer, opts.notIssuer)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("issuer = ?", addr[:])
 	}
 
-	if opts.sender != nil {
-		addr, err := addressBytesForAddress(*opts.sender)
+	if len(opts.sender) > 0 || len(opts.notSender) > 0 {
+		var err error
+		query, err = filterByAddresses(query, "sender", opts.sender, opts.notSender)
 		if err != nil {
-			return errorResult(err)
+			return nil, err
 		}
-		query = query.Where("sender = ?", addr[:])
 	}
 
-	if opts.tag != nil && len(opts.tag) > 0 {
-		query = query.Where("tag = ?", opts.tag)
-	}
+	query = filterByValues(query, "tag", opts.tag, opts.notTag)
+	query = filterByPrefixes(query, "tag", opts.tagPrefix)
+	query = filterBySubstrings(query, "tag", opts.tagContains)
 
 	if opts.createdBefore != nil {
 		query = query.Where("created_at < ?", *opts.createdBefore)
@@ -297,5 +359,65 @@ func (i *Indexer) NFTOutputsWithFilters(filters ...NFTFilterOption) *IndexerResu
 		query = query.Where("created_at > ?", *opts.createdAfter)
 	}
 
-	return i.combineOutputIDFilteredQuery(query, opts.pageSize, opts.cursor)
+	return query, nil
+}
+
+func (i *Indexer) NFTOutputsWithFilters(ctx context.Context, filters ...NFTFilterOption) *IndexerResult {
+	opts := nftFilterOptions(filters)
+
+	query, err := nftConditions(i.db.Model(&nft{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(filtersQueryCost(opts.filterNames()), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query, pageSize, opts.cursor, opts.backward)
+}
+
+// NFTOutputsWithFilterExpression returns the NFT outputs matching the given filter expression and filters.
+// Only the paging related filters are useful in combination with an expression, all others can be part of the expression.
+func (i *Indexer) NFTOutputsWithFilterExpression(ctx context.Context, expr *NFTFilterExpression, filters ...NFTFilterOption) *IndexerResult {
+	opts := nftFilterOptions(filters)
+
+	query, err := nftConditions(i.db.Model(&nft{}), opts)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	condition, err := filterExpressionCondition(i.db, expr, func(query *gorm.DB, filters []NFTFilterOption) (*gorm.DB, error) {
+		return nftConditions(query, nftFilterOptions(filters))
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+
+	pageSize, err := i.limitQueryCost(expressionQueryCost(expr, opts.filterNames(), func(filters []NFTFilterOption) []string {
+		return nftFilterOptions(filters).filterNames()
+	}), opts.pageSize)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	return i.combineOutputIDFilteredQuery(ctx, query.Where(condition), pageSize, opts.cursor, opts.backward)
+}
+
+// NFTIDs returns the nftIDs of the given NFT outputs. Outputs that are not unspent anymore are not part of the result.
+func (i *Indexer) NFTIDs(ctx context.Context, outputIDs iotago.OutputIDs) (map[iotago.OutputID]iotago.NFTID, error) {
+	ids, err := i.idsForOutputIDs(ctx, &nft{}, "nft_id", outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[iotago.OutputID]iotago.NFTID, len(ids))
+	for outputID, idBytes := range ids {
+		id := iotago.NFTID{}
+		copy(id[:], idBytes)
+		result[outputID] = id
+	}
+
+	return result, nil
 }
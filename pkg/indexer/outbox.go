@@ -0,0 +1,189 @@
+package indexer
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	inx "github.com/iotaledger/inx/go"
+)
+
+// outboxEntry is the ledger change of a milestone that was not published yet.
+// It is written in the same transaction as the changes of the outputs, so every committed milestone has an entry.
+type outboxEntry struct {
+	MilestoneIndex uint32 `gorm:"primaryKey;notnull" json:"milestoneIndex"`
+	Payload        []byte `gorm:"notnull" json:"payload"`
+}
+
+func (o *outboxEntry) TableName() string {
+	return "outbox"
+}
+
+// OutboxMessage is the ledger change of a milestone in the outbox.
+type OutboxMessage struct {
+	// MilestoneIndex is the index of the milestone, it identifies the message.
+	MilestoneIndex uint32
+	// Payload is the JSON encoded OutboxPayload.
+	Payload []byte
+}
+
+// OutboxOutput is an output in the payload of an outbox message.
+type OutboxOutput struct {
+	// OutputID is the hex encoded ID of the output.
+	OutputID string `json:"outputId"`
+	// Output is the JSON encoded output.
+	Output json.RawMessage `json:"output"`
+}
+
+// OutboxPayload is the payload of an outbox message.
+type OutboxPayload struct {
+	// MilestoneIndex is the index of the milestone.
+	MilestoneIndex uint32 `json:"milestoneIndex"`
+	// Reset is set if the indexer imported the ledger at the milestone index, e.g. after it was cleared.
+	// The message does not contain the outputs of the ledger, consumers have to discard their state
+	// and read the ledger at the milestone index from the indexer. The following messages build on that ledger.
+	Reset bool `json:"reset,omitempty"`
+	// Created are the outputs that were created by the milestone.
+	Created []*OutboxOutput `json:"created"`
+	// Consumed are the outputs that were consumed by the milestone.
+	Consumed []*OutboxOutput `json:"consumed"`
+}
+
+func outboxOutput(output *inx.LedgerOutput) (*OutboxOutput, error) {
+	unwrapped, err := output.UnwrapOutput(serializer.DeSeriModeNoValidation, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	outputJSON, err := json.Marshal(unwrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxOutput{
+		OutputID: output.GetOutputId().Unwrap().ToHex(),
+		Output:   outputJSON,
+	}, nil
+}
+
+// outboxEntryForLedgerUpdate returns the outbox entry of the ledger update.
+// Outputs that were created and consumed in the same milestone never became part of the indexed ledger, so they are left out.
+func outboxEntryForLedgerUpdate(update *nodebridge.LedgerUpdate) (*outboxEntry, error) {
+	consumedOutputs := make(map[string]struct{}, len(update.Consumed))
+	for _, spent := range update.Consumed {
+		consumedOutputs[string(spent.GetOutput().GetOutputId().GetId())] = struct{}{}
+	}
+
+	createdOutputs := make(map[string]struct{}, len(update.Created))
+	payload := &OutboxPayload{
+		MilestoneIndex: update.MilestoneIndex,
+		Created:        make([]*OutboxOutput, 0, len(update.Created)),
+		Consumed:       make([]*OutboxOutput, 0, len(update.Consumed)),
+	}
+
+	for _, output := range update.Created {
+		outputID := string(output.GetOutputId().GetId())
+		createdOutputs[outputID] = struct{}{}
+		if _, wasSpentInSameMilestone := consumedOutputs[outputID]; wasSpentInSameMilestone {
+			continue
+		}
+
+		created, err := outboxOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		payload.Created = append(payload.Created, created)
+	}
+
+	for _, spent := range update.Consumed {
+		if _, wasCreatedInSameMilestone := createdOutputs[string(spent.GetOutput().GetOutputId().GetId())]; wasCreatedInSameMilestone {
+			continue
+		}
+
+		consumed, err := outboxOutput(spent.GetOutput())
+		if err != nil {
+			return nil, err
+		}
+		payload.Consumed = append(payload.Consumed, consumed)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outboxEntry{
+		MilestoneIndex: update.MilestoneIndex,
+		Payload:        data,
+	}, nil
+}
+
+// EnableOutbox creates the outbox table and lets UpdatedLedger write an entry for every milestone.
+// The table is not part of the indexed ledger, so it is kept if the indexer is cleared.
+func (i *Indexer) EnableOutbox() error {
+	if err := i.db.AutoMigrate(&outboxEntry{}); err != nil {
+		return err
+	}
+	i.outboxEnabled.Store(true)
+
+	return nil
+}
+
+// OutboxMessages returns up to limit messages of the outbox, ordered by their milestone index.
+func (i *Indexer) OutboxMessages(limit int) ([]*OutboxMessage, error) {
+	var entries []*outboxEntry
+	if err := i.db.Order("milestone_index asc").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	messages := make([]*OutboxMessage, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, &OutboxMessage{
+			MilestoneIndex: entry.MilestoneIndex,
+			Payload:        entry.Payload,
+		})
+	}
+
+	return messages, nil
+}
+
+// AcknowledgeOutbox removes the messages up to the given milestone index from the outbox after they were published.
+func (i *Indexer) AcknowledgeOutbox(milestoneIndex uint32) error {
+	return i.db.Where("milestone_index <= ?", milestoneIndex).Delete(&outboxEntry{}).Error
+}
+
+// writeOutboxResetEntry writes the reset message of a ledger that was imported at the given ledger index.
+// Unpublished messages of later milestones belong to the previous ledger, so they are removed.
+func writeOutboxResetEntry(tx *gorm.DB, ledgerIndex uint32) error {
+	data, err := json.Marshal(&OutboxPayload{
+		MilestoneIndex: ledgerIndex,
+		Reset:          true,
+		Created:        make([]*OutboxOutput, 0),
+		Consumed:       make([]*OutboxOutput, 0),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Where("milestone_index > ?", ledgerIndex).Delete(&outboxEntry{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&outboxEntry{
+		MilestoneIndex: ledgerIndex,
+		Payload:        data,
+	}).Error
+}
+
+func writeOutboxEntry(tx *gorm.DB, update *nodebridge.LedgerUpdate) error {
+	entry, err := outboxEntryForLedgerUpdate(update)
+	if err != nil {
+		return err
+	}
+
+	// an entry of an older ledger is replaced if the indexer was cleared before it was published
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(entry).Error
+}
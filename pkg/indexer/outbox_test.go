@@ -0,0 +1,51 @@
+package indexer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/iotaledger/inx-indexer/pkg/database"
+)
+
+func TestOutboxResetAfterImport(t *testing.T) {
+	idx := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	if err := idx.EnableOutbox(); err != nil {
+		t.Fatal(err)
+	}
+
+	// unpublished messages of the previous ledger
+	for _, milestoneIndex := range []uint32{testLedgerIndex - 10, testLedgerIndex, testLedgerIndex + 5} {
+		if err := idx.db.Create(&outboxEntry{MilestoneIndex: milestoneIndex, Payload: []byte("{}")}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := idx.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	generateTestLedger(t, idx, 100, 3)
+
+	messages, err := idx.OutboxMessages(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected the earlier message and the reset message, got %d messages", len(messages))
+	}
+	if messages[0].MilestoneIndex != testLedgerIndex-10 {
+		t.Errorf("expected the earlier message to be kept, got milestone %d", messages[0].MilestoneIndex)
+	}
+
+	reset := messages[1]
+	if reset.MilestoneIndex != testLedgerIndex {
+		t.Fatalf("expected the reset message at the ledger index %d, got %d", testLedgerIndex, reset.MilestoneIndex)
+	}
+
+	payload := &OutboxPayload{}
+	if err := json.Unmarshal(reset.Payload, payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.Reset || payload.MilestoneIndex != testLedgerIndex || len(payload.Created) != 0 || len(payload.Consumed) != 0 {
+		t.Errorf("expected an empty reset message, got %s", reset.Payload)
+	}
+}
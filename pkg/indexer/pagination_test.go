@@ -0,0 +1,254 @@
+package indexer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func testQueryResult(createdAt uint32, id byte) queryResult {
+	outputID := make(outputIDBytes, iotago.OutputIDLength)
+	outputID[0] = id
+
+	return queryResult{
+		OutputID:  outputID,
+		CreatedAt: unixTime(createdAt),
+	}
+}
+
+func TestCursor(t *testing.T) {
+	result := testQueryResult(testFirstTimestamp, 0xab)
+
+	cursor := result.cursor()
+	if len(cursor) != CursorLength {
+		t.Fatalf("expected cursor length %d, got %d", CursorLength, len(cursor))
+	}
+
+	createdAt, outputID, err := parseCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !createdAt.Equal(result.CreatedAt) {
+		t.Errorf("expected creation time %s, got %s", result.CreatedAt, createdAt)
+	}
+	if !reflect.DeepEqual(outputID, result.OutputID) {
+		t.Errorf("expected output ID %x, got %x", result.OutputID, outputID)
+	}
+
+	for _, invalid := range []string{"", cursor[:CursorLength-2], "zzzzzzzz" + cursor[8:], cursor[:8] + "zz" + cursor[10:]} {
+		if _, _, err := parseCursor(invalid); err == nil {
+			t.Errorf("expected cursor %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	results := []queryResult{
+		testQueryResult(1, 1),
+		testQueryResult(1, 2),
+		testQueryResult(2, 1),
+		testQueryResult(3, 1),
+	}
+	reversed := func(results []queryResult) []queryResult {
+		r := make([]queryResult, 0, len(results))
+		for i := len(results) - 1; i >= 0; i-- {
+			r = append(r, results[i])
+		}
+
+		return r
+	}
+	cursor := func(result queryResult) *string {
+		c := result.cursor()
+
+		return &c
+	}
+
+	tests := []struct {
+		name     string
+		results  []queryResult
+		pageSize uint32
+		cursor   *string
+		backward bool
+		page     []queryResult
+		next     *string
+		prev     *string
+	}{
+		{
+			name:     "no pagination",
+			results:  results,
+			pageSize: 0,
+			page:     results,
+		},
+		{
+			name:     "first page with a next page",
+			results:  results[:3],
+			pageSize: 2,
+			page:     results[:2],
+			next:     cursor(results[2]),
+		},
+		{
+			name:     "single page",
+			results:  results[:2],
+			pageSize: 2,
+			page:     results[:2],
+		},
+		{
+			name:     "middle page",
+			results:  results[1:4],
+			pageSize: 2,
+			cursor:   cursor(results[1]),
+			page:     results[1:3],
+			next:     cursor(results[3]),
+			prev:     cursor(results[1]),
+		},
+		{
+			name:     "empty page after a cursor",
+			results:  nil,
+			pageSize: 2,
+			cursor:   cursor(results[3]),
+			prev:     cursor(results[3]),
+		},
+		{
+			name:     "previous page with more results before it",
+			results:  reversed(results[:3]),
+			pageSize: 2,
+			cursor:   cursor(results[3]),
+			backward: true,
+			page:     results[1:3],
+			next:     cursor(results[3]),
+			prev:     cursor(results[1]),
+		},
+		{
+			name:     "previous page is the first page",
+			results:  reversed(results[:2]),
+			pageSize: 2,
+			cursor:   cursor(results[2]),
+			backward: true,
+			page:     results[:2],
+			next:     cursor(results[2]),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			page, next, prev := paginate(append([]queryResult{}, test.results...), test.pageSize, test.cursor, test.backward)
+
+			if len(page) != len(test.page) || (len(page) > 0 && !reflect.DeepEqual(page, test.page)) {
+				t.Errorf("expected page %v, got %v", test.page, page)
+			}
+			if !reflect.DeepEqual(next, test.next) {
+				t.Errorf("expected next cursor %v, got %v", stringValue(test.next), stringValue(next))
+			}
+			if !reflect.DeepEqual(prev, test.prev) {
+				t.Errorf("expected previous cursor %v, got %v", stringValue(test.prev), stringValue(prev))
+			}
+		})
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	return *s
+}
+
+// TestPaginationWalk walks all pages forward with the next cursors and back again with the previous cursors.
+// The outputs share their creation times, so the pages also have to be split between outputs of the same time.
+func TestPaginationWalk(t *testing.T) {
+	const pageSize = 7
+
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			idx := newTestIndexer(t, dbParams)
+			ledger := generateTestLedger(t, idx, 500, 1)
+
+			var addresses []BasicOutputFilterOption
+			for _, address := range ledger.addresses[:50] {
+				addresses = append(addresses, BasicOutputUnlockableByAddress(address))
+			}
+
+			filters := map[string][]BasicOutputFilterOption{
+				"no filters":   nil,
+				"address":      addresses,
+				"hasTimelock":  {BasicOutputHasTimelockCondition(true)},
+				"createdAfter": {BasicOutputCreatedAfter(time.Unix(testFirstTimestamp+3, 0))},
+			}
+
+			for name, filter := range filters {
+				filter := filter
+				t.Run(name, func(t *testing.T) {
+					ctx := context.Background()
+
+					// a single page that holds all outputs
+					all := idx.BasicOutputsWithFilters(ctx, append(filter, BasicOutputPageSize(1000))...)
+					if all.Error != nil {
+						t.Fatal(all.Error)
+					}
+					if all.Cursor != nil {
+						t.Fatal("expected all outputs to fit on a single page")
+					}
+					if len(all.OutputIDs) < 2*pageSize {
+						t.Fatalf("expected more than two pages, got %d outputs", len(all.OutputIDs))
+					}
+
+					var pages []iotago.OutputIDs
+					var walked iotago.OutputIDs
+					result := idx.BasicOutputsWithFilters(ctx, append(filter, BasicOutputPageSize(pageSize))...)
+					for {
+						if result.Error != nil {
+							t.Fatal(result.Error)
+						}
+						pages = append(pages, result.OutputIDs)
+						walked = append(walked, result.OutputIDs...)
+
+						if result.Cursor == nil {
+							break
+						}
+						if len(result.OutputIDs) != pageSize {
+							t.Fatalf("expected a full page before the last one, got %d outputs", len(result.OutputIDs))
+						}
+						result = idx.BasicOutputsWithFilters(ctx, append(filter, BasicOutputPageSize(pageSize), BasicOutputCursor(*result.Cursor))...)
+					}
+
+					if !reflect.DeepEqual(walked, all.OutputIDs) {
+						t.Fatalf("expected the pages to contain all %d outputs in order, got %d outputs", len(all.OutputIDs), len(walked))
+					}
+
+					for page := len(pages) - 2; page >= 0; page-- {
+						if result.PrevCursor == nil {
+							t.Fatalf("expected a previous cursor on page %d", page+1)
+						}
+						result = idx.BasicOutputsWithFilters(ctx, append(filter, BasicOutputPageSize(pageSize), BasicOutputPrevCursor(*result.PrevCursor))...)
+						if result.Error != nil {
+							t.Fatal(result.Error)
+						}
+						if !reflect.DeepEqual(result.OutputIDs, pages[page]) {
+							t.Fatalf("expected the previous page to be page %d", page)
+						}
+					}
+
+					if result.PrevCursor != nil {
+						t.Errorf("expected no previous cursor on the first page, got %s", *result.PrevCursor)
+					}
+					if result.Cursor == nil || len(pages) < 2 {
+						t.Fatal("expected a next cursor on the first page")
+					}
+					next := idx.BasicOutputsWithFilters(ctx, append(filter, BasicOutputPageSize(pageSize), BasicOutputCursor(*result.Cursor))...)
+					if next.Error != nil {
+						t.Fatal(next.Error)
+					}
+					if !reflect.DeepEqual(next.OutputIDs, pages[1]) {
+						t.Error("expected the next cursor of a previous page to lead to the second page")
+					}
+				})
+			}
+		})
+	}
+}
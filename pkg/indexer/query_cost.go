@@ -0,0 +1,301 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ScanFactorIndexed is the amount of rows read per result if a filter is served by an index in the order of the pages.
+	ScanFactorIndexed uint64 = 1
+	// ScanFactorIndexedRange is the amount of rows read per result if a filter is served by an index in a different order,
+	// so the matching rows have to be sorted.
+	ScanFactorIndexedRange uint64 = 10
+	// ScanFactorUnindexed is the amount of rows read per result if no filter is served by an index,
+	// so the rows are read in the order of the pages until enough of them match.
+	ScanFactorUnindexed uint64 = 100
+
+	// DefaultMaxQueryCost is the default maximum query cost of the REST API.
+	// Pages of the default maximum page size of 1000 are allowed for all filters that are served by an index,
+	// while queries without a selective index are limited to pages of 100 results.
+	DefaultMaxQueryCost = 1000 * ScanFactorIndexedRange
+	// DefaultReducePageSize is the default of whether the REST API reduces the page size of too expensive queries.
+	// Clients that relied on full pages of unindexed filters get smaller pages instead of errors.
+	DefaultReducePageSize = true
+)
+
+var (
+	ErrQueryTooExpensive = errors.New("query exceeds the maximum query cost")
+
+	// queryCostScanFactors are the scan factors of the filters that are served by an index.
+	// All other filters, e.g. hasNativeTokens or the negated filters, are not served by an index.
+	// The rules follow the indexes of the output tables, TestQueryPlans checks them against the plans of the combinations.
+	queryCostScanFactors = map[string]uint64{
+		"address":                     ScanFactorIndexed,
+		"sender":                      ScanFactorIndexed,
+		"tag":                         ScanFactorIndexed,
+		"issuer":                      ScanFactorIndexed,
+		"stateController":             ScanFactorIndexed,
+		"governor":                    ScanFactorIndexed,
+		"aliasAddress":                ScanFactorIndexed,
+		"storageDepositReturnAddress": ScanFactorIndexed,
+		"expirationReturnAddress":     ScanFactorIndexed,
+		"createdBefore":               ScanFactorIndexed,
+		"createdAfter":                ScanFactorIndexed,
+		"tagPrefix":                   ScanFactorIndexedRange,
+		"expiresBefore":               ScanFactorIndexedRange,
+		"expiresAfter":                ScanFactorIndexedRange,
+		"timelockedBefore":            ScanFactorIndexedRange,
+		"timelockedAfter":             ScanFactorIndexedRange,
+	}
+)
+
+// CheckMaxQueryCost returns an error if pages of the given maximum page size exceed the maximum query cost
+// for the filters that are served by a range of an index, so clients could not use their maximum page size with them.
+func CheckMaxQueryCost(maxQueryCost uint64, maxPageSize int) error {
+	if maxQueryCost == 0 || maxPageSize <= 0 {
+		return nil
+	}
+
+	if cost := uint64(maxPageSize) * ScanFactorIndexedRange; cost > maxQueryCost {
+		return errors.Errorf("the maximum query cost of %d is too low for pages of %d results, filters that are served by an index range need %d, raise the maximum query cost to at least %d or lower the page size to at most %d",
+			maxQueryCost, maxPageSize, cost, cost, maxQueryCost/ScanFactorIndexedRange)
+	}
+
+	return nil
+}
+
+// QueryCost is the estimated cost of a paginated query.
+type QueryCost struct {
+	// ScanFactor is the estimated amount of rows read per result.
+	ScanFactor uint64
+	// Filters are the names of the filters of the query.
+	Filters []string
+}
+
+// Cost returns the estimated amount of rows read for a page of the given size.
+func (c *QueryCost) Cost(pageSize uint32) uint64 {
+	return uint64(pageSize) * c.ScanFactor
+}
+
+// filtersScanFactor returns the scan factor of a combination of filters that all have to match.
+// The most selective filter decides which index is used, so it determines the scan factor.
+// Without filters the rows are read in the order of the pages, which needs no additional rows.
+func filtersScanFactor(filters []string) uint64 {
+	if len(filters) == 0 {
+		return ScanFactorIndexed
+	}
+
+	scanFactor := ScanFactorUnindexed
+	for _, filter := range filters {
+		if factor, exists := queryCostScanFactors[filter]; exists && factor < scanFactor {
+			scanFactor = factor
+		}
+	}
+
+	return scanFactor
+}
+
+// expressionQueryCost estimates the cost of a filter expression combined with the given filters.
+// All operands of an "or" have to be read, a negation can not be served by an index.
+func expressionQueryCost[T any](expr *FilterExpression[T], filters []string, filterNames func([]T) []string) *QueryCost {
+	names := make(map[string]struct{})
+	for _, filter := range filters {
+		names[filter] = struct{}{}
+	}
+
+	var scanFactor func(expr *FilterExpression[T]) uint64
+	scanFactor = func(expr *FilterExpression[T]) uint64 {
+		switch {
+		case expr == nil:
+			return ScanFactorIndexed
+		case len(expr.And) > 0:
+			factor := ScanFactorUnindexed
+			for _, operand := range expr.And {
+				if operandFactor := scanFactor(operand); operandFactor < factor {
+					factor = operandFactor
+				}
+			}
+
+			return factor
+		case len(expr.Or) > 0:
+			var factor uint64
+			for _, operand := range expr.Or {
+				factor += scanFactor(operand)
+			}
+			if factor > ScanFactorUnindexed {
+				factor = ScanFactorUnindexed
+			}
+
+			return factor
+		case expr.Not != nil:
+			// the operand is only walked to collect the names of its filters
+			scanFactor(expr.Not)
+
+			return ScanFactorUnindexed
+		default:
+			leafFilters := filterNames(expr.Filters)
+			for _, filter := range leafFilters {
+				names[filter] = struct{}{}
+			}
+
+			return filtersScanFactor(leafFilters)
+		}
+	}
+
+	factor := scanFactor(expr)
+	if len(filters) > 0 {
+		if filtersFactor := filtersScanFactor(filters); filtersFactor < factor {
+			factor = filtersFactor
+		}
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	return &QueryCost{
+		ScanFactor: factor,
+		Filters:    sortedNames,
+	}
+}
+
+func filtersQueryCost(filters []string) *QueryCost {
+	return &QueryCost{
+		ScanFactor: filtersScanFactor(filters),
+		Filters:    filters,
+	}
+}
+
+// limitQueryCost checks the estimated cost of a page against the maximum query cost.
+// Too expensive queries get a smaller page size if that is enabled, otherwise or if even a single result is too expensive,
+// ErrQueryTooExpensive is returned. A page size of 0 is not paginated, so its cost is unbounded.
+func (i *Indexer) limitQueryCost(cost *QueryCost, pageSize uint32) (uint32, error) {
+	if i.maxQueryCost == 0 || (pageSize > 0 && cost.Cost(pageSize) <= i.maxQueryCost) {
+		return pageSize, nil
+	}
+
+	maxPageSize := i.maxQueryCost / cost.ScanFactor
+	if i.reducePageSize && maxPageSize > 0 {
+		return uint32(maxPageSize), nil
+	}
+
+	filters := "no filters"
+	if len(cost.Filters) > 0 {
+		filters = "the filters " + strings.Join(cost.Filters, ", ")
+	}
+
+	estimate := fmt.Sprintf("the estimated cost of %d rows", cost.Cost(pageSize))
+	if pageSize == 0 {
+		estimate = "the unbounded cost of a query without page size"
+	}
+
+	message := fmt.Sprintf("%s (%d rows per result for %s) exceeds the maximum of %d, add a filter that is served by an index, e.g. address, sender or tag",
+		estimate, cost.ScanFactor, filters, i.maxQueryCost)
+	if maxPageSize > 0 {
+		message += fmt.Sprintf(", or use a page size of at most %d", maxPageSize)
+	}
+
+	return 0, errors.WithMessage(ErrQueryTooExpensive, message)
+}
+
+func (o *BasicOutputFilterOptions) filterNames() []string {
+	return setFilterNames(map[string]bool{
+		"hasNativeTokens":             o.hasNativeTokens != nil,
+		"minNativeTokenCount":         o.minNativeTokenCount != nil,
+		"maxNativeTokenCount":         o.maxNativeTokenCount != nil,
+		"address":                     len(o.unlockableByAddress) > 0,
+		"notAddress":                  len(o.notUnlockableByAddress) > 0,
+		"hasStorageDepositReturn":     o.hasStorageDepositReturnCondition != nil,
+		"storageDepositReturnAddress": o.storageDepositReturnAddress != nil,
+		"hasExpiration":               o.hasExpirationCondition != nil,
+		"expirationReturnAddress":     o.expirationReturnAddress != nil,
+		"expiresBefore":               o.expiresBefore != nil,
+		"expiresAfter":                o.expiresAfter != nil,
+		"hasTimelock":                 o.hasTimelockCondition != nil,
+		"timelockedBefore":            o.timelockedBefore != nil,
+		"timelockedAfter":             o.timelockedAfter != nil,
+		"sender":                      len(o.sender) > 0,
+		"notSender":                   len(o.notSender) > 0,
+		"tag":                         len(o.tag) > 0,
+		"notTag":                      len(o.notTag) > 0,
+		"tagPrefix":                   len(o.tagPrefix) > 0,
+		"tagContains":                 len(o.tagContains) > 0,
+		"createdBefore":               o.createdBefore != nil,
+		"createdAfter":                o.createdAfter != nil,
+	})
+}
+
+func (o *NFTFilterOptions) filterNames() []string {
+	return setFilterNames(map[string]bool{
+		"hasNativeTokens":             o.hasNativeTokens != nil,
+		"minNativeTokenCount":         o.minNativeTokenCount != nil,
+		"maxNativeTokenCount":         o.maxNativeTokenCount != nil,
+		"address":                     len(o.unlockableByAddress) > 0,
+		"notAddress":                  len(o.notUnlockableByAddress) > 0,
+		"hasStorageDepositReturn":     o.hasStorageDepositReturnCondition != nil,
+		"storageDepositReturnAddress": o.storageDepositReturnAddress != nil,
+		"hasExpiration":               o.hasExpirationCondition != nil,
+		"expirationReturnAddress":     o.expirationReturnAddress != nil,
+		"expiresBefore":               o.expiresBefore != nil,
+		"expiresAfter":                o.expiresAfter != nil,
+		"hasTimelock":                 o.hasTimelockCondition != nil,
+		"timelockedBefore":            o.timelockedBefore != nil,
+		"timelockedAfter":             o.timelockedAfter != nil,
+		"issuer":                      len(o.issuer) > 0,
+		"notIssuer":                   len(o.notIssuer) > 0,
+		"sender":                      len(o.sender) > 0,
+		"notSender":                   len(o.notSender) > 0,
+		"tag":                         len(o.tag) > 0,
+		"notTag":                      len(o.notTag) > 0,
+		"tagPrefix":                   len(o.tagPrefix) > 0,
+		"tagContains":                 len(o.tagContains) > 0,
+		"createdBefore":               o.createdBefore != nil,
+		"createdAfter":                o.createdAfter != nil,
+	})
+}
+
+func (o *AliasFilterOptions) filterNames() []string {
+	return setFilterNames(map[string]bool{
+		"hasNativeTokens":     o.hasNativeTokens != nil,
+		"minNativeTokenCount": o.minNativeTokenCount != nil,
+		"maxNativeTokenCount": o.maxNativeTokenCount != nil,
+		"stateController":     o.stateController != nil,
+		"governor":            o.governor != nil,
+		"issuer":              len(o.issuer) > 0,
+		"notIssuer":           len(o.notIssuer) > 0,
+		"sender":              len(o.sender) > 0,
+		"notSender":           len(o.notSender) > 0,
+		"createdBefore":       o.createdBefore != nil,
+		"createdAfter":        o.createdAfter != nil,
+	})
+}
+
+func (o *FoundryFilterOptions) filterNames() []string {
+	return setFilterNames(map[string]bool{
+		"hasNativeTokens":     o.hasNativeTokens != nil,
+		"minNativeTokenCount": o.minNativeTokenCount != nil,
+		"maxNativeTokenCount": o.maxNativeTokenCount != nil,
+		"aliasAddress":        o.aliasAddress != nil,
+		"createdBefore":       o.createdBefore != nil,
+		"createdAfter":        o.createdAfter != nil,
+	})
+}
+
+// setFilterNames returns the sorted names of the filters that are set.
+func setFilterNames(filters map[string]bool) []string {
+	names := make([]string, 0)
+	for name, isSet := range filters {
+		if isSet {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
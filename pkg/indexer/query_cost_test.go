@@ -0,0 +1,314 @@
+package indexer
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestFiltersScanFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		filters    []string
+		scanFactor uint64
+	}{
+		{
+			name:       "no filters",
+			scanFactor: ScanFactorIndexed,
+		},
+		{
+			name:       "indexed filter",
+			filters:    []string{"address"},
+			scanFactor: ScanFactorIndexed,
+		},
+		{
+			name:       "range filter",
+			filters:    []string{"tagPrefix"},
+			scanFactor: ScanFactorIndexedRange,
+		},
+		{
+			name:       "unindexed filter",
+			filters:    []string{"hasNativeTokens"},
+			scanFactor: ScanFactorUnindexed,
+		},
+		{
+			name:       "negated filter",
+			filters:    []string{"notAddress"},
+			scanFactor: ScanFactorUnindexed,
+		},
+		{
+			name:       "most selective filter",
+			filters:    []string{"hasNativeTokens", "expiresBefore", "sender"},
+			scanFactor: ScanFactorIndexed,
+		},
+		{
+			name:       "range and unindexed filters",
+			filters:    []string{"hasTimelock", "timelockedAfter"},
+			scanFactor: ScanFactorIndexedRange,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if scanFactor := filtersScanFactor(test.filters); scanFactor != test.scanFactor {
+				t.Errorf("expected scan factor %d, got %d", test.scanFactor, scanFactor)
+			}
+		})
+	}
+}
+
+func TestLimitQueryCost(t *testing.T) {
+	unindexed := filtersQueryCost([]string{"hasNativeTokens"})
+	indexed := filtersQueryCost([]string{"address"})
+
+	tests := []struct {
+		name           string
+		maxQueryCost   uint64
+		reducePageSize bool
+		cost           *QueryCost
+		pageSize       uint32
+		limited        uint32
+		message        string
+	}{
+		{
+			name:     "no maximum",
+			cost:     unindexed,
+			pageSize: 1000,
+			limited:  1000,
+		},
+		{
+			name:         "within the maximum",
+			maxQueryCost: 10_000,
+			cost:         unindexed,
+			pageSize:     100,
+			limited:      100,
+		},
+		{
+			name:         "indexed filter",
+			maxQueryCost: 10_000,
+			cost:         indexed,
+			pageSize:     10_000,
+			limited:      10_000,
+		},
+		{
+			name:           "reduced page size",
+			maxQueryCost:   10_000,
+			reducePageSize: true,
+			cost:           unindexed,
+			pageSize:       1000,
+			limited:        100,
+		},
+		{
+			name:         "default maximum with an indexed filter",
+			maxQueryCost: DefaultMaxQueryCost,
+			cost:         indexed,
+			pageSize:     1000,
+			limited:      1000,
+		},
+		{
+			name:         "default maximum with a range filter",
+			maxQueryCost: DefaultMaxQueryCost,
+			cost:         filtersQueryCost([]string{"tagPrefix"}),
+			pageSize:     1000,
+			limited:      1000,
+		},
+		{
+			name:           "default settings with only an unindexed filter",
+			maxQueryCost:   DefaultMaxQueryCost,
+			reducePageSize: DefaultReducePageSize,
+			cost:           unindexed,
+			pageSize:       1000,
+			limited:        100,
+		},
+		{
+			name:         "default maximum with only an unindexed filter rejected",
+			maxQueryCost: DefaultMaxQueryCost,
+			cost:         unindexed,
+			pageSize:     1000,
+			message:      "use a page size of at most 100",
+		},
+		{
+			name:         "rejected",
+			maxQueryCost: 10_000,
+			cost:         unindexed,
+			pageSize:     1000,
+			message:      "the estimated cost of 100000 rows (100 rows per result for the filters hasNativeTokens) exceeds the maximum of 10000",
+		},
+		{
+			name:           "single result too expensive",
+			maxQueryCost:   50,
+			reducePageSize: true,
+			cost:           unindexed,
+			pageSize:       1000,
+			message:        "exceeds the maximum of 50, add a filter",
+		},
+		{
+			name:           "without page size reduced",
+			maxQueryCost:   10_000,
+			reducePageSize: true,
+			cost:           indexed,
+			pageSize:       0,
+			limited:        10_000,
+		},
+		{
+			name:         "without page size rejected",
+			maxQueryCost: 10_000,
+			cost:         indexed,
+			pageSize:     0,
+			message:      "the unbounded cost of a query without page size",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			idx := &Indexer{maxQueryCost: test.maxQueryCost, reducePageSize: test.reducePageSize}
+
+			pageSize, err := idx.limitQueryCost(test.cost, test.pageSize)
+			if test.message != "" {
+				if !errors.Is(err, ErrQueryTooExpensive) {
+					t.Fatalf("expected the query to be too expensive, got %v", err)
+				}
+				if !strings.Contains(err.Error(), test.message) {
+					t.Errorf("expected error %q, got %q", test.message, err.Error())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pageSize != test.limited {
+				t.Errorf("expected page size %d, got %d", test.limited, pageSize)
+			}
+		})
+	}
+}
+
+func TestExpressionQueryCost(t *testing.T) {
+	filterNames := func(filters []string) []string {
+		sorted := append([]string{}, filters...)
+		sort.Strings(sorted)
+
+		return sorted
+	}
+	leaf := func(filters ...string) *FilterExpression[string] {
+		return &FilterExpression[string]{Filters: filters}
+	}
+
+	tests := []struct {
+		name       string
+		expr       *FilterExpression[string]
+		filters    []string
+		scanFactor uint64
+		names      []string
+	}{
+		{
+			name:       "indexed filter",
+			expr:       leaf("address"),
+			scanFactor: ScanFactorIndexed,
+			names:      []string{"address"},
+		},
+		{
+			name:       "unindexed filter",
+			expr:       leaf("hasNativeTokens"),
+			scanFactor: ScanFactorUnindexed,
+			names:      []string{"hasNativeTokens"},
+		},
+		{
+			name:       "and uses the most selective operand",
+			expr:       &FilterExpression[string]{And: []*FilterExpression[string]{leaf("hasNativeTokens"), leaf("tagPrefix")}},
+			scanFactor: ScanFactorIndexedRange,
+			names:      []string{"hasNativeTokens", "tagPrefix"},
+		},
+		{
+			name:       "or reads all operands",
+			expr:       &FilterExpression[string]{Or: []*FilterExpression[string]{leaf("address"), leaf("tagPrefix")}},
+			scanFactor: ScanFactorIndexed + ScanFactorIndexedRange,
+			names:      []string{"address", "tagPrefix"},
+		},
+		{
+			name:       "or is limited to an unindexed scan",
+			expr:       &FilterExpression[string]{Or: []*FilterExpression[string]{leaf("address"), leaf("hasNativeTokens")}},
+			scanFactor: ScanFactorUnindexed,
+			names:      []string{"address", "hasNativeTokens"},
+		},
+		{
+			name:       "not is unindexed",
+			expr:       &FilterExpression[string]{Not: leaf("address")},
+			scanFactor: ScanFactorUnindexed,
+			names:      []string{"address"},
+		},
+		{
+			name:       "filters of the query",
+			expr:       &FilterExpression[string]{Not: leaf("address")},
+			filters:    []string{"sender"},
+			scanFactor: ScanFactorIndexed,
+			names:      []string{"address", "sender"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cost := expressionQueryCost(test.expr, test.filters, filterNames)
+			if cost.ScanFactor != test.scanFactor {
+				t.Errorf("expected scan factor %d, got %d", test.scanFactor, cost.ScanFactor)
+			}
+			if !reflect.DeepEqual(cost.Filters, test.names) {
+				t.Errorf("expected filters %v, got %v", test.names, cost.Filters)
+			}
+		})
+	}
+}
+
+func TestCheckMaxQueryCost(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxQueryCost uint64
+		maxPageSize  int
+		valid        bool
+	}{
+		{
+			name:         "defaults",
+			maxQueryCost: DefaultMaxQueryCost,
+			maxPageSize:  1000,
+			valid:        true,
+		},
+		{
+			name:        "no maximum",
+			maxPageSize: 100_000,
+			valid:       true,
+		},
+		{
+			// the page size of the API is used
+			name:         "no page size",
+			maxQueryCost: 100,
+			valid:        true,
+		},
+		{
+			name:         "page size above the maximum",
+			maxQueryCost: DefaultMaxQueryCost,
+			maxPageSize:  1001,
+		},
+		{
+			name:         "maximum below a page of an index range",
+			maxQueryCost: 5000,
+			maxPageSize:  1000,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if err := CheckMaxQueryCost(test.maxQueryCost, test.maxPageSize); (err == nil) != test.valid {
+				t.Errorf("expected valid: %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
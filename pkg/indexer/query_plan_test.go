@@ -0,0 +1,634 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// queryPlanPageSize is the page size of the explained queries.
+	queryPlanPageSize = 100
+	// queryPlanBasicOutputs is the amount of generated basic outputs, the other tables get a share of it.
+	// The planner needs realistic statistics, on nearly empty tables it prefers reading everything.
+	queryPlanBasicOutputs = 20_000
+)
+
+var (
+	// queryPlanTables are the output tables that are filtered by the API.
+	queryPlanTables = map[string]interface{}{
+		"basic_outputs": &basicOutput{},
+		"nfts":          &nft{},
+		"aliases":       &alias{},
+		"foundries":     &foundry{},
+	}
+)
+
+// queryPlan is the query plan the database chose for a combination of filters.
+type queryPlan struct {
+	// table is the table the filters were applied to.
+	table string
+	// filters are the names of the applied filters.
+	filters []string
+	// page is the page of the paginated query that was explained.
+	page string
+	// sql is the explained statement.
+	sql string
+	// lines contains the lines of the query plan.
+	lines []string
+	// fullScans are the steps of the plan that read a whole table, either directly or in the order of an index.
+	fullScans []string
+}
+
+func (p *queryPlan) String() string {
+	return fmt.Sprintf("%s [%s] (%s page)\n%s\n  %s", p.table, strings.Join(p.filters, ", "), p.page, p.sql, strings.Join(p.lines, "\n  "))
+}
+
+// limitedByQueryCost checks whether the combination is allowed to read the whole table, because none of its filters
+// is served by an index according to the rules of the query cost and the query cost limits its page size.
+// Without a maximum query cost nothing limits these combinations, so they have to use an index like all others.
+func (p *queryPlan) limitedByQueryCost(idx *Indexer) bool {
+	if idx.maxQueryCost == 0 {
+		return false
+	}
+
+	for _, filter := range p.filters {
+		if _, indexed := queryCostScanFactors[filter]; indexed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// queryPlanFilter is a filter option together with the name used in the query cost.
+type queryPlanFilter[T any] struct {
+	name   string
+	option T
+}
+
+// queryPlanPage describes one of the pages of the paginated queries.
+type queryPlanPage struct {
+	name     string
+	cursor   *string
+	backward bool
+}
+
+// queryPlanCombinations returns every combination of the filters that are served by an index,
+// once alone and once together with all filters that are not served by an index.
+// The other filters are applied to the rows read from the index, so they do not change which index can be used,
+// and a combination of only those filters is limited by the query cost instead.
+func queryPlanCombinations[T any](filters []queryPlanFilter[T]) [][]queryPlanFilter[T] {
+	var indexed, unindexed []queryPlanFilter[T]
+	for _, filter := range filters {
+		if _, exists := queryCostScanFactors[filter.name]; exists {
+			indexed = append(indexed, filter)
+		} else {
+			unindexed = append(unindexed, filter)
+		}
+	}
+
+	var combinations [][]queryPlanFilter[T]
+	for set := 0; set < 1<<len(indexed); set++ {
+		var combination []queryPlanFilter[T]
+		for i := range indexed {
+			if set&(1<<i) != 0 {
+				combination = append(combination, indexed[i])
+			}
+		}
+
+		combinations = append(combinations, combination)
+		combinations = append(combinations, append(append([]queryPlanFilter[T]{}, combination...), unindexed...))
+	}
+
+	return combinations
+}
+
+func queryPlanFilterNames[T any](filters []queryPlanFilter[T]) []string {
+	names := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		names = append(names, filter.name)
+	}
+
+	return names
+}
+
+func queryPlanFilterOptions[T any](filters []queryPlanFilter[T]) []T {
+	options := make([]T, 0, len(filters))
+	for _, filter := range filters {
+		options = append(options, filter.option)
+	}
+
+	return options
+}
+
+// queryPlans explains the paginated queries of every supported filter combination on every output table.
+// The queries are not executed. Every combination is checked on the first, a following and a previous page.
+func queryPlans(ctx context.Context, idx *Indexer, ledger *testLedger) ([]*queryPlan, error) {
+	address := ledger.addresses[0]
+	aliasAddress := ledger.aliases[0]
+	tag := ledger.tags[0]
+	// the ranges only match a small part of the outputs, like the ranges of real queries
+	early := unixTime(testFirstTimestamp + queryPlanBasicOutputs/testOutputsPerMilestone/20)
+	late := unixTime(testFirstTimestamp + queryPlanBasicOutputs/testOutputsPerMilestone*19/20)
+	cursor := outputCursor(unixTime(testFirstTimestamp+queryPlanBasicOutputs/testOutputsPerMilestone/2), make(outputIDBytes, iotago.OutputIDLength))
+
+	pages := []queryPlanPage{
+		{name: "first"},
+		{name: "next", cursor: &cursor},
+		{name: "previous", cursor: &cursor, backward: true},
+	}
+
+	var plans []*queryPlan
+	explain := func(table string, filters []string, conditions func(query *gorm.DB) (*gorm.DB, error)) error {
+		for _, page := range pages {
+			query, err := conditions(idx.db.Model(queryPlanTables[table]))
+			if err != nil {
+				return err
+			}
+
+			query, err = paginatedOutputIDQuery(query, queryPlanPageSize, page.cursor, page.backward)
+			if err != nil {
+				return err
+			}
+
+			plan, err := explainQuery(ctx, idx, query)
+			if err != nil {
+				return errors.Wrapf(err, "explaining query on %s with filters %v failed", table, filters)
+			}
+			plan.table = table
+			plan.filters = filters
+			plan.page = page.name
+			plan.fullScans = fullScans(idx.engine, plan.lines, containsCreatedFilter(filters))
+			plans = append(plans, plan)
+		}
+
+		return nil
+	}
+
+	basicOutputFilters := []queryPlanFilter[BasicOutputFilterOption]{
+		{"hasNativeTokens", BasicOutputHasNativeTokens(true)},
+		{"minNativeTokenCount", BasicOutputMinNativeTokenCount(1)},
+		{"maxNativeTokenCount", BasicOutputMaxNativeTokenCount(1)},
+		{"address", BasicOutputUnlockableByAddress(address)},
+		{"notAddress", BasicOutputNotUnlockableByAddress(address)},
+		{"hasStorageDepositReturn", BasicOutputHasStorageDepositReturnCondition(true)},
+		{"storageDepositReturnAddress", BasicOutputStorageDepositReturnAddress(address)},
+		{"hasExpiration", BasicOutputHasExpirationCondition(true)},
+		{"expirationReturnAddress", BasicOutputExpirationReturnAddress(address)},
+		{"expiresBefore", BasicOutputExpiresBefore(early)},
+		{"expiresAfter", BasicOutputExpiresAfter(late)},
+		{"hasTimelock", BasicOutputHasTimelockCondition(true)},
+		{"timelockedBefore", BasicOutputTimelockedBefore(early)},
+		{"timelockedAfter", BasicOutputTimelockedAfter(late)},
+		{"sender", BasicOutputSender(address)},
+		{"notSender", BasicOutputNotSender(address)},
+		{"tag", BasicOutputTag(tag)},
+		{"notTag", BasicOutputNotTag(tag)},
+		{"tagPrefix", BasicOutputTagPrefix(tag)},
+		{"tagContains", BasicOutputTagContains(string(tag))},
+		{"createdBefore", BasicOutputCreatedBefore(early)},
+		{"createdAfter", BasicOutputCreatedAfter(late)},
+	}
+	for _, filters := range queryPlanCombinations(basicOutputFilters) {
+		opts := basicOutputFilterOptions(queryPlanFilterOptions(filters))
+		if err := explain("basic_outputs", queryPlanFilterNames(filters), func(query *gorm.DB) (*gorm.DB, error) {
+			return basicOutputConditions(query, opts)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	nftFilters := []queryPlanFilter[NFTFilterOption]{
+		{"hasNativeTokens", NFTHasNativeTokens(true)},
+		{"minNativeTokenCount", NFTMinNativeTokenCount(1)},
+		{"maxNativeTokenCount", NFTMaxNativeTokenCount(1)},
+		{"address", NFTUnlockableByAddress(address)},
+		{"notAddress", NFTNotUnlockableByAddress(address)},
+		{"hasStorageDepositReturn", NFTHasStorageDepositReturnCondition(true)},
+		{"storageDepositReturnAddress", NFTStorageDepositReturnAddress(address)},
+		{"hasExpiration", NFTHasExpirationCondition(true)},
+		{"expirationReturnAddress", NFTExpirationReturnAddress(address)},
+		{"expiresBefore", NFTExpiresBefore(early)},
+		{"expiresAfter", NFTExpiresAfter(late)},
+		{"hasTimelock", NFTHasTimelockCondition(true)},
+		{"timelockedBefore", NFTTimelockedBefore(early)},
+		{"timelockedAfter", NFTTimelockedAfter(late)},
+		{"issuer", NFTIssuer(address)},
+		{"notIssuer", NFTNotIssuer(address)},
+		{"sender", NFTSender(address)},
+		{"notSender", NFTNotSender(address)},
+		{"tag", NFTTag(tag)},
+		{"notTag", NFTNotTag(tag)},
+		{"tagPrefix", NFTTagPrefix(tag)},
+		{"tagContains", NFTTagContains(string(tag))},
+		{"createdBefore", NFTCreatedBefore(early)},
+		{"createdAfter", NFTCreatedAfter(late)},
+	}
+	for _, filters := range queryPlanCombinations(nftFilters) {
+		opts := nftFilterOptions(queryPlanFilterOptions(filters))
+		if err := explain("nfts", queryPlanFilterNames(filters), func(query *gorm.DB) (*gorm.DB, error) {
+			return nftConditions(query, opts)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	aliasFilters := []queryPlanFilter[AliasFilterOption]{
+		{"hasNativeTokens", AliasHasNativeTokens(true)},
+		{"minNativeTokenCount", AliasMinNativeTokenCount(1)},
+		{"maxNativeTokenCount", AliasMaxNativeTokenCount(1)},
+		{"stateController", AliasStateController(address)},
+		{"governor", AliasGovernor(address)},
+		{"issuer", AliasIssuer(address)},
+		{"notIssuer", AliasNotIssuer(address)},
+		{"sender", AliasSender(address)},
+		{"notSender", AliasNotSender(address)},
+		{"createdBefore", AliasCreatedBefore(early)},
+		{"createdAfter", AliasCreatedAfter(late)},
+	}
+	for _, filters := range queryPlanCombinations(aliasFilters) {
+		opts := aliasFilterOptions(queryPlanFilterOptions(filters))
+		if err := explain("aliases", queryPlanFilterNames(filters), func(query *gorm.DB) (*gorm.DB, error) {
+			return aliasConditions(query, opts)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	foundryFilters := []queryPlanFilter[FoundryFilterOption]{
+		{"hasNativeTokens", FoundryHasNativeTokens(true)},
+		{"minNativeTokenCount", FoundryMinNativeTokenCount(1)},
+		{"maxNativeTokenCount", FoundryMaxNativeTokenCount(1)},
+		{"aliasAddress", FoundryWithAliasAddress(aliasAddress)},
+		{"createdBefore", FoundryCreatedBefore(early)},
+		{"createdAfter", FoundryCreatedAfter(late)},
+	}
+	for _, filters := range queryPlanCombinations(foundryFilters) {
+		opts := foundryFilterOptions(queryPlanFilterOptions(filters))
+		if err := explain("foundries", queryPlanFilterNames(filters), func(query *gorm.DB) (*gorm.DB, error) {
+			return foundryConditions(query, opts)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// the storage deposit returns of an address are read from the basic and NFT outputs at once
+	addr, err := addressBytesForAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		query, err := storageDepositReturnsQuery(idx.db, addr, queryPlanPageSize, page.cursor, page.backward)
+		if err != nil {
+			return nil, err
+		}
+
+		plan, err := explainQuery(ctx, idx, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "explaining storage deposit returns query failed")
+		}
+		plan.table = "basic_outputs, nfts"
+		plan.filters = []string{"storageDepositReturnAddress"}
+		plan.page = page.name
+		plan.fullScans = fullScans(idx.engine, plan.lines, false)
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+func containsCreatedFilter(filters []string) bool {
+	for _, filter := range filters {
+		if filter == "createdBefore" || filter == "createdAfter" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// explainQuery asks the database for the plan of the query, joined with the ledger index like the executed queries.
+func explainQuery(ctx context.Context, idx *Indexer, query *gorm.DB) (*queryPlan, error) {
+	plan := &queryPlan{}
+
+	if err := idx.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// build the statement without executing it, so it can be prefixed with EXPLAIN
+		stmt := withLedgerIndex(tx, query).Session(&gorm.Session{DryRun: true}).Find(&queryResults{}).Statement
+		plan.sql = stmt.SQL.String()
+
+		var explainSQL string
+		//nolint:exhaustive // we have a default case.
+		switch idx.engine {
+		case database.EngineSQLite:
+			explainSQL = "EXPLAIN QUERY PLAN " + plan.sql
+		case database.EnginePostgreSQL:
+			explainSQL = "EXPLAIN " + plan.sql
+		default:
+			return errors.Errorf("unsupported db engine for query plans: %s", idx.engine)
+		}
+
+		rows, err := tx.Statement.ConnPool.QueryContext(ctx, explainSQL, stmt.Vars...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		lines, err := queryPlanLines(rows)
+		if err != nil {
+			return err
+		}
+		plan.lines = lines
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// queryPlanLines returns the last column of every row, which holds the description of the plan step on all engines.
+func queryPlanLines(rows *sql.Rows) ([]string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		var line string
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		values[len(values)-1] = &line
+
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// constrainsFilterColumn checks whether the conditions of an index search constrain a filtered column.
+// The cursor of the pages only constrains (created_at, output_id), which is part of every index,
+// so a search with only the cursor reads the whole table in the order of the index.
+// created_at itself is only a filtered column if the createdBefore or createdAfter filters are set.
+func constrainsFilterColumn(conditions []string, createdFiltered bool) bool {
+	for _, condition := range conditions {
+		condition = strings.Trim(condition, "() ")
+		switch {
+		case strings.Contains(condition, "created_at") && strings.Contains(condition, "output_id"):
+			// the row value comparison of the cursor
+			continue
+		case strings.HasPrefix(condition, "created_at") && !createdFiltered:
+			continue
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// fullScans returns the steps of the plan that read a whole output table.
+// These are scans without an index, but also scans of an index whose leading column is not constrained by a filter,
+// since they only walk the table in the order of the index.
+func fullScans(engine database.Engine, lines []string, createdFiltered bool) []string {
+	var scans []string
+
+	//nolint:exhaustive // other engines are not supported.
+	switch engine {
+	case database.EngineSQLite:
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+
+			table := fields[1]
+			if table == "TABLE" && len(fields) > 2 {
+				table = fields[2]
+			}
+			if _, isOutputTable := queryPlanTables[table]; !isOutputTable {
+				// e.g. the scans of the subqueries, which are named after their alias
+				continue
+			}
+
+			switch fields[0] {
+			case "SCAN":
+				// e.g. "SCAN basic_outputs" or "SCAN basic_outputs USING INDEX basic_outputs_created_at"
+				scans = append(scans, line)
+			case "SEARCH":
+				// e.g. "SEARCH basic_outputs USING INDEX basic_outputs_address (address=? AND (created_at,output_id)>(?,?))"
+				start := strings.Index(line, " (")
+				if start == -1 || !constrainsFilterColumn(strings.Split(line[start+2:len(line)-1], " AND "), createdFiltered) {
+					scans = append(scans, line)
+				}
+			}
+		}
+
+	case database.EnginePostgreSQL:
+		for i, line := range lines {
+			step := strings.TrimPrefix(strings.TrimSpace(line), "->  ")
+
+			switch {
+			case strings.HasPrefix(step, "Seq Scan on "), strings.HasPrefix(step, "Parallel Seq Scan on "):
+				// e.g. "Seq Scan on basic_outputs  (cost=...)"
+				table := strings.Fields(step[strings.Index(step, " on ")+4:])[0]
+				if _, isOutputTable := queryPlanTables[table]; isOutputTable {
+					scans = append(scans, line)
+				}
+
+			case strings.Contains(step, "Index Scan"), strings.Contains(step, "Index Only Scan"):
+				// e.g. "Index Scan using basic_outputs_address on basic_outputs  (cost=...)", "Index Scan Backward using ..."
+				// or "Bitmap Index Scan on basic_outputs_address  (cost=...)",
+				// followed by the details of the step, like "Index Cond: (...)", until the next step starts
+				var conditions []string
+				for _, detail := range lines[i+1:] {
+					detail = strings.TrimSpace(detail)
+					if strings.HasPrefix(detail, "->") {
+						break
+					}
+					if strings.HasPrefix(detail, "Index Cond: ") {
+						conditions = strings.Split(strings.TrimPrefix(detail, "Index Cond: "), " AND ")
+					}
+				}
+
+				if !constrainsFilterColumn(conditions, createdFiltered) {
+					scans = append(scans, line)
+				}
+			}
+		}
+	}
+
+	return scans
+}
+
+// TestQueryPlans checks that every filter combination that is served by an index according to the query cost
+// uses an index on one of its filtered columns, instead of reading the whole table.
+// The combinations without such a filter are only accepted because the query cost limits their page size.
+// The test therefore requires the default maximum query cost, the node has to run with a maximum query cost as well.
+func TestQueryPlans(t *testing.T) {
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			idx := newTestIndexer(t, dbParams)
+			idx.maxQueryCost = DefaultMaxQueryCost
+			ledger := generateTestLedger(t, idx, queryPlanBasicOutputs, 42)
+
+			ts := time.Now()
+			plans, err := queryPlans(context.Background(), idx, ledger)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var limitedScans int
+			for _, plan := range plans {
+				if len(plan.fullScans) == 0 {
+					continue
+				}
+
+				if plan.limitedByQueryCost(idx) {
+					limitedScans++
+
+					continue
+				}
+
+				t.Errorf("full scan (%s):\n%s", strings.Join(plan.fullScans, ", "), plan)
+			}
+
+			t.Logf("explained %d queries in %s, %d full scans of combinations that are limited by the maximum query cost of %d",
+				len(plans), time.Since(ts).Truncate(time.Millisecond), limitedScans, idx.maxQueryCost)
+		})
+	}
+}
+
+func TestFullScans(t *testing.T) {
+	tests := []struct {
+		name            string
+		engine          database.Engine
+		lines           []string
+		createdFiltered bool
+		fullScans       int
+	}{
+		{
+			name:   "sqlite search on a filtered column",
+			engine: database.EngineSQLite,
+			lines: []string{
+				"MATERIALIZE results",
+				"SEARCH basic_outputs USING COVERING INDEX basic_outputs_address (address=? AND (created_at,output_id)>(?,?))",
+				"SCAN results",
+				"SCAN statuses",
+			},
+		},
+		{
+			name:      "sqlite table scan",
+			engine:    database.EngineSQLite,
+			lines:     []string{"MATERIALIZE results", "SCAN basic_outputs", "SCAN statuses"},
+			fullScans: 1,
+		},
+		{
+			name:      "sqlite scan in the order of an index",
+			engine:    database.EngineSQLite,
+			lines:     []string{"MATERIALIZE results", "SCAN basic_outputs USING INDEX basic_outputs_created_at", "SCAN statuses"},
+			fullScans: 1,
+		},
+		{
+			name:      "sqlite search with only the cursor",
+			engine:    database.EngineSQLite,
+			lines:     []string{"SEARCH nfts USING INDEX nfts_created_at ((created_at,output_id)>(?,?))"},
+			fullScans: 1,
+		},
+		{
+			name:            "sqlite search on the creation time",
+			engine:          database.EngineSQLite,
+			lines:           []string{"SEARCH nfts USING INDEX nfts_created_at (created_at>?)"},
+			createdFiltered: true,
+		},
+		{
+			name:   "postgres index scan with condition",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"Nested Loop  (cost=0.29..10.50 rows=101 width=49)",
+				"  ->  Limit  (cost=0.29..8.31 rows=1 width=41)",
+				"        ->  Index Only Scan using basic_outputs_address on basic_outputs  (cost=0.29..8.31 rows=1 width=41)",
+				"              Index Cond: ((address = '\\x00'::bytea) AND (ROW(created_at, output_id) > ROW('2023-03-28 00:00:00+00'::timestamp with time zone, '\\x00'::bytea)))",
+				"  ->  Seq Scan on statuses  (cost=0.00..1.01 rows=1 width=4)",
+			},
+		},
+		{
+			name:   "postgres sequential scan",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"Nested Loop  (cost=0.00..500.00 rows=101 width=49)",
+				"  ->  Seq Scan on basic_outputs  (cost=0.00..450.00 rows=101 width=41)",
+				"        Filter: (native_token_count > 0)",
+				"  ->  Seq Scan on statuses  (cost=0.00..1.01 rows=1 width=4)",
+			},
+			fullScans: 1,
+		},
+		{
+			name:   "postgres index scan without condition",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"Limit  (cost=0.29..80.31 rows=101 width=41)",
+				"  ->  Index Scan using basic_outputs_created_at on basic_outputs  (cost=0.29..800.31 rows=1000 width=41)",
+				"        Filter: (native_token_count > 0)",
+				"  ->  Index Scan using nfts_address on nfts  (cost=0.29..8.31 rows=1 width=41)",
+				"        Index Cond: (address = '\\x00'::bytea)",
+			},
+			fullScans: 1,
+		},
+		{
+			name:   "postgres index scan with only the cursor",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"  ->  Index Scan Backward using basic_outputs_created_at on basic_outputs  (cost=0.29..800.31 rows=1000 width=41)",
+				"        Index Cond: (ROW(created_at, output_id) < ROW('2023-03-28 00:00:00+00'::timestamp with time zone, '\\x00'::bytea))",
+				"        Filter: (native_token_count > 0)",
+			},
+			fullScans: 1,
+		},
+		{
+			name:   "postgres bitmap index scan",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"  ->  Bitmap Heap Scan on basic_outputs  (cost=4.30..40.00 rows=10 width=41)",
+				"        Recheck Cond: (tag = '\\x746167'::bytea)",
+				"        ->  Bitmap Index Scan on basic_outputs_tag  (cost=0.00..4.30 rows=10 width=0)",
+				"              Index Cond: (tag = '\\x746167'::bytea)",
+			},
+		},
+		{
+			name:   "postgres range of the creation time without its filter",
+			engine: database.EnginePostgreSQL,
+			lines: []string{
+				"  ->  Index Scan using basic_outputs_created_at on basic_outputs  (cost=0.29..800.31 rows=1000 width=41)",
+				"        Index Cond: (created_at > '2023-03-28 00:00:00+00'::timestamp with time zone)",
+			},
+			fullScans: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if scans := fullScans(test.engine, test.lines, test.createdFiltered); len(scans) != test.fullScans {
+				t.Errorf("expected %d full scans, got %d: %v", test.fullScans, len(scans), scans)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
+)
+
+// LedgerIndexRecorder records the ledger indexes the reads of a context were answered at.
+// A read replica can answer with an older ledger index than the primary, so callers that cache results
+// by the ledger index of the primary have to check the ledger indexes of the reads.
+type LedgerIndexRecorder struct {
+	mutex         sync.Mutex
+	ledgerIndexes map[uint32]struct{}
+}
+
+type ledgerIndexRecorderKey struct{}
+
+// WithLedgerIndexRecorder returns a context that records the ledger indexes of the reads that use it.
+func WithLedgerIndexRecorder(ctx context.Context) (context.Context, *LedgerIndexRecorder) {
+	recorder := &LedgerIndexRecorder{ledgerIndexes: make(map[uint32]struct{})}
+
+	return context.WithValue(ctx, ledgerIndexRecorderKey{}, recorder), recorder
+}
+
+// ReadAt reports whether all recorded reads were answered at the given ledger index.
+func (r *LedgerIndexRecorder) ReadAt(ledgerIndex uint32) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for recorded := range r.ledgerIndexes {
+		if recorded != ledgerIndex {
+			return false
+		}
+	}
+
+	return true
+}
+
+func recordLedgerIndex(ctx context.Context, ledgerIndex uint32) {
+	recorder, ok := ctx.Value(ledgerIndexRecorderKey{}).(*LedgerIndexRecorder)
+	if !ok {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.ledgerIndexes[ledgerIndex] = struct{}{}
+}
+
+// nextReadReplica returns the read replica that should be used for the next query.
+// Returns nil if no read replicas are configured.
+func (i *Indexer) nextReadReplica() *gorm.DB {
+	if len(i.readReplicas) == 0 {
+		return nil
+	}
+
+	return i.readReplicas[int(i.readReplicaCounter.Add(1))%len(i.readReplicas)]
+}
+
+// readReplicaLagging checks if a result with the given ledger index is too far behind the primary.
+func (i *Indexer) readReplicaLagging(ledgerIndex uint32) bool {
+	return i.ledgerIndex.Load() > ledgerIndex+i.maxReadReplicaLag
+}
+
+// readTransactionWithFallback runs the read function in a read-only transaction on a read replica if configured.
+// The read function returns the ledger index the data was read at.
+// The primary is used instead if the replica failed or lags behind, so the read function might be called twice.
+func (i *Indexer) readTransactionWithFallback(ctx context.Context, read func(tx *gorm.DB) (uint32, error)) (uint32, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "readTransaction")
+	defer span.End()
+
+	read = i.withStatementTimeout(read)
+
+	if replica := i.nextReadReplica(); replica != nil {
+		span.SetAttributes(attribute.Bool("indexer.read_replica", true))
+
+		ledgerIndex, err := readTransaction(ctx, replica, read)
+		if err == nil && !i.readReplicaLagging(ledgerIndex) {
+			recordLedgerIndex(ctx, ledgerIndex)
+
+			return ledgerIndex, nil
+		}
+
+		if ctx.Err() != nil {
+			// the query was canceled, there is no need to ask the primary.
+			// The read of a lagging replica might have succeeded before, so the error of the context is returned.
+			span.RecordError(ctx.Err())
+			span.SetStatus(codes.Error, ctx.Err().Error())
+
+			return 0, ctx.Err()
+		}
+
+		if err != nil {
+			i.LogDebugf("Querying read replica failed, falling back to primary: %s", err)
+			span.AddEvent("fallback to primary", trace.WithAttributes(attribute.String("indexer.fallback_reason", err.Error())))
+		} else {
+			i.LogDebugf("Read replica is lagging behind (%d vs %d), falling back to primary", ledgerIndex, i.ledgerIndex.Load())
+			span.AddEvent("fallback to primary", trace.WithAttributes(attribute.String("indexer.fallback_reason", "read replica is lagging behind")))
+		}
+	}
+
+	ledgerIndex, err := readTransaction(ctx, i.db, read)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return 0, err
+	}
+	recordLedgerIndex(ctx, ledgerIndex)
+
+	return ledgerIndex, nil
+}
+
+// withStatementTimeout limits the duration of every statement of the read function to the statement timeout.
+// The timeout is set for the transaction only, so the other connections of the pool, which are also used by the writer,
+// the import of the ledger and the index builds, are not limited.
+func (i *Indexer) withStatementTimeout(read func(tx *gorm.DB) (uint32, error)) func(tx *gorm.DB) (uint32, error) {
+	if i.statementTimeout <= 0 {
+		return read
+	}
+
+	return func(tx *gorm.DB) (uint32, error) {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", i.statementTimeout.Milliseconds())).Error; err != nil {
+			return 0, err
+		}
+
+		return read(tx)
+	}
+}
+
+// readTransaction runs the read function in a read-only snapshot of the database,
+// so the returned ledger index is never ahead of the data that was read.
+func readTransaction(ctx context.Context, db *gorm.DB, read func(tx *gorm.DB) (uint32, error)) (uint32, error) {
+	var ledgerIndex uint32
+
+	if err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		ledgerIndex, err = read(tx)
+
+		return err
+	}, readTxOptions); err != nil {
+		return 0, err
+	}
+
+	return ledgerIndex, nil
+}
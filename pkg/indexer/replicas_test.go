@@ -0,0 +1,250 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestLedgerIndexRecorder(t *testing.T) {
+	const replicaLedgerIndex = testLedgerIndex - 5
+
+	idx := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	generateTestLedger(t, idx, 100, 4)
+	if _, err := idx.Status(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a read replica that lags behind the primary
+	replica := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	if err := replica.ImportTransaction(context.Background()).Finalize(replicaLedgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	idx.readReplicas = []*gorm.DB{replica.db}
+
+	tests := []struct {
+		name        string
+		maxLag      uint32
+		ledgerIndex uint32
+	}{
+		{
+			name:        "answered by the replica",
+			maxLag:      10,
+			ledgerIndex: replicaLedgerIndex,
+		},
+		{
+			name:        "replica lags too far behind",
+			maxLag:      2,
+			ledgerIndex: testLedgerIndex,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			idx.maxReadReplicaLag = test.maxLag
+
+			ctx, recorder := WithLedgerIndexRecorder(context.Background())
+			if !recorder.ReadAt(testLedgerIndex) {
+				t.Error("expected a recorder without reads to match every ledger index")
+			}
+
+			result := idx.BasicOutputsWithFilters(ctx, BasicOutputPageSize(10))
+			if result.Error != nil {
+				t.Fatal(result.Error)
+			}
+			if result.LedgerIndex != test.ledgerIndex {
+				t.Fatalf("expected the result at ledger index %d, got %d", test.ledgerIndex, result.LedgerIndex)
+			}
+
+			if !recorder.ReadAt(test.ledgerIndex) {
+				t.Errorf("expected the read to be recorded at ledger index %d", test.ledgerIndex)
+			}
+			for _, other := range []uint32{replicaLedgerIndex, testLedgerIndex} {
+				if other != test.ledgerIndex && recorder.ReadAt(other) {
+					t.Errorf("expected the read not to match ledger index %d", other)
+				}
+			}
+		})
+	}
+}
+
+func TestReadReplicaFallback(t *testing.T) {
+	const replicaLedgerIndex = testLedgerIndex - 5
+
+	idx := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	generateTestLedger(t, idx, 10, 6)
+	if _, err := idx.Status(); err != nil {
+		t.Fatal(err)
+	}
+
+	newReplica := func(t *testing.T) *gorm.DB {
+		t.Helper()
+
+		replica := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+		if err := replica.ImportTransaction(context.Background()).Finalize(replicaLedgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		return replica.db
+	}
+
+	failingReplica := newReplica(t)
+	if err := closeDatabase(failingReplica); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		replicas []*gorm.DB
+		maxLag   uint32
+		// ledgerIndexes are the ledger indexes of the consecutive reads
+		ledgerIndexes []uint32
+	}{
+		{
+			name:          "no read replicas",
+			maxLag:        10,
+			ledgerIndexes: []uint32{testLedgerIndex, testLedgerIndex},
+		},
+		{
+			name:          "answered by the replica within the lag",
+			replicas:      []*gorm.DB{newReplica(t)},
+			maxLag:        5,
+			ledgerIndexes: []uint32{replicaLedgerIndex, replicaLedgerIndex},
+		},
+		{
+			name:          "replica lags too far behind",
+			replicas:      []*gorm.DB{newReplica(t)},
+			maxLag:        4,
+			ledgerIndexes: []uint32{testLedgerIndex, testLedgerIndex},
+		},
+		{
+			name:          "replica fails",
+			replicas:      []*gorm.DB{failingReplica},
+			maxLag:        10,
+			ledgerIndexes: []uint32{testLedgerIndex, testLedgerIndex},
+		},
+		{
+			name:          "queries are distributed between the replicas",
+			replicas:      []*gorm.DB{failingReplica, newReplica(t)},
+			maxLag:        10,
+			ledgerIndexes: []uint32{replicaLedgerIndex, testLedgerIndex, replicaLedgerIndex, testLedgerIndex},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			idx.readReplicas = test.replicas
+			idx.maxReadReplicaLag = test.maxLag
+			idx.readReplicaCounter.Store(0)
+
+			for i, expected := range test.ledgerIndexes {
+				result := idx.BasicOutputsWithFilters(context.Background(), BasicOutputPageSize(10))
+				if result.Error != nil {
+					t.Fatalf("read %d failed: %s", i, result.Error)
+				}
+				if result.LedgerIndex != expected {
+					t.Errorf("expected read %d at ledger index %d, got %d", i, expected, result.LedgerIndex)
+				}
+			}
+		})
+	}
+	idx.readReplicas = nil
+}
+
+// cancelAfterReadContext is canceled once the read function returned, so the read itself succeeds.
+// It has no done channel, so the database driver does not abort the transaction.
+type cancelAfterReadContext struct {
+	context.Context
+	canceled bool
+}
+
+func (c *cancelAfterReadContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c *cancelAfterReadContext) Err() error {
+	if c.canceled {
+		return context.Canceled
+	}
+
+	return nil
+}
+
+func TestReadTransactionCanceledWithLaggingReplica(t *testing.T) {
+	idx := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	generateTestLedger(t, idx, 10, 5)
+	if _, err := idx.Status(); err != nil {
+		t.Fatal(err)
+	}
+
+	replica := newTestIndexer(t, testEngines(t)[database.EngineSQLite])
+	if err := replica.ImportTransaction(context.Background()).Finalize(testLedgerIndex-5, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	idx.readReplicas = []*gorm.DB{replica.db}
+	idx.maxReadReplicaLag = 2
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		// context returns the context of the read and a function that is called by the read function
+		context func() (context.Context, func())
+		// reads is the expected amount of calls of the read function
+		reads int
+	}{
+		{
+			// the read of the lagging replica succeeds, the context is canceled before the primary is asked
+			name: "canceled after the read of the replica",
+			context: func() (context.Context, func()) {
+				ctx := &cancelAfterReadContext{Context: context.Background()}
+
+				return ctx, func() { ctx.canceled = true }
+			},
+			reads: 1,
+		},
+		{
+			// the transaction of the replica fails, so the read function is not called at all
+			name: "canceled before the read",
+			context: func() (context.Context, func()) {
+				return canceled, func() {}
+			},
+			reads: 0,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("expected no panic, got %v", r)
+				}
+			}()
+
+			ctx, onRead := test.context()
+			reads := 0
+			ledgerIndex, err := idx.readTransactionWithFallback(ctx, func(tx *gorm.DB) (uint32, error) {
+				reads++
+				onRead()
+
+				return ledgerIndexFromDatabase(tx), nil
+			})
+			//nolint:errorlint // the error of the context is returned as is
+			if !errors.Is(err, context.Canceled) || err != ctx.Err() {
+				t.Fatalf("expected the error of the context %v, got ledger index %d and error %v", ctx.Err(), ledgerIndex, err)
+			}
+			if reads != test.reads {
+				t.Errorf("expected %d reads, the primary must not be asked after the cancellation, got %d", test.reads, reads)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// ScheduledCondition is an unlock condition that takes effect at a certain time.
+type ScheduledCondition byte
+
+const (
+	// ScheduledConditionExpiration is the expiration unlock condition, the output returns to the expiration return address.
+	ScheduledConditionExpiration ScheduledCondition = iota
+	// ScheduledConditionTimelock is the timelock unlock condition, the output can be unlocked by its owner.
+	ScheduledConditionTimelock
+)
+
+// column returns the column that holds the time of the condition.
+func (c ScheduledCondition) column() string {
+	if c == ScheduledConditionTimelock {
+		return "timelock_time"
+	}
+
+	return "expiration_time"
+}
+
+// ScheduledOutput is an unspent output with an unlock condition that takes effect at a certain time.
+type ScheduledOutput struct {
+	// OutputID is the ID of the output.
+	OutputID iotago.OutputID
+	// OutputType is the type of the output, either a basic or an NFT output.
+	OutputType iotago.OutputType
+	// Owner is the address that owns the output.
+	Owner iotago.Address
+	// ReturnAddress is the expiration return address, if the output has an expiration unlock condition.
+	ReturnAddress iotago.Address
+	// Time is the time at which the condition takes effect.
+	Time time.Time
+}
+
+// scheduledOutputResult is a row of the scheduled output query.
+type scheduledOutputResult struct {
+	OutputID                outputIDBytes
+	OutputType              iotago.OutputType
+	Address                 addressBytes
+	ExpirationReturnAddress addressBytes
+	ScheduledTime           time.Time
+}
+
+// ScheduledOutputs returns the unspent basic and NFT outputs whose condition takes effect after the given time
+// and not later than the given until time, ordered by that time.
+func (i *Indexer) ScheduledOutputs(ctx context.Context, condition ScheduledCondition, after time.Time, until time.Time) ([]*ScheduledOutput, error) {
+	var results []scheduledOutputResult
+	for _, table := range unlockConditionTables {
+		var tableResults []scheduledOutputResult
+		if err := i.db.WithContext(ctx).Model(table.model).
+			Select("output_id", fmt.Sprintf("%d as output_type", table.outputType), "address", "expiration_return_address", condition.column()+" as scheduled_time").
+			Where(condition.column()+" > ? AND "+condition.column()+" <= ?", after, until).
+			Find(&tableResults).Error; err != nil {
+			return nil, err
+		}
+		results = append(results, tableResults...)
+	}
+
+	sort.Slice(results, func(a int, b int) bool {
+		if !results[a].ScheduledTime.Equal(results[b].ScheduledTime) {
+			return results[a].ScheduledTime.Before(results[b].ScheduledTime)
+		}
+
+		return bytes.Compare(results[a].OutputID, results[b].OutputID) < 0
+	})
+
+	outputs := make([]*ScheduledOutput, 0, len(results))
+	for _, result := range results {
+		owner, err := addressFromBytes(result.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid address of output %s", result.OutputID.ID().ToHex())
+		}
+
+		var returnAddress iotago.Address
+		if len(result.ExpirationReturnAddress) > 0 {
+			returnAddress, err = addressFromBytes(result.ExpirationReturnAddress)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid expiration return address of output %s", result.OutputID.ID().ToHex())
+			}
+		}
+
+		outputs = append(outputs, &ScheduledOutput{
+			OutputID:      result.OutputID.ID(),
+			OutputType:    result.OutputType,
+			Owner:         owner,
+			ReturnAddress: returnAddress,
+			Time:          result.ScheduledTime,
+		})
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,155 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// StorageDepositReturn is an unspent output that has to return a storage deposit to the return address.
+type StorageDepositReturn struct {
+	// OutputID is the ID of the output.
+	OutputID iotago.OutputID
+	// OutputType is the type of the output, either a basic or an NFT output.
+	OutputType iotago.OutputType
+	// Owner is the address that owns the output and has to return the storage deposit when spending it.
+	Owner iotago.Address
+	// Amount is the amount of base tokens that have to be returned.
+	Amount uint64
+	// ExpirationTime is the time at which the output returns to the expiration return address, if it has an expiration.
+	ExpirationTime *time.Time
+}
+
+// StorageDepositReturnsResult is a page of the storage deposit returns of an address.
+type StorageDepositReturnsResult struct {
+	StorageDepositReturns []*StorageDepositReturn
+	LedgerIndex           uint32
+	PageSize              uint32
+	Cursor                *string
+	PrevCursor            *string
+	Error                 error
+}
+
+// storageDepositReturnResult is a row of the storage deposit return query.
+type storageDepositReturnResult struct {
+	OutputID             outputIDBytes
+	OutputType           iotago.OutputType
+	Address              addressBytes
+	StorageDepositReturn uint64
+	ExpirationTime       *time.Time
+	CreatedAt            time.Time
+	LedgerIndex          uint32
+}
+
+func (r storageDepositReturnResult) cursor() string {
+	return outputCursor(r.CreatedAt, r.OutputID)
+}
+
+// storageDepositReturnsQuery returns a page of the outputs of all tables that return a storage deposit to the address.
+// Every table is paginated on its own index before the results are combined, so only the outputs
+// of a single page are read from each table.
+func storageDepositReturnsQuery(db *gorm.DB, addr addressBytes, pageSize uint32, cursor *string, backward bool) (*gorm.DB, error) {
+	unions := make([]interface{}, 0, len(unlockConditionTables))
+	sql := ""
+	for _, table := range unlockConditionTables {
+		query := db.Model(table.model).
+			Select("output_id", fmt.Sprintf("%d as output_type", table.outputType), "address", "storage_deposit_return", "expiration_time", "created_at").
+			Where("storage_deposit_return_address = ?", addr[:])
+
+		query, err := paginatedQuery(query, pageSize, cursor, backward)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(unions) > 0 {
+			sql += " UNION ALL "
+		}
+		// the ordered and limited queries have to be wrapped in a sub-query to be combined
+		sql += fmt.Sprintf("SELECT * FROM (?) as outputs_%d", len(unions))
+		unions = append(unions, query)
+	}
+
+	return paginatedQuery(db.Table("("+sql+") as outputs", unions...), pageSize, nil, backward)
+}
+
+// StorageDepositReturns returns the unspent basic and NFT outputs that have to return a storage deposit to the given address,
+// ordered by their creation time.
+func (i *Indexer) StorageDepositReturns(ctx context.Context, address iotago.Address, pageSize uint32, cursor *string, backward bool) *StorageDepositReturnsResult {
+	errorResult := func(err error) *StorageDepositReturnsResult {
+		return &StorageDepositReturnsResult{
+			Error: err,
+		}
+	}
+
+	addr, err := addressBytesForAddress(address)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	query, err := storageDepositReturnsQuery(i.db, addr, pageSize, cursor, backward)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	if i.maxQueryDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.maxQueryDuration)
+		defer cancel()
+	}
+
+	ts := time.Now()
+	var results []storageDepositReturnResult
+	ledgerIndex, err := i.readTransactionWithFallback(ctx, func(tx *gorm.DB) (uint32, error) {
+		results = nil
+		if err := withLedgerIndex(tx, query).Find(&results).Error; err != nil {
+			return 0, err
+		}
+
+		if len(results) > 0 {
+			return results[0].LedgerIndex, nil
+		}
+
+		return ledgerIndexFromDatabase(tx), nil
+	})
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			i.Events.QueryTimedOut.Trigger(time.Since(ts))
+
+			return errorResult(ErrQueryTimeout)
+		}
+
+		return errorResult(err)
+	}
+
+	results, nextCursor, prevCursor := paginate(results, pageSize, cursor, backward)
+
+	storageDepositReturns := make([]*StorageDepositReturn, 0, len(results))
+	for _, result := range results {
+		owner, err := addressFromBytes(result.Address)
+		if err != nil {
+			return errorResult(errors.Wrapf(err, "invalid address of output %s", result.OutputID.ID().ToHex()))
+		}
+
+		storageDepositReturns = append(storageDepositReturns, &StorageDepositReturn{
+			OutputID:       result.OutputID.ID(),
+			OutputType:     result.OutputType,
+			Owner:          owner,
+			Amount:         result.StorageDepositReturn,
+			ExpirationTime: result.ExpirationTime,
+		})
+	}
+
+	return &StorageDepositReturnsResult{
+		StorageDepositReturns: storageDepositReturns,
+		LedgerIndex:           ledgerIndex,
+		PageSize:              pageSize,
+		Cursor:                nextCursor,
+		PrevCursor:            prevCursor,
+		Error:                 nil,
+	}
+}
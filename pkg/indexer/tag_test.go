@@ -0,0 +1,187 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestPrefixUpperBound(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     []byte
+		upperBound []byte
+	}{
+		{
+			name:   "empty",
+			prefix: []byte{},
+		},
+		{
+			name:       "single byte",
+			prefix:     []byte{0x61},
+			upperBound: []byte{0x62},
+		},
+		{
+			name:       "zero byte",
+			prefix:     []byte{0x00},
+			upperBound: []byte{0x01},
+		},
+		{
+			name:       "last byte below 0xff",
+			prefix:     []byte{0x61, 0xfe},
+			upperBound: []byte{0x61, 0xff},
+		},
+		{
+			name:       "0xff suffix",
+			prefix:     []byte{0x61, 0xff},
+			upperBound: []byte{0x62},
+		},
+		{
+			name:       "0xff suffixes",
+			prefix:     []byte{0x61, 0x62, 0xff, 0xff},
+			upperBound: []byte{0x61, 0x63},
+		},
+		{
+			name:       "0xff before the last byte",
+			prefix:     []byte{0xff, 0x61},
+			upperBound: []byte{0xff, 0x62},
+		},
+		{
+			name:   "only 0xff",
+			prefix: []byte{0xff},
+		},
+		{
+			name:   "only 0xff bytes",
+			prefix: []byte{0xff, 0xff, 0xff},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			prefix := append([]byte{}, test.prefix...)
+
+			upperBound := prefixUpperBound(prefix)
+			if !reflect.DeepEqual(upperBound, test.upperBound) {
+				t.Errorf("expected upper bound %x, got %x", test.upperBound, upperBound)
+			}
+			if !bytes.Equal(prefix, test.prefix) {
+				t.Errorf("expected the prefix to be unchanged, got %x", prefix)
+			}
+		})
+	}
+}
+
+type tagFilterTest struct {
+	name     string
+	filters  []BasicOutputFilterOption
+	expected map[iotago.OutputID]struct{}
+}
+
+// TestTagPrefixAndSubstring compares the tag filters with matching the tags in Go.
+// The tags contain 0xff bytes and share prefixes, so the ranges of the prefix filters are checked at their edges.
+func TestTagPrefixAndSubstring(t *testing.T) {
+	tags := [][]byte{
+		[]byte("a"),
+		[]byte("ab"),
+		[]byte("ab\xfe"),
+		[]byte("ab\xff"),
+		[]byte("ab\xff\x00"),
+		[]byte("ab\xff\xff"),
+		[]byte("ac"),
+		[]byte("b"),
+		[]byte("\x00"),
+		[]byte("\xfe\xff"),
+		[]byte("\xff"),
+		[]byte("\xff\x00"),
+		[]byte("\xff\xff"),
+		[]byte("\xff\xff\xff"),
+	}
+
+	for engine, dbParams := range testEngines(t) {
+		dbParams := dbParams
+		t.Run(string(engine), func(t *testing.T) {
+			idx := newTestIndexer(t, dbParams)
+
+			outputTags := make(map[iotago.OutputID][]byte)
+			importer := idx.ImportTransaction(context.Background())
+			for i, tag := range append(tags, nil) {
+				outputID := iotago.OutputID{byte(i + 1)}
+				output := &iotago.BasicOutput{
+					Amount:     1_000_000,
+					Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: &iotago.Ed25519Address{}}},
+				}
+				if tag != nil {
+					output.Features = iotago.Features{&iotago.TagFeature{Tag: tag}}
+				}
+				if err := importer.AddOutput(outputID, output, testFirstTimestamp); err != nil {
+					t.Fatal(err)
+				}
+				outputTags[outputID] = tag
+			}
+			if err := importer.Finalize(testLedgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := func(match func(tag []byte) bool) map[iotago.OutputID]struct{} {
+				outputIDs := make(map[iotago.OutputID]struct{})
+				for outputID, tag := range outputTags {
+					if tag != nil && match(tag) {
+						outputIDs[outputID] = struct{}{}
+					}
+				}
+
+				return outputIDs
+			}
+
+			var tests []tagFilterTest
+
+			for _, prefix := range [][]byte{[]byte("a"), []byte("ab"), []byte("ab\xff"), []byte("ab\xff\xff"), []byte("\xfe"), []byte("\xff"), []byte("\xff\xff")} {
+				prefix := prefix
+				tests = append(tests, tagFilterTest{
+					name:     "prefix " + iotago.EncodeHex(prefix),
+					filters:  []BasicOutputFilterOption{BasicOutputTagPrefix(prefix)},
+					expected: expected(func(tag []byte) bool { return bytes.HasPrefix(tag, prefix) }),
+				})
+			}
+
+			for _, substring := range []string{"b", "\xff", "b\xff", "\x00"} {
+				substring := substring
+				tests = append(tests, tagFilterTest{
+					name:     "substring " + iotago.EncodeHex([]byte(substring)),
+					filters:  []BasicOutputFilterOption{BasicOutputTagContains(substring)},
+					expected: expected(func(tag []byte) bool { return bytes.Contains(tag, []byte(substring)) }),
+				})
+			}
+
+			tests = append(tests, tagFilterTest{
+				name:    "prefixes",
+				filters: []BasicOutputFilterOption{BasicOutputTagPrefix([]byte("ac")), BasicOutputTagPrefix([]byte("\xff\xff"))},
+				expected: expected(func(tag []byte) bool {
+					return bytes.HasPrefix(tag, []byte("ac")) || bytes.HasPrefix(tag, []byte("\xff\xff"))
+				}),
+			})
+
+			for _, test := range tests {
+				test := test
+				t.Run(test.name, func(t *testing.T) {
+					result := idx.BasicOutputsWithFilters(context.Background(), append(test.filters, BasicOutputPageSize(100))...)
+					if result.Error != nil {
+						t.Fatal(result.Error)
+					}
+
+					outputIDs := make(map[iotago.OutputID]struct{})
+					for _, outputID := range result.OutputIDs {
+						outputIDs[outputID] = struct{}{}
+					}
+					if !reflect.DeepEqual(outputIDs, test.expected) {
+						t.Errorf("expected %d outputs, got %d", len(test.expected), len(outputIDs))
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package indexer
+
+import (
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
+)
+
+// EnableTracing creates a span for every statement on the primary database and the read replicas.
+func (i *Indexer) EnableTracing() error {
+	if err := i.db.Use(tracing.NewGormPlugin()); err != nil {
+		return err
+	}
+
+	for _, replica := range i.readReplicas {
+		if err := replica.Use(tracing.NewGormPlugin()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package indexer
 
 import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -8,7 +13,6 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/iotaledger/hive.go/serializer/v2"
-	"github.com/iotaledger/inx-indexer/pkg/database"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -18,6 +22,12 @@ const (
 
 var (
 	NullOutputID = iotago.OutputID{}
+
+	// readTxOptions are used for the transactions of the filter queries.
+	readTxOptions = &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	}
 )
 
 type outputIDBytes []byte
@@ -26,6 +36,15 @@ type nftIDBytes []byte
 type aliasIDBytes []byte
 type foundryIDBytes []byte
 
+// unlockConditionTables are the tables of the outputs that can have storage deposit return, timelock and expiration unlock conditions.
+var unlockConditionTables = []struct {
+	model      interface{}
+	outputType iotago.OutputType
+}{
+	{&basicOutput{}, iotago.OutputBasic},
+	{&nft{}, iotago.OutputNFT},
+}
+
 type Status struct {
 	ID              uint `gorm:"primaryKey;notnull"`
 	LedgerIndex     uint32
@@ -36,10 +55,39 @@ type Status struct {
 
 type queryResult struct {
 	OutputID    outputIDBytes
-	Cursor      string
+	CreatedAt   time.Time
 	LedgerIndex uint32
 }
 
+// cursor returns the position of the result in the order of the paginated queries.
+func (r queryResult) cursor() string {
+	return outputCursor(r.CreatedAt, r.OutputID)
+}
+
+// outputCursor returns the cursor of an output with the given creation time.
+func outputCursor(createdAt time.Time, outputID outputIDBytes) string {
+	return fmt.Sprintf("%08x%s", uint32(createdAt.Unix()), hex.EncodeToString(outputID))
+}
+
+// parseCursor returns the creation time and the output ID the cursor points to.
+func parseCursor(cursor string) (time.Time, outputIDBytes, error) {
+	if len(cursor) != CursorLength {
+		return time.Time{}, nil, errors.Errorf("Invalid cursor length: %d", len(cursor))
+	}
+
+	createdAt, err := strconv.ParseUint(cursor[:8], 16, 32)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrapf(err, "invalid cursor: %s", cursor)
+	}
+
+	outputID, err := hex.DecodeString(cursor[8:])
+	if err != nil {
+		return time.Time{}, nil, errors.Wrapf(err, "invalid cursor: %s", cursor)
+	}
+
+	return unixTime(uint32(createdAt)), outputID, nil
+}
+
 func (o outputIDBytes) ID() iotago.OutputID {
 	id := iotago.OutputID{}
 	copy(id[:], o)
@@ -58,16 +106,186 @@ func (q queryResults) IDs() iotago.OutputIDs {
 	return outputIDs
 }
 
+type outputIDWithID struct {
+	OutputID outputIDBytes
+	ID       []byte
+}
+
+// idsForOutputIDs returns the value of the ID column of the given model for each of the given outputIDs.
+// Outputs that are not found in the table, e.g. because they were consumed in the meantime, are not part of the result.
+func (i *Indexer) idsForOutputIDs(ctx context.Context, model interface{}, idColumn string, outputIDs iotago.OutputIDs) (map[iotago.OutputID][]byte, error) {
+	result := make(map[iotago.OutputID][]byte, len(outputIDs))
+	if len(outputIDs) == 0 {
+		return result, nil
+	}
+
+	outputIDsBytes := make([][]byte, 0, len(outputIDs))
+	for _, outputID := range outputIDs {
+		outputIDBytes := make([]byte, iotago.OutputIDLength)
+		copy(outputIDBytes, outputID[:])
+		outputIDsBytes = append(outputIDsBytes, outputIDBytes)
+	}
+
+	var rows []outputIDWithID
+	if err := i.db.WithContext(ctx).Model(model).
+		Select("output_id", idColumn+" as id").
+		Where("output_id IN ?", outputIDsBytes).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.OutputID.ID()] = row.ID
+	}
+
+	return result, nil
+}
+
 func addressBytesForAddress(addr iotago.Address) (addressBytes, error) {
 	return addr.Serialize(serializer.DeSeriModeNoValidation, nil)
 }
 
+// addressFromBytes deserializes an address that was stored in the database.
+func addressFromBytes(addr addressBytes) (iotago.Address, error) {
+	if len(addr) == 0 {
+		return nil, errors.New("empty address")
+	}
+
+	address, err := iotago.AddressSelector(uint32(addr[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := address.Deserialize(addr, serializer.DeSeriModeNoValidation, nil); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func addressBytesForAddresses(addrs []iotago.Address) ([][]byte, error) {
+	result := make([][]byte, 0, len(addrs))
+	for _, addr := range addrs {
+		addrBytes, err := addressBytesForAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, addrBytes[:])
+	}
+
+	return result, nil
+}
+
+// filterByValues only keeps rows where the column matches one of the included values and none of the excluded values.
+// Rows without a value in the column are kept by the exclusion, since they do not match any of the excluded values.
+func filterByValues(query *gorm.DB, column string, included [][]byte, excluded [][]byte) *gorm.DB {
+	if len(included) > 0 {
+		query = query.Where(column+" IN ?", included)
+	}
+
+	if len(excluded) > 0 {
+		query = query.Where("("+column+" IS NULL OR "+column+" NOT IN ?)", excluded)
+	}
+
+	return query
+}
+
+func filterByAddresses(query *gorm.DB, column string, included []iotago.Address, excluded []iotago.Address) (*gorm.DB, error) {
+	includedBytes, err := addressBytesForAddresses(included)
+	if err != nil {
+		return nil, err
+	}
+
+	excludedBytes, err := addressBytesForAddresses(excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterByValues(query, column, includedBytes, excludedBytes), nil
+}
+
+// prefixUpperBound returns the smallest value that is bigger than all values starting with the given prefix,
+// or nil if there is no such value because the prefix only consists of 0xff bytes.
+func prefixUpperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			upperBound := make([]byte, i+1)
+			copy(upperBound, prefix[:i+1])
+			upperBound[i]++
+
+			return upperBound
+		}
+	}
+
+	return nil
+}
+
+// filterByPrefixes matches all values of the column that start with one of the given prefixes.
+// The prefixes are translated into range queries, so an index on the column can be used on all engines.
+func filterByPrefixes(query *gorm.DB, column string, prefixes [][]byte) *gorm.DB {
+	if len(prefixes) == 0 {
+		return query
+	}
+
+	conditions := make([]string, 0, len(prefixes))
+	args := make([]interface{}, 0, 2*len(prefixes))
+	for _, prefix := range prefixes {
+		upperBound := prefixUpperBound(prefix)
+		if upperBound == nil {
+			conditions = append(conditions, "("+column+" >= ?)")
+			args = append(args, prefix)
+
+			continue
+		}
+
+		conditions = append(conditions, "("+column+" >= ? AND "+column+" < ?)")
+		args = append(args, prefix, upperBound)
+	}
+
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// filterBySubstrings matches all values of the column that contain one of the given byte sequences.
+func filterBySubstrings(query *gorm.DB, column string, substrings [][]byte) *gorm.DB {
+	if len(substrings) == 0 {
+		return query
+	}
+
+	// instr and position both compare the raw bytes if the arguments are blobs or bytea
+	condition := "instr(" + column + ", ?) > 0"
+	if query.Dialector.Name() == "postgres" {
+		condition = "position(? in " + column + ") > 0"
+	}
+
+	conditions := make([]string, 0, len(substrings))
+	args := make([]interface{}, 0, len(substrings))
+	for _, substring := range substrings {
+		conditions = append(conditions, condition)
+		args = append(args, substring)
+	}
+
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// filterByUnlockConditionTime matches all values of the time column of an optional unlock condition in the range of the condition.
+// Outputs without the unlock condition are NULL and never match, which are most of them. SQLite does not know that
+// from its statistics and assumes that a range matches a quarter of the rows, so it would rather walk the whole table
+// in the order of the pages. The likelihood tells its planner that the range is selective.
+func filterByUnlockConditionTime(query *gorm.DB, condition string, value time.Time) *gorm.DB {
+	if query.Dialector.Name() == "sqlite" {
+		condition = "likelihood(" + condition + ", 0.01)"
+	}
+
+	return query.Where(condition, value)
+}
+
 //nolint:revive // better be explicit here
 type IndexerResult struct {
 	OutputIDs   iotago.OutputIDs
 	LedgerIndex uint32
 	PageSize    uint32
 	Cursor      *string
+	PrevCursor  *string
 	Error       error
 }
 
@@ -81,75 +299,169 @@ func unixTime(fromValue uint32) time.Time {
 	return time.Unix(int64(fromValue), 0)
 }
 
-func (i *Indexer) combineOutputIDFilteredQuery(query *gorm.DB, pageSize uint32, cursor *string) *IndexerResult {
+// paginatedQuery orders the query in the order of the cursors and limits the query to a single page.
+// One more result than the page size is queried to know whether there is another page.
+func paginatedQuery(query *gorm.DB, pageSize uint32, cursor *string, backward bool) (*gorm.DB, error) {
+	order := "created_at asc, output_id asc"
+	cursorCondition := ">="
+	if backward {
+		// walk the same key in reverse order, the results are reversed again after the query
+		order = "created_at desc, output_id desc"
+		cursorCondition = "<"
+	}
 
-	query = query.Select("output_id").Order("created_at asc, output_id asc")
+	query = query.Order(order)
 	if pageSize > 0 {
-		var cursorQuery string
-		//nolint:exhaustive // we have a default case.
-		switch i.engine {
-		case database.EngineSQLite:
-			cursorQuery = "printf('%08X', strftime('%s', `created_at`)) || hex(output_id) as cursor"
-		case database.EnginePostgreSQL:
-			cursorQuery = "lpad(to_hex(extract(epoch from created_at)::integer), 8, '0') || encode(output_id, 'hex') as cursor"
-		default:
-			i.LogErrorfAndExit("Unsupported db engine pagination queries: %s", i.engine)
-		}
-
-		query = query.Select("output_id", cursorQuery).Limit(int(pageSize + 1))
+		query = query.Limit(int(pageSize + 1))
 
 		if cursor != nil {
-			if len(*cursor) != CursorLength {
-				return errorResult(errors.Errorf("Invalid cursor length: %d", len(*cursor)))
-			}
-			//nolint:exhaustive // we have a default case.
-			switch i.engine {
-			case database.EngineSQLite:
-				query = query.Where("cursor >= ?", strings.ToUpper(*cursor))
-			case database.EnginePostgreSQL:
-				query = query.Where("lpad(to_hex(extract(epoch from created_at)::integer), 8, '0') || encode(output_id, 'hex') >= ?", *cursor)
-			default:
-				i.LogErrorfAndExit("Unsupported db engine pagination queries: %s", i.engine)
+			createdAt, outputID, err := parseCursor(strings.ToLower(*cursor))
+			if err != nil {
+				return nil, err
 			}
+
+			// the row value comparison can be served by the indexes that end in (created_at, output_id),
+			// so the position of the page does not matter
+			query = query.Where("(created_at, output_id) "+cursorCondition+" (?, ?)", createdAt, outputID)
 		}
 	}
 
-	// This combines the query with a second query that checks for the current ledger_index.
-	// This way we do not need to lock anything and we know the index matches the results.
-	//TODO: measure performance for big datasets
-	ledgerIndexQuery := i.db.Model(&Status{}).Select("ledger_index")
-	joinedQuery := i.db.Table("(?) as results, (?) as status", query, ledgerIndexQuery)
+	return query, nil
+}
 
-	var results queryResults
+// paginatedOutputIDQuery selects the output IDs of the query in the order of the cursors and limits the query to a single page.
+func paginatedOutputIDQuery(query *gorm.DB, pageSize uint32, cursor *string, backward bool) (*gorm.DB, error) {
+	return paginatedQuery(query.Select("output_id", "created_at"), pageSize, cursor, backward)
+}
 
-	result := joinedQuery.Find(&results)
-	if err := result.Error; err != nil {
-		return errorResult(err)
+// paginatedResult is a result of a paginated query.
+type paginatedResult interface {
+	cursor() string
+}
+
+// paginate cuts the results of a paginated query down to the page size and puts them in ascending order.
+// It returns the page together with the cursors of the next and the previous page.
+func paginate[T paginatedResult](results []T, pageSize uint32, cursor *string, backward bool) ([]T, *string, *string) {
+	if pageSize == 0 {
+		return results, nil, nil
 	}
 
-	var ledgerIndex uint32
-	if len(results) > 0 {
-		ledgerIndex = results[0].LedgerIndex
+	var nextCursor *string
+	var prevCursor *string
+
+	if backward {
+		if uint32(len(results)) > pageSize {
+			// there are more results before this page, the earliest result on this page is the start of the previous one
+			results = results[:pageSize]
+			c := results[len(results)-1].cursor()
+			prevCursor = &c
+		}
+		for l, r := 0, len(results)-1; l < r; l, r = l+1, r-1 {
+			results[l], results[r] = results[r], results[l]
+		}
+
+		// the next page starts where the given cursor pointed to
+		c := strings.ToLower(*cursor)
+		nextCursor = &c
 	} else {
-		// Since we got no results for the query, return the current ledger index
-		if status, err := i.Status(); err == nil {
-			ledgerIndex = status.LedgerIndex
+		if uint32(len(results)) > pageSize {
+			lastResult := results[len(results)-1]
+			results = results[:len(results)-1]
+			c := lastResult.cursor()
+			nextCursor = &c
+		}
+
+		if cursor != nil {
+			// we are not on the first page, so the previous page ends right before this one
+			c := strings.ToLower(*cursor)
+			if len(results) > 0 {
+				c = results[0].cursor()
+			}
+			prevCursor = &c
 		}
 	}
 
-	var nextCursor *string
-	if pageSize > 0 && uint32(len(results)) > pageSize {
-		lastResult := results[len(results)-1]
-		results = results[:len(results)-1]
-		c := strings.ToLower(lastResult.Cursor)
-		nextCursor = &c
+	return results, nextCursor, prevCursor
+}
+
+func (i *Indexer) combineOutputIDFilteredQuery(ctx context.Context, query *gorm.DB, pageSize uint32, cursor *string, backward bool) *IndexerResult {
+	query, err := paginatedOutputIDQuery(query, pageSize, cursor, backward)
+	if err != nil {
+		return errorResult(err)
 	}
 
+	if i.maxQueryDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.maxQueryDuration)
+		defer cancel()
+	}
+
+	ts := time.Now()
+	results, ledgerIndex, err := i.outputIDFilteredQueryResultWithFallback(ctx, query)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			i.Events.QueryTimedOut.Trigger(time.Since(ts))
+
+			return errorResult(ErrQueryTimeout)
+		}
+
+		return errorResult(err)
+	}
+
+	results, nextCursor, prevCursor := paginate(results, pageSize, cursor, backward)
+
 	return &IndexerResult{
 		OutputIDs:   results.IDs(),
 		LedgerIndex: ledgerIndex,
 		PageSize:    pageSize,
 		Cursor:      nextCursor,
+		PrevCursor:  prevCursor,
 		Error:       nil,
 	}
 }
+
+// outputIDFilteredQueryResultWithFallback runs the query on a read replica if configured.
+// The primary is queried instead if the replica failed or lags behind.
+func (i *Indexer) outputIDFilteredQueryResultWithFallback(ctx context.Context, query *gorm.DB) (queryResults, uint32, error) {
+	var results queryResults
+
+	ledgerIndex, err := i.readTransactionWithFallback(ctx, func(tx *gorm.DB) (uint32, error) {
+		var err error
+		results, err = outputIDFilteredQueryResult(tx, query)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(results) > 0 {
+			return results[0].LedgerIndex, nil
+		}
+
+		// Since we got no results for the query, return the current ledger index
+		return ledgerIndexFromDatabase(tx), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, ledgerIndex, nil
+}
+
+// withLedgerIndex joins the query with the current ledger index of the status table.
+func withLedgerIndex(db *gorm.DB, query *gorm.DB) *gorm.DB {
+	ledgerIndexQuery := db.Model(&Status{}).Select("ledger_index")
+
+	return db.Table("(?) as results, (?) as status", query, ledgerIndexQuery)
+}
+
+// outputIDFilteredQueryResult runs the query together with a second query that checks for the current ledger_index.
+// This way we do not need to lock anything and we know the index matches the results.
+func outputIDFilteredQueryResult(tx *gorm.DB, query *gorm.DB) (queryResults, error) {
+	var results queryResults
+
+	//TODO: measure performance for big datasets
+	if err := withLedgerIndex(tx, query).Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,34 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// EventPublisher triggers an event for every message, so other components of the same process can consume the outbox.
+// Closures that are added with Hook run synchronously, so a message only counts as published once they returned.
+type EventPublisher struct {
+	// Published is triggered for every published message.
+	Published *event.Event[*indexer.OutboxMessage]
+}
+
+// NewEventPublisher creates an EventPublisher.
+func NewEventPublisher() *EventPublisher {
+	return &EventPublisher{
+		Published: event.New[*indexer.OutboxMessage](),
+	}
+}
+
+// Publish triggers the Published event.
+func (p *EventPublisher) Publish(_ context.Context, message *indexer.OutboxMessage) error {
+	p.Published.Trigger(message)
+
+	return nil
+}
+
+// Close does nothing, the event stays usable.
+func (p *EventPublisher) Close() error {
+	return nil
+}
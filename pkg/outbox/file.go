@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// FilePublisher appends the payload of every message as a line to a file (newline delimited JSON).
+// It is a reference implementation of a Publisher, e.g. to be tailed by a log shipper.
+type FilePublisher struct {
+	fileLock sync.Mutex
+	file     *os.File
+}
+
+// NewFilePublisher opens the file at the given path for appending, it is created if it does not exist.
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	//nolint:gosec // the path is taken from the config file
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{file: file}, nil
+}
+
+// Publish appends the payload of the message to the file and syncs it to the disk.
+func (p *FilePublisher) Publish(_ context.Context, message *indexer.OutboxMessage) error {
+	p.fileLock.Lock()
+	defer p.fileLock.Unlock()
+
+	line := make([]byte, 0, len(message.Payload)+1)
+	line = append(line, message.Payload...)
+	line = append(line, '\n')
+
+	if _, err := p.file.Write(line); err != nil {
+		return err
+	}
+
+	return p.file.Sync()
+}
+
+// Close closes the file.
+func (p *FilePublisher) Close() error {
+	p.fileLock.Lock()
+	defer p.fileLock.Unlock()
+
+	return p.file.Close()
+}
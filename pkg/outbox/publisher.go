@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// Publisher publishes the messages of the outbox to a message broker.
+type Publisher interface {
+	// Publish publishes the message of a milestone.
+	// It must only return nil once the broker persisted the message, otherwise the message is published again.
+	// Messages are published in the order of the milestones, but a message may be published more than once,
+	// so consumers have to use the milestone index of the message to detect duplicates.
+	Publish(ctx context.Context, message *indexer.OutboxMessage) error
+	// Close releases the resources of the publisher.
+	Close() error
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// Relay publishes the messages of the outbox of the indexer in the order of the milestones.
+// A message is only removed from the outbox after it was published, so every milestone is delivered at least once.
+type Relay struct {
+	*logger.WrappedLogger
+
+	indexer   *indexer.Indexer
+	publisher Publisher
+	// wakeup signals the relay that a new message was written to the outbox.
+	wakeup chan struct{}
+
+	// pollInterval is the interval in which the outbox is checked if the relay was not woken up.
+	pollInterval time.Duration
+	// retryInterval is the time until a failed message is published again.
+	retryInterval time.Duration
+	// batchSize is the maximum amount of messages read from the outbox at once.
+	batchSize int
+}
+
+// WithPollInterval sets the interval in which the outbox is checked if the relay was not woken up.
+func WithPollInterval(interval time.Duration) options.Option[Relay] {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+// WithRetryInterval sets the time until a failed message is published again.
+func WithRetryInterval(interval time.Duration) options.Option[Relay] {
+	return func(r *Relay) {
+		r.retryInterval = interval
+	}
+}
+
+// WithBatchSize sets the maximum amount of messages read from the outbox at once.
+func WithBatchSize(batchSize int) options.Option[Relay] {
+	return func(r *Relay) {
+		r.batchSize = batchSize
+	}
+}
+
+// NewRelay creates a Relay that publishes the outbox of the given indexer with the publisher.
+func NewRelay(idx *indexer.Indexer, publisher Publisher, log *logger.Logger, opts ...options.Option[Relay]) *Relay {
+	return options.Apply(&Relay{
+		WrappedLogger: logger.NewWrappedLogger(log),
+		indexer:       idx,
+		publisher:     publisher,
+		wakeup:        make(chan struct{}, 1),
+		pollInterval:  time.Second,
+		retryInterval: 5 * time.Second,
+		batchSize:     100,
+	}, opts)
+}
+
+// Wakeup signals the relay that a new message was written to the outbox.
+func (r *Relay) Wakeup() {
+	select {
+	case r.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes the messages of the outbox until the context is canceled.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		wait := r.pollInterval
+		if err := r.publishPending(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.LogWarnf("Publishing outbox failed, retrying in %s: %s", r.retryInterval, err.Error())
+			wait = r.retryInterval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-r.wakeup:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// publishPending publishes the messages of the outbox until it is empty.
+func (r *Relay) publishPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		messages, err := r.indexer.OutboxMessages(r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for _, message := range messages {
+			if err := r.publisher.Publish(ctx, message); err != nil {
+				return err
+			}
+
+			// a crash before the acknowledgement publishes the message again after the restart
+			if err := r.indexer.AcknowledgeOutbox(message.MilestoneIndex); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,294 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// MilestoneTimestampFunc reads the timestamp of the milestone with the given index.
+type MilestoneTimestampFunc func(ctx context.Context, index uint32) (time.Time, error)
+
+// milestone is a milestone that was enqueued for the worker of the Scheduler.
+type milestone struct {
+	index uint32
+	// timestamp is the timestamp of the milestone, it is read with the MilestoneTimestampFunc if zero.
+	timestamp time.Time
+}
+
+// Event is an unlock condition of an output that takes effect at the time of a milestone.
+type Event struct {
+	// MilestoneIndex is the index of the milestone that reached the time of the condition.
+	MilestoneIndex uint32
+	// MilestoneTimestamp is the timestamp of the milestone.
+	MilestoneTimestamp time.Time
+	// Output is the output with the unlock condition.
+	Output *indexer.ScheduledOutput
+}
+
+// Events are the events issued by the Scheduler.
+type Events struct {
+	// ExpiringSoon is triggered if the expiration of an output is within the expiring soon window of the milestone timestamp.
+	ExpiringSoon *event.Event[*Event]
+	// Expired is triggered if a milestone reached the expiration time of an output,
+	// the output can now be unlocked by the expiration return address.
+	Expired *event.Event[*Event]
+	// TimelockReleased is triggered if a milestone reached the timelock time of an output,
+	// the output can now be unlocked by its owner.
+	TimelockReleased *event.Event[*Event]
+}
+
+// MilestoneEvents are the events of the conditions that took effect with a milestone.
+type MilestoneEvents struct {
+	MilestoneIndex     uint32
+	MilestoneTimestamp time.Time
+	ExpiringSoon       []*Event
+	Expired            []*Event
+	TimelockReleased   []*Event
+}
+
+// EventWriter writes rows that belong to the events of a milestone in the transaction that stores the progress of the Scheduler.
+// If it returns an error, the milestone is not applied and its conditions are checked again with the next milestone.
+type EventWriter func(tx *gorm.DB, events *MilestoneEvents) error
+
+func newEvents() *Events {
+	return &Events{
+		ExpiringSoon:     event.New[*Event](),
+		Expired:          event.New[*Event](),
+		TimelockReleased: event.New[*Event](),
+	}
+}
+
+// Scheduler issues events for the timelocks and expirations of the unspent outputs once a milestone reaches their time.
+// The milestone timestamps are used as the clock, so the events match the time the node uses to validate the conditions.
+type Scheduler struct {
+	*logger.WrappedLogger
+
+	// Events are the events issued by the Scheduler.
+	Events *Events
+
+	indexer *indexer.Indexer
+	store   *store
+	// milestoneTimestamp reads the timestamps of the milestones that are not known when they are enqueued.
+	milestoneTimestamp MilestoneTimestampFunc
+
+	// expiringSoonWindow is the duration before an expiration at which the ExpiringSoon event is triggered.
+	expiringSoonWindow time.Duration
+	// lastTimestamp is the timestamp of the last applied milestone.
+	lastTimestamp time.Time
+	// eventWriters are called in the transaction that stores the progress of every applied milestone.
+	eventWriters []EventWriter
+
+	// pendingMutex protects the enqueued milestones.
+	pendingMutex sync.Mutex
+	// pending is the latest enqueued milestone that was not applied yet.
+	// Older ones are replaced, their conditions are covered by the time range of the later milestone.
+	pending *milestone
+	// startIndex is the index of the milestone before the first enqueued milestone, 0 if none was enqueued yet.
+	startIndex uint32
+	// wakeup signals the worker that a milestone was enqueued.
+	wakeup chan struct{}
+}
+
+// WithExpiringSoonWindow sets the duration before an expiration at which the ExpiringSoon event is triggered (0 = disabled).
+func WithExpiringSoonWindow(window time.Duration) options.Option[Scheduler] {
+	return func(s *Scheduler) {
+		s.expiringSoonWindow = window
+	}
+}
+
+// New creates a Scheduler that reads the scheduled outputs from the given indexer.
+// It continues after the last milestone it applied before, which is stored in the database of the indexer.
+func New(idx *indexer.Indexer, milestoneTimestamp MilestoneTimestampFunc, log *logger.Logger, opts ...options.Option[Scheduler]) (*Scheduler, error) {
+	s, err := newStore(idx.Database())
+	if err != nil {
+		return nil, err
+	}
+
+	lastTimestamp, err := s.lastTimestamp()
+	if err != nil {
+		return nil, err
+	}
+
+	return options.Apply(&Scheduler{
+		WrappedLogger:      logger.NewWrappedLogger(log),
+		Events:             newEvents(),
+		indexer:            idx,
+		store:              s,
+		milestoneTimestamp: milestoneTimestamp,
+		lastTimestamp:      lastTimestamp,
+		wakeup:             make(chan struct{}, 1),
+	}, opts), nil
+}
+
+// AddEventWriter adds a writer that is called for every applied milestone, so the rows it writes are committed
+// together with the progress of the Scheduler and no events are lost or repeated if the application stops.
+// It has to be added before the worker is started.
+func (s *Scheduler) AddEventWriter(writer EventWriter) {
+	s.eventWriters = append(s.eventWriters, writer)
+}
+
+// Enqueue hands a milestone to the worker of the Scheduler, without waiting for its events.
+// It has to be called after the changes of the milestone were committed. The timestamp is read with the
+// MilestoneTimestampFunc if it is zero. If the worker is behind, only the latest milestone is applied,
+// so the events of the skipped milestones are issued with the latest one.
+func (s *Scheduler) Enqueue(index uint32, timestamp time.Time) {
+	s.pendingMutex.Lock()
+	if s.startIndex == 0 && index > 0 {
+		// start with the conditions that took effect after the milestone the indexer was at before
+		s.startIndex = index - 1
+	}
+	s.pending = &milestone{index: index, timestamp: timestamp}
+	s.pendingMutex.Unlock()
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Run applies the enqueued milestones until the context is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wakeup:
+			s.applyPending(ctx)
+		}
+	}
+}
+
+// applyPending applies the latest enqueued milestone.
+func (s *Scheduler) applyPending(ctx context.Context) {
+	s.pendingMutex.Lock()
+	pending, startIndex := s.pending, s.startIndex
+	s.pending = nil
+	s.pendingMutex.Unlock()
+
+	if pending == nil {
+		return
+	}
+
+	if !s.Initialized() && startIndex > 0 {
+		if timestamp, err := s.milestoneTimestamp(ctx, startIndex); err == nil {
+			s.Init(timestamp)
+		} else {
+			s.LogWarnf("Reading timestamp of milestone %d failed, starting with milestone %d: %s", startIndex, pending.index, err)
+		}
+	}
+
+	timestamp := pending.timestamp
+	if timestamp.IsZero() {
+		var err error
+		if timestamp, err = s.milestoneTimestamp(ctx, pending.index); err != nil {
+			// the conditions are checked with the next milestone
+			s.LogWarnf("Reading timestamp of milestone %d failed: %s", pending.index, err)
+
+			return
+		}
+	}
+
+	if err := s.ApplyMilestone(ctx, pending.index, timestamp); err != nil {
+		s.LogWarnf("Scheduling events of milestone %d failed: %s", pending.index, err)
+	}
+}
+
+// Initialized returns whether the timestamp of a milestone was applied already, by this or a previous run.
+func (s *Scheduler) Initialized() bool {
+	return !s.lastTimestamp.IsZero()
+}
+
+// Init sets the timestamp of the milestone the indexer is at, without triggering events.
+// The events of the following milestones are triggered for the conditions that take effect after that timestamp.
+func (s *Scheduler) Init(timestamp time.Time) {
+	s.lastTimestamp = timestamp
+}
+
+// ApplyMilestone triggers the events of all conditions that took effect since the last applied milestone.
+// It has to be called after the changes of the milestone were committed, so outputs that were
+// spent in the milestone do not trigger events anymore. It is called by the worker for the enqueued milestones.
+// If an error is returned, the conditions are checked again with the next milestone.
+func (s *Scheduler) ApplyMilestone(ctx context.Context, index uint32, timestamp time.Time) error {
+	if !s.Initialized() {
+		if err := s.store.setLastMilestone(s.store.db, index, timestamp); err != nil {
+			return err
+		}
+		s.Init(timestamp)
+
+		return nil
+	}
+
+	if !timestamp.After(s.lastTimestamp) {
+		return nil
+	}
+
+	expired, err := s.indexer.ScheduledOutputs(ctx, indexer.ScheduledConditionExpiration, s.lastTimestamp, timestamp)
+	if err != nil {
+		return err
+	}
+
+	released, err := s.indexer.ScheduledOutputs(ctx, indexer.ScheduledConditionTimelock, s.lastTimestamp, timestamp)
+	if err != nil {
+		return err
+	}
+
+	var expiringSoon []*indexer.ScheduledOutput
+	if s.expiringSoonWindow > 0 {
+		expiringSoon, err = s.indexer.ScheduledOutputs(ctx, indexer.ScheduledConditionExpiration, s.lastTimestamp.Add(s.expiringSoonWindow), timestamp.Add(s.expiringSoonWindow))
+		if err != nil {
+			return err
+		}
+	}
+
+	toEvents := func(outputs []*indexer.ScheduledOutput) []*Event {
+		events := make([]*Event, 0, len(outputs))
+		for _, output := range outputs {
+			events = append(events, &Event{
+				MilestoneIndex:     index,
+				MilestoneTimestamp: timestamp,
+				Output:             output,
+			})
+		}
+
+		return events
+	}
+	events := &MilestoneEvents{
+		MilestoneIndex:     index,
+		MilestoneTimestamp: timestamp,
+		ExpiringSoon:       toEvents(expiringSoon),
+		Expired:            toEvents(expired),
+		TimelockReleased:   toEvents(released),
+	}
+
+	if err := s.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, writer := range s.eventWriters {
+			if err := writer(tx, events); err != nil {
+				return err
+			}
+		}
+
+		return s.store.setLastMilestone(tx, index, timestamp)
+	}); err != nil {
+		return err
+	}
+	s.lastTimestamp = timestamp
+
+	trigger := func(e *event.Event[*Event], issued []*Event) {
+		for _, scheduled := range issued {
+			e.Trigger(scheduled)
+		}
+	}
+	trigger(s.Events.ExpiringSoon, events.ExpiringSoon)
+	trigger(s.Events.Expired, events.Expired)
+	trigger(s.Events.TimelockReleased, events.TimelockReleased)
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	testStartTimestamp = 1_680_000_000
+	expiringSoonWindow = 60 * time.Second
+)
+
+var initTestLogger sync.Once
+
+// testTime returns the time the given amount of seconds after the start of the test.
+func testTime(seconds int) time.Time {
+	return time.Unix(testStartTimestamp+int64(seconds), 0)
+}
+
+// newTestIndexer returns an indexer that contains an output for every condition, keyed by the seconds after the start
+// at which the condition takes effect. The output IDs start with 'e' for expirations or 't' for timelocks, followed by the seconds.
+func newTestIndexer(t *testing.T, expirations []int, timelocks []int) *indexer.Indexer {
+	t.Helper()
+
+	initTestLogger.Do(func() {
+		if err := logger.InitGlobalLogger(configuration.New()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	idx, err := indexer.NewIndexer(database.Params{Engine: database.EngineSQLite, Path: t.TempDir()}, logger.NewLogger("Indexer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = idx.CloseDatabase() })
+
+	if err := idx.CreateTables(); err != nil {
+		t.Fatal(err)
+	}
+
+	owner := &iotago.Ed25519Address{0x1}
+	returnAddress := &iotago.Ed25519Address{0x2}
+
+	importer := idx.ImportTransaction(context.Background())
+	addOutput := func(outputID iotago.OutputID, condition iotago.UnlockCondition) {
+		output := &iotago.BasicOutput{
+			Amount:     1_000_000,
+			Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: owner}, condition},
+		}
+		if err := importer.AddOutput(outputID, output, testStartTimestamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, seconds := range expirations {
+		addOutput(testOutputID('e', seconds), &iotago.ExpirationUnlockCondition{ReturnAddress: returnAddress, UnixTime: uint32(testTime(seconds).Unix())})
+	}
+	for _, seconds := range timelocks {
+		addOutput(testOutputID('t', seconds), &iotago.TimelockUnlockCondition{UnixTime: uint32(testTime(seconds).Unix())})
+	}
+	if err := importer.Finalize(1, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+// newTestScheduler returns a scheduler that reads the scheduled outputs from the given indexer.
+func newTestScheduler(t *testing.T, idx *indexer.Indexer, milestoneTimestamp MilestoneTimestampFunc) *Scheduler {
+	t.Helper()
+
+	s, err := New(idx, milestoneTimestamp, logger.NewLogger("Scheduler"), WithExpiringSoonWindow(expiringSoonWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func testOutputID(condition byte, seconds int) iotago.OutputID {
+	return iotago.OutputID{condition, byte(seconds >> 8), byte(seconds)}
+}
+
+// testEvent is an issued event in a comparable form.
+type testEvent struct {
+	name           string
+	milestoneIndex uint32
+	milestoneTime  int
+	outputID       iotago.OutputID
+}
+
+func (e testEvent) String() string {
+	return fmt.Sprintf("%s of %s at milestone %d (+%ds)", e.name, e.outputID.ToHex(), e.milestoneIndex, e.milestoneTime)
+}
+
+// recordEvents returns a function that returns the events issued by the scheduler since the last call.
+func recordEvents(s *Scheduler) func() []testEvent {
+	var events []testEvent
+
+	record := func(name string) *event.Closure[*Event] {
+		return event.NewClosure(func(e *Event) {
+			events = append(events, testEvent{
+				name:           name,
+				milestoneIndex: e.MilestoneIndex,
+				milestoneTime:  int(e.MilestoneTimestamp.Unix() - testStartTimestamp),
+				outputID:       e.Output.OutputID,
+			})
+		})
+	}
+	s.Events.ExpiringSoon.Hook(record("expiringSoon"))
+	s.Events.Expired.Hook(record("expired"))
+	s.Events.TimelockReleased.Hook(record("timelockReleased"))
+
+	return func() []testEvent {
+		issued := events
+		events = nil
+
+		return issued
+	}
+}
+
+func TestApplyMilestone(t *testing.T) {
+	idx := newTestIndexer(t, []int{100, 300}, []int{50, 300})
+	s := newTestScheduler(t, idx, nil)
+	issued := recordEvents(s)
+
+	tests := []struct {
+		name           string
+		milestoneIndex uint32
+		milestoneTime  int
+		events         []testEvent
+	}{
+		{
+			// the first milestone only initializes the clock
+			name:           "first milestone",
+			milestoneIndex: 1,
+			milestoneTime:  0,
+		},
+		{
+			name:           "nothing reached",
+			milestoneIndex: 2,
+			milestoneTime:  10,
+		},
+		{
+			name:           "expiring soon",
+			milestoneIndex: 3,
+			milestoneTime:  45,
+			events: []testEvent{
+				{name: "expiringSoon", milestoneIndex: 3, milestoneTime: 45, outputID: testOutputID('e', 100)},
+			},
+		},
+		{
+			name:           "timelock released at its time",
+			milestoneIndex: 4,
+			milestoneTime:  50,
+			events: []testEvent{
+				{name: "timelockReleased", milestoneIndex: 4, milestoneTime: 50, outputID: testOutputID('t', 50)},
+			},
+		},
+		{
+			name:           "one second before the expiration",
+			milestoneIndex: 5,
+			milestoneTime:  99,
+		},
+		{
+			name:           "expired at its time",
+			milestoneIndex: 6,
+			milestoneTime:  100,
+			events: []testEvent{
+				{name: "expired", milestoneIndex: 6, milestoneTime: 100, outputID: testOutputID('e', 100)},
+			},
+		},
+		{
+			// the milestones 7 to 9 were skipped, their conditions are issued with the next milestone
+			name:           "skipped milestones",
+			milestoneIndex: 10,
+			milestoneTime:  400,
+			events: []testEvent{
+				{name: "expiringSoon", milestoneIndex: 10, milestoneTime: 400, outputID: testOutputID('e', 300)},
+				{name: "expired", milestoneIndex: 10, milestoneTime: 400, outputID: testOutputID('e', 300)},
+				{name: "timelockReleased", milestoneIndex: 10, milestoneTime: 400, outputID: testOutputID('t', 300)},
+			},
+		},
+		{
+			name:           "same timestamp",
+			milestoneIndex: 11,
+			milestoneTime:  400,
+		},
+	}
+
+	for _, test := range tests {
+		if err := s.ApplyMilestone(context.Background(), test.milestoneIndex, testTime(test.milestoneTime)); err != nil {
+			t.Fatal(err)
+		}
+
+		if events := issued(); !reflect.DeepEqual(events, test.events) {
+			t.Errorf("%s: expected the events %v, got %v", test.name, test.events, events)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	idx := newTestIndexer(t, []int{100}, []int{50})
+
+	timestamps := map[uint32]time.Time{
+		4: testTime(0),
+		8: testTime(120),
+	}
+	var requested []uint32
+	milestoneTimestamp := func(_ context.Context, index uint32) (time.Time, error) {
+		requested = append(requested, index)
+		timestamp, ok := timestamps[index]
+		if !ok {
+			return time.Time{}, errors.Errorf("milestone %d not found", index)
+		}
+
+		return timestamp, nil
+	}
+
+	s := newTestScheduler(t, idx, milestoneTimestamp)
+	issued := recordEvents(s)
+
+	// the worker is behind, so only the latest milestone is applied, the timestamp of milestone 8 is read from the node
+	s.Enqueue(5, testTime(10))
+	s.Enqueue(6, testTime(20))
+	s.Enqueue(7, testTime(30))
+	s.Enqueue(8, time.Time{})
+	s.applyPending(context.Background())
+
+	if !reflect.DeepEqual(requested, []uint32{4, 8}) {
+		t.Errorf("expected the timestamps of the milestone before the first one and of milestone 8 to be read, got %v", requested)
+	}
+
+	// the expiring soon window of the skipped milestones is covered as well
+	expected := []testEvent{
+		{name: "expiringSoon", milestoneIndex: 8, milestoneTime: 120, outputID: testOutputID('e', 100)},
+		{name: "expired", milestoneIndex: 8, milestoneTime: 120, outputID: testOutputID('e', 100)},
+		{name: "timelockReleased", milestoneIndex: 8, milestoneTime: 120, outputID: testOutputID('t', 50)},
+	}
+	if events := issued(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected the events %v, got %v", expected, events)
+	}
+
+	// a milestone whose timestamp can not be read is skipped, its conditions are checked with the next milestone
+	s.Enqueue(9, time.Time{})
+	s.applyPending(context.Background())
+	if events := issued(); len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+	if !s.lastTimestamp.Equal(testTime(120)) {
+		t.Errorf("expected the clock to stay at milestone 8, got %s", s.lastTimestamp)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the worker to stop")
+	}
+}
+
+func TestResume(t *testing.T) {
+	idx := newTestIndexer(t, []int{100}, []int{50})
+
+	s := newTestScheduler(t, idx, nil)
+	for index, seconds := range []int{0, 10} {
+		if err := s.ApplyMilestone(context.Background(), uint32(index+1), testTime(seconds)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the scheduler was stopped after milestone 2, the first milestone after the restart
+	// issues the conditions that took effect in between instead of starting the clock again
+	restarted := newTestScheduler(t, idx, nil)
+	if !restarted.Initialized() {
+		t.Fatal("expected the scheduler to continue after the stored milestone")
+	}
+	issued := recordEvents(restarted)
+
+	if err := restarted.ApplyMilestone(context.Background(), 5, testTime(120)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []testEvent{
+		{name: "expiringSoon", milestoneIndex: 5, milestoneTime: 120, outputID: testOutputID('e', 100)},
+		{name: "expired", milestoneIndex: 5, milestoneTime: 120, outputID: testOutputID('e', 100)},
+		{name: "timelockReleased", milestoneIndex: 5, milestoneTime: 120, outputID: testOutputID('t', 50)},
+	}
+	if events := issued(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected the events %v, got %v", expected, events)
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	idx := newTestIndexer(t, []int{100}, []int{50})
+
+	s := newTestScheduler(t, idx, nil)
+	issued := recordEvents(s)
+
+	var written []uint32
+	failing := true
+	s.AddEventWriter(func(_ *gorm.DB, events *MilestoneEvents) error {
+		if failing {
+			return errors.New("write failed")
+		}
+		written = append(written, events.MilestoneIndex)
+
+		return nil
+	})
+
+	if err := s.ApplyMilestone(context.Background(), 1, testTime(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a milestone whose events can not be written is not applied, neither in memory nor in the database
+	if err := s.ApplyMilestone(context.Background(), 2, testTime(60)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if events := issued(); len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+	if !s.lastTimestamp.Equal(testTime(0)) {
+		t.Errorf("expected the clock to stay at milestone 1, got %s", s.lastTimestamp)
+	}
+	if restarted := newTestScheduler(t, idx, nil); !restarted.lastTimestamp.Equal(testTime(0)) {
+		t.Errorf("expected the stored clock to stay at milestone 1, got %s", restarted.lastTimestamp)
+	}
+
+	// the conditions are issued with the next milestone
+	failing = false
+	if err := s.ApplyMilestone(context.Background(), 3, testTime(60)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, []uint32{3}) {
+		t.Errorf("expected the events of milestone 3 to be written, got %v", written)
+	}
+
+	expected := []testEvent{
+		{name: "expiringSoon", milestoneIndex: 3, milestoneTime: 60, outputID: testOutputID('e', 100)},
+		{name: "timelockReleased", milestoneIndex: 3, milestoneTime: 60, outputID: testOutputID('t', 50)},
+	}
+	if events := issued(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected the events %v, got %v", expected, events)
+	}
+}
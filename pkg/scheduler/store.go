@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// schedulerStatus is the last milestone that was applied by the Scheduler.
+// It is not part of the tables of the indexer, so it is kept if the indexer is cleared.
+type schedulerStatus struct {
+	ID             uint   `gorm:"primaryKey;notnull"`
+	MilestoneIndex uint32 `gorm:"notnull"`
+	// MilestoneTimestamp is the unix timestamp of the milestone.
+	MilestoneTimestamp int64 `gorm:"notnull"`
+}
+
+func (schedulerStatus) TableName() string {
+	return "scheduler_status"
+}
+
+// store persists the progress of the Scheduler, so the conditions that took effect while it was stopped
+// are issued with the first milestone after a restart.
+type store struct {
+	db *gorm.DB
+}
+
+func newStore(db *gorm.DB) (*store, error) {
+	if err := db.AutoMigrate(&schedulerStatus{}); err != nil {
+		return nil, err
+	}
+
+	return &store{db: db}, nil
+}
+
+// lastTimestamp returns the timestamp of the last applied milestone, zero if none was applied yet.
+func (s *store) lastTimestamp() (time.Time, error) {
+	status := &schedulerStatus{}
+	if err := s.db.Take(status).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, err
+	}
+
+	return time.Unix(status.MilestoneTimestamp, 0), nil
+}
+
+// setLastMilestone stores the last applied milestone.
+func (s *store) setLastMilestone(tx *gorm.DB, index uint32, timestamp time.Time) error {
+	return tx.Save(&schedulerStatus{ID: 1, MilestoneIndex: index, MilestoneTimestamp: timestamp.Unix()}).Error
+}
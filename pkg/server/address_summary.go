@@ -0,0 +1,44 @@
+package server
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+func (s *IndexerServer) addressSummary(c echo.Context) (*addressSummaryResponse, error) {
+	address, err := s.parseBech32AddressParam(c, ParameterBech32Address)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.Indexer.AddressSummary(c.Request().Context(), address, time.Now())
+	if err != nil {
+		if errors.Is(err, indexer.ErrQueryTimeout) {
+			return nil, errors.WithMessagef(ErrQueryTimeout, "reading address summary failed: %s", err)
+		}
+
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading address summary failed: %s", err)
+	}
+
+	return &addressSummaryResponse{
+		LedgerIndex:  summary.LedgerIndex,
+		BasicOutputs: summary.BasicOutputs,
+		NFTOutputs:   summary.NFTOutputs,
+		AliasOutputs: aliasOutputsSummary{
+			StateController: summary.AliasOutputsAsStateController,
+			Governor:        summary.AliasOutputsAsGovernor,
+		},
+		FoundryOutputs: summary.FoundryOutputs,
+		Pending: pendingConditionsSummary{
+			Timelocked:                  summary.Timelocked,
+			Expiring:                    summary.Expiring,
+			ExpirationReturn:            summary.ExpirationReturn,
+			StorageDepositReturn:        summary.StorageDepositReturn,
+			StorageDepositReturnAddress: summary.StorageDepositReturnAddress,
+		},
+	}, nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const (
+	// cursorVersion is the version of the opaque cursor format.
+	cursorVersion byte = 2
+
+	// cursorFlagBackward marks cursors that point to the previous page.
+	cursorFlagBackward byte = 1 << 0
+
+	cursorFilterHashLength = 16
+	cursorPositionLength   = indexer.CursorLength / 2
+	cursorMACLength        = sha256.Size
+
+	// version + flags + pageSize + filter hash + position + MAC.
+	cursorLength = 1 + 1 + 4 + cursorFilterHashLength + cursorPositionLength + cursorMACLength
+)
+
+// cursorFilterHash returns a hash over the route and the filters of the request, including the path parameters
+// and a filter expression given in the body.
+// The cursor and the page size are not part of the hash, since they change between pages.
+// The values of a repeated filter are sorted, their order does not change the result.
+func cursorFilterHash(c echo.Context) []byte {
+	query := url.Values{}
+	for key, values := range c.QueryParams() {
+		if key == QueryParameterCursor || key == QueryParameterPageSize {
+			continue
+		}
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		query[key] = sorted
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(query.Encode()))
+	for _, value := range c.ParamValues() {
+		// the values of the path parameters, e.g. the address of an address route, belong to the filters as well
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+	if filterExpression, ok := c.Get(contextKeyFilterExpression).([]byte); ok {
+		hash.Write([]byte{0})
+		hash.Write(filterExpression)
+	}
+
+	return hash.Sum(nil)[:cursorFilterHashLength]
+}
+
+func (s *IndexerServer) cursorMAC(data []byte) []byte {
+	mac := hmac.New(sha256.New, s.cursorSigningKey)
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// encodeCursor wraps the cursor of the indexer into an opaque cursor that is bound to the route and filters of the request.
+func (s *IndexerServer) encodeCursor(c echo.Context, indexerCursor string, pageSize uint32, backward bool) (string, error) {
+	return s.encodeCursorWithFilterHash(cursorFilterHash(c), indexerCursor, pageSize, backward)
+}
+
+// encodeCursorWithFilterHash wraps the cursor of the indexer into an opaque cursor that is bound to the given filter hash.
+func (s *IndexerServer) encodeCursorWithFilterHash(filterHash []byte, indexerCursor string, pageSize uint32, backward bool) (string, error) {
+	position, err := hex.DecodeString(indexerCursor)
+	if err != nil || len(position) != cursorPositionLength {
+		return "", errors.Errorf("invalid indexer cursor: %s", indexerCursor)
+	}
+
+	data := make([]byte, 0, cursorLength)
+	var flags byte
+	if backward {
+		flags |= cursorFlagBackward
+	}
+
+	data = append(data, cursorVersion, flags)
+	data = binary.BigEndian.AppendUint32(data, pageSize)
+	data = append(data, filterHash...)
+	data = append(data, position...)
+	data = append(data, s.cursorMAC(data)...)
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// parseCursorQueryParameter verifies the opaque cursor of the request and returns the cursor of the indexer,
+// the page size and whether the cursor points to the previous page.
+func (s *IndexerServer) parseCursorQueryParameter(c echo.Context) (string, uint32, bool, error) {
+	return s.decodeCursor(c.QueryParam(QueryParameterCursor), "query parameter "+QueryParameterCursor, cursorFilterHash(c), s.maxPageSize(c.Request().Context()))
+}
+
+// decodeCursor verifies the opaque cursor against the given filter hash and returns the cursor of the indexer,
+// the page size and whether the cursor points to the previous page. The name is used in the error messages.
+// The page size of the cursor is limited to the given maximum page size of the client.
+func (s *IndexerServer) decodeCursor(cursor string, name string, filterHash []byte, maxPageSize int) (string, uint32, bool, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) == 0 {
+		return "", 0, false, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("%s has wrong format", name))
+	}
+
+	if data[0] != cursorVersion {
+		return "", 0, false, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("%s has unsupported version %d", name, data[0]))
+	}
+
+	if len(data) != cursorLength {
+		return "", 0, false, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("%s has wrong format", name))
+	}
+
+	payload, mac := data[:cursorLength-cursorMACLength], data[cursorLength-cursorMACLength:]
+	if !hmac.Equal(mac, s.cursorMAC(payload)) {
+		return "", 0, false, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("%s has an invalid signature", name))
+	}
+
+	offset := 1
+	backward := payload[offset]&cursorFlagBackward != 0
+	offset++
+
+	size := binary.BigEndian.Uint32(payload[offset : offset+4])
+	offset += 4
+
+	if !bytes.Equal(payload[offset:offset+cursorFilterHashLength], filterHash) {
+		return "", 0, false, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("%s does not match the route or the filters of the request", name))
+	}
+	offset += cursorFilterHashLength
+
+	pageSize := size
+	if pageSize == 0 || pageSize > uint32(maxPageSize) {
+		pageSize = uint32(maxPageSize)
+	}
+
+	return hex.EncodeToString(payload[offset:]), pageSize, backward, nil
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const testIndexerCursor = "6422a0c0" + "0000000000000000000000000000000000000000000000000000000000000000" + "0100"
+
+// newCursorTestContext returns the context of a request to the route with the given query and path parameters.
+func newCursorTestContext(path string, query string, params map[string]string) echo.Context {
+	// echo only keeps as many path parameters as the registered routes have
+	e := echo.New()
+	e.GET(path, func(c echo.Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath(path)
+
+	names := make([]string, 0, len(params))
+	values := make([]string, 0, len(params))
+	for name, value := range params {
+		names = append(names, name)
+		values = append(values, value)
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+
+	return c
+}
+
+func newCursorTestServer(key string) *IndexerServer {
+	return &IndexerServer{
+		cursorSigningKey:        []byte(key),
+		RestAPILimitsMaxResults: 1000,
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	if len(testIndexerCursor) != indexer.CursorLength {
+		t.Fatalf("invalid test cursor length: %d", len(testIndexerCursor))
+	}
+
+	s := newCursorTestServer("key")
+
+	for _, backward := range []bool{false, true} {
+		c := newCursorTestContext(RouteOutputsBasic, "address=rms1abc&pageSize=10", nil)
+		cursor, err := s.encodeCursor(c, testIndexerCursor, 10, backward)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the cursor and the page size of the request are not part of the filters
+		next := newCursorTestContext(RouteOutputsBasic, "pageSize=20&address=rms1abc&cursor="+cursor, nil)
+		indexerCursor, pageSize, decodedBackward, err := s.parseCursorQueryParameter(next)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if indexerCursor != testIndexerCursor {
+			t.Errorf("expected indexer cursor %s, got %s", testIndexerCursor, indexerCursor)
+		}
+		if pageSize != 10 {
+			t.Errorf("expected the page size of the cursor, got %d", pageSize)
+		}
+		if decodedBackward != backward {
+			t.Errorf("expected backward %t, got %t", backward, decodedBackward)
+		}
+	}
+}
+
+func TestCursorReorderedRepeatedValues(t *testing.T) {
+	s := newCursorTestServer("key")
+
+	c := newCursorTestContext(RouteOutputsBasic, "tag=0x01&tag=0x02&address=rms1abc", nil)
+	cursor, err := s.encodeCursor(c, testIndexerCursor, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the client may build the query of the next page with another order of the repeated values
+	next := newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x02&tag=0x01&cursor="+cursor, nil)
+	indexerCursor, _, _, err := s.parseCursorQueryParameter(next)
+	if err != nil {
+		t.Fatalf("expected the cursor to be accepted with reordered values, got %s", err)
+	}
+	if indexerCursor != testIndexerCursor {
+		t.Errorf("expected indexer cursor %s, got %s", testIndexerCursor, indexerCursor)
+	}
+
+	// the values are sorted in a copy, the parameters of the request are not changed
+	if tags := next.QueryParams()[QueryParameterTag]; len(tags) != 2 || tags[0] != "0x02" || tags[1] != "0x01" {
+		t.Errorf("expected the query parameters of the request to keep their order, got %v", tags)
+	}
+}
+
+func TestCursorPageSizeLimit(t *testing.T) {
+	s := newCursorTestServer("key")
+
+	c := newCursorTestContext(RouteOutputsBasic, "", nil)
+	cursor, err := s.encodeCursor(c, testIndexerCursor, 5000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, pageSize, _, err := s.decodeCursor(cursor, "cursor", cursorFilterHash(c), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pageSize != 100 {
+		t.Errorf("expected the page size to be limited to 100, got %d", pageSize)
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	s := newCursorTestServer("key")
+
+	c := newCursorTestContext(RouteAddressStorageDepositReturns, "hasNativeTokens=true", map[string]string{ParameterBech32Address: "rms1abc"})
+	cursor, err := s.encodeCursor(c, testIndexerCursor, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(modify func(data []byte) []byte) string {
+		modified := append([]byte{}, data...)
+
+		return base64.RawURLEncoding.EncodeToString(modify(modified))
+	}
+
+	tests := []struct {
+		name    string
+		server  *IndexerServer
+		context echo.Context
+		cursor  string
+		message string
+	}{
+		{
+			name:    "not base64",
+			cursor:  "not a cursor!",
+			message: "has wrong format",
+		},
+		{
+			name: "unsupported version",
+			cursor: encode(func(data []byte) []byte {
+				data[0] = 1
+
+				return data
+			}),
+			message: "has unsupported version 1",
+		},
+		{
+			name: "truncated",
+			cursor: encode(func(data []byte) []byte {
+				return data[:len(data)-1]
+			}),
+			message: "has wrong format",
+		},
+		{
+			name: "tampered position",
+			cursor: encode(func(data []byte) []byte {
+				data[cursorLength-cursorMACLength-1] ^= 0xff
+
+				return data
+			}),
+			message: "has an invalid signature",
+		},
+		{
+			name: "tampered page size",
+			cursor: encode(func(data []byte) []byte {
+				data[5] = 0xff
+
+				return data
+			}),
+			message: "has an invalid signature",
+		},
+		{
+			name: "tampered direction",
+			cursor: encode(func(data []byte) []byte {
+				data[1] ^= cursorFlagBackward
+
+				return data
+			}),
+			message: "has an invalid signature",
+		},
+		{
+			name:    "signed with another key",
+			server:  newCursorTestServer("other key"),
+			cursor:  cursor,
+			message: "has an invalid signature",
+		},
+		{
+			name:    "other filters",
+			context: newCursorTestContext(RouteAddressStorageDepositReturns, "hasNativeTokens=false", map[string]string{ParameterBech32Address: "rms1abc"}),
+			cursor:  cursor,
+			message: "does not match the route or the filters of the request",
+		},
+		{
+			name:    "other path parameter",
+			context: newCursorTestContext(RouteAddressStorageDepositReturns, "hasNativeTokens=true", map[string]string{ParameterBech32Address: "rms1def"}),
+			cursor:  cursor,
+			message: "does not match the route or the filters of the request",
+		},
+		{
+			name:    "other route",
+			context: newCursorTestContext(RouteOutputsBasic, "hasNativeTokens=true", nil),
+			cursor:  cursor,
+			message: "does not match the route or the filters of the request",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			server := s
+			if test.server != nil {
+				server = test.server
+			}
+			filterContext := c
+			if test.context != nil {
+				filterContext = test.context
+			}
+
+			_, _, _, err := server.decodeCursor(test.cursor, "cursor", cursorFilterHash(filterContext), 1000)
+			if !errors.Is(err, httpserver.ErrInvalidParameter) {
+				t.Fatalf("expected an invalid parameter error, got %v", err)
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error %q, got %q", test.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestCursorFilterHash(t *testing.T) {
+	base := cursorFilterHash(newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x01", nil))
+
+	tests := []struct {
+		name    string
+		context echo.Context
+		equal   bool
+	}{
+		{
+			name:    "other order of the parameters",
+			context: newCursorTestContext(RouteOutputsBasic, "tag=0x01&address=rms1abc", nil),
+			equal:   true,
+		},
+		{
+			name:    "cursor and page size",
+			context: newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x01&pageSize=5&cursor=abc", nil),
+			equal:   true,
+		},
+		{
+			name:    "additional filter",
+			context: newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x01&hasTimelock=true", nil),
+		},
+		{
+			name:    "other value of a filter",
+			context: newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x02", nil),
+		},
+		{
+			name:    "additional value of a filter",
+			context: newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x01&tag=0x02", nil),
+		},
+		{
+			name: "filter expression",
+			context: func() echo.Context {
+				c := newCursorTestContext(RouteOutputsBasic, "address=rms1abc&tag=0x01", nil)
+				c.Set(contextKeyFilterExpression, []byte(`{"not":{"hasTimelock":true}}`))
+
+				return c
+			}(),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if equal := string(cursorFilterHash(test.context)) == string(base); equal != test.equal {
+				t.Errorf("expected equal hashes %t, got %t", test.equal, equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// RouteOutputsBasicExport is the route for exporting all basic outputs filtered by the given parameters.
+	// GET with the query parameters of RouteOutputsBasic streams a row for every output in the order of the cursors.
+	// POST accepts the same parameters as form encoded or JSON body. The paging parameters are ignored.
+	// Query parameter: "format" (csv or ndjson, default csv)
+	RouteOutputsBasicExport = "/outputs/basic/export"
+
+	// RouteOutputsAliasesExport is the route for exporting all aliases filtered by the given parameters.
+	// GET with the query parameters of RouteOutputsAliases streams a row for every output in the order of the cursors.
+	// POST accepts the same parameters as form encoded or JSON body. The paging parameters are ignored.
+	// Query parameter: "format" (csv or ndjson, default csv)
+	RouteOutputsAliasesExport = "/outputs/alias/export"
+
+	// RouteOutputsNFTsExport is the route for exporting all NFTs filtered by the given parameters.
+	// GET with the query parameters of RouteOutputsNFTs streams a row for every output in the order of the cursors.
+	// POST accepts the same parameters as form encoded or JSON body. The paging parameters are ignored.
+	// Query parameter: "format" (csv or ndjson, default csv)
+	RouteOutputsNFTsExport = "/outputs/nft/export"
+
+	// RouteOutputsFoundriesExport is the route for exporting all foundries filtered by the given parameters.
+	// GET with the query parameters of RouteOutputsFoundries streams a row for every output in the order of the cursors.
+	// POST accepts the same parameters as form encoded or JSON body. The paging parameters are ignored.
+	// Query parameter: "format" (csv or ndjson, default csv)
+	RouteOutputsFoundriesExport = "/outputs/foundry/export"
+
+	// QueryParameterFormat is used to define the format of an export.
+	QueryParameterFormat = "format"
+
+	// HeaderLedgerIndex is the header that contains the ledger index all rows of an export were read at.
+	HeaderLedgerIndex = "X-Ledger-Index"
+
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	// MIMEApplicationNDJSON is the content type of an export with a JSON object per line.
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	// MIMETextCSV is the content type of an export with comma separated values.
+	MIMETextCSV = "text/csv; charset=utf-8"
+
+	// exportFlushInterval is the amount of rows after which the buffered rows are sent to the client.
+	exportFlushInterval = 1000
+)
+
+// exportColumn is a column of an export.
+type exportColumn struct {
+	name string
+	// value returns the value of the column for an output, nil if it is not set.
+	value func(s *IndexerServer, output *indexer.ExportedOutput) interface{}
+}
+
+func exportAddress(address iotago.Address, hrp iotago.NetworkPrefix) interface{} {
+	if address == nil {
+		return nil
+	}
+
+	return address.Bech32(hrp)
+}
+
+func exportTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return uint32(t.Unix())
+}
+
+func exportHex(value []byte) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+
+	return iotago.EncodeHex(value)
+}
+
+var (
+	exportColumnOutputID = exportColumn{"outputId", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return o.OutputID.ToHex()
+	}}
+	exportColumnOutputType = exportColumn{"outputType", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		// the numeric type, the same as in the NDJSON rows
+		return uint8(o.OutputType)
+	}}
+	exportColumnCreatedAt = exportColumn{"createdAt", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return uint32(o.CreatedAt.Unix())
+	}}
+	exportColumnID = func(name string) exportColumn {
+		return exportColumn{name, func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+			return exportHex(o.ID)
+		}}
+	}
+	exportColumnNativeTokenCount = exportColumn{"nativeTokenCount", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return o.NativeTokenCount
+	}}
+	exportColumnAddress = exportColumn{"address", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.Address, s.Bech32HRP)
+	}}
+	exportColumnStateController = exportColumn{"stateController", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.StateController, s.Bech32HRP)
+	}}
+	exportColumnGovernor = exportColumn{"governor", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.Governor, s.Bech32HRP)
+	}}
+	exportColumnAliasAddress = exportColumn{"aliasAddress", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.AliasAddress, s.Bech32HRP)
+	}}
+	exportColumnIssuer = exportColumn{"issuer", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.Issuer, s.Bech32HRP)
+	}}
+	exportColumnSender = exportColumn{"sender", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.Sender, s.Bech32HRP)
+	}}
+	exportColumnTag = exportColumn{"tag", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportHex(o.Tag)
+	}}
+	exportColumnStorageDepositReturn = exportColumn{"storageDepositReturn", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		if o.StorageDepositReturn == nil {
+			return nil
+		}
+
+		return strconv.FormatUint(*o.StorageDepositReturn, 10)
+	}}
+	exportColumnStorageDepositReturnAddress = exportColumn{"storageDepositReturnAddress", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.StorageDepositReturnAddress, s.Bech32HRP)
+	}}
+	exportColumnTimelockTime = exportColumn{"timelockTime", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportTime(o.TimelockTime)
+	}}
+	exportColumnExpirationTime = exportColumn{"expirationTime", func(_ *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportTime(o.ExpirationTime)
+	}}
+	exportColumnExpirationReturnAddress = exportColumn{"expirationReturnAddress", func(s *IndexerServer, o *indexer.ExportedOutput) interface{} {
+		return exportAddress(o.ExpirationReturnAddress, s.Bech32HRP)
+	}}
+
+	basicOutputExportColumns = []exportColumn{
+		exportColumnOutputID, exportColumnOutputType, exportColumnCreatedAt, exportColumnNativeTokenCount,
+		exportColumnAddress, exportColumnSender, exportColumnTag,
+		exportColumnStorageDepositReturn, exportColumnStorageDepositReturnAddress,
+		exportColumnTimelockTime, exportColumnExpirationTime, exportColumnExpirationReturnAddress,
+	}
+	aliasExportColumns = []exportColumn{
+		exportColumnOutputID, exportColumnOutputType, exportColumnCreatedAt, exportColumnID("aliasId"), exportColumnNativeTokenCount,
+		exportColumnStateController, exportColumnGovernor, exportColumnIssuer, exportColumnSender,
+	}
+	nftExportColumns = []exportColumn{
+		exportColumnOutputID, exportColumnOutputType, exportColumnCreatedAt, exportColumnID("nftId"), exportColumnNativeTokenCount,
+		exportColumnAddress, exportColumnIssuer, exportColumnSender, exportColumnTag,
+		exportColumnStorageDepositReturn, exportColumnStorageDepositReturnAddress,
+		exportColumnTimelockTime, exportColumnExpirationTime, exportColumnExpirationReturnAddress,
+	}
+	foundryExportColumns = []exportColumn{
+		exportColumnOutputID, exportColumnOutputType, exportColumnCreatedAt, exportColumnID("foundryId"), exportColumnNativeTokenCount,
+		exportColumnAliasAddress,
+	}
+)
+
+// exportWriter streams the outputs of an export to the response.
+// The headers are sent once the ledger index of the export is known.
+type exportWriter struct {
+	s       *IndexerServer
+	c       echo.Context
+	format  string
+	name    string
+	columns []exportColumn
+
+	buffer *bufio.Writer
+	csv    *csv.Writer
+	rows   int
+}
+
+func (w *exportWriter) Begin(ledgerIndex uint32) error {
+	extension := exportFormatCSV
+	contentType := MIMETextCSV
+	if w.format == exportFormatNDJSON {
+		extension = exportFormatNDJSON
+		contentType = MIMEApplicationNDJSON
+	}
+
+	header := w.c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-outputs-%d.%s", w.name, ledgerIndex, extension)))
+	header.Set(HeaderLedgerIndex, strconv.FormatUint(uint64(ledgerIndex), 10))
+	w.c.Response().WriteHeader(http.StatusOK)
+
+	w.buffer = bufio.NewWriter(w.c.Response())
+	if w.format != exportFormatCSV {
+		return nil
+	}
+
+	w.csv = csv.NewWriter(w.buffer)
+	names := make([]string, 0, len(w.columns))
+	for _, column := range w.columns {
+		names = append(names, column.name)
+	}
+
+	return w.csv.Write(names)
+}
+
+func (w *exportWriter) Write(output *indexer.ExportedOutput) error {
+	var err error
+	if w.format == exportFormatCSV {
+		err = w.writeCSV(output)
+	} else {
+		err = w.writeNDJSON(output)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%exportFlushInterval == 0 {
+		return w.flush()
+	}
+
+	return nil
+}
+
+func (w *exportWriter) writeCSV(output *indexer.ExportedOutput) error {
+	record := make([]string, 0, len(w.columns))
+	for _, column := range w.columns {
+		value := column.value(w.s, output)
+		if value == nil {
+			record = append(record, "")
+
+			continue
+		}
+		record = append(record, fmt.Sprint(value))
+	}
+
+	return w.csv.Write(record)
+}
+
+// writeNDJSON writes the output as a JSON object in the order of the columns, the columns that are not set are omitted.
+func (w *exportWriter) writeNDJSON(output *indexer.ExportedOutput) error {
+	if err := w.buffer.WriteByte('{'); err != nil {
+		return err
+	}
+
+	first := true
+	for _, column := range w.columns {
+		value := column.value(w.s, output)
+		if value == nil {
+			continue
+		}
+
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if err := w.buffer.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		if _, err := fmt.Fprintf(w.buffer, "%q:%s", column.name, valueJSON); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.buffer.WriteString("}\n")
+
+	return err
+}
+
+// flush sends the buffered rows to the client.
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+	w.c.Response().Flush()
+
+	return nil
+}
+
+// exportRoute returns the handler of the export route of an output type.
+func exportRoute[T any](s *IndexerServer, name string, columns []exportColumn, parseFilters func(c echo.Context) ([]T, error), export func(context.Context, indexer.ExportWriter, ...T) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.exportSlots != nil {
+			select {
+			case s.exportSlots <- struct{}{}:
+				defer func() { <-s.exportSlots }()
+			default:
+				return errors.WithMessagef(echo.ErrTooManyRequests, "the maximum of %d concurrent exports is reached, retry later", cap(s.exportSlots))
+			}
+		}
+
+		// an export is not paginated, so a cursor or page size of the paginated routes must not be validated as filter
+		query := c.QueryParams()
+		query.Del(QueryParameterCursor)
+		query.Del(QueryParameterPageSize)
+		c.Request().URL.RawQuery = query.Encode()
+
+		format := exportFormatCSV
+		if len(c.QueryParam(QueryParameterFormat)) > 0 {
+			format = c.QueryParam(QueryParameterFormat)
+			if format != exportFormatCSV && format != exportFormatNDJSON {
+				return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid format: %s, supported formats are %s and %s", format, exportFormatCSV, exportFormatNDJSON)
+			}
+		}
+
+		filters, err := parseFilters(c)
+		if err != nil {
+			return err
+		}
+
+		writer := &exportWriter{
+			s:       s,
+			c:       c,
+			format:  format,
+			name:    name,
+			columns: columns,
+		}
+
+		if err := export(c.Request().Context(), writer, filters...); err != nil {
+			if !c.Response().Committed {
+				if errors.Is(err, indexer.ErrQueryTimeout) {
+					return errors.WithMessagef(ErrQueryTimeout, "exporting outputs failed: %s", err)
+				}
+
+				return errors.WithMessagef(echo.ErrInternalServerError, "exporting outputs failed: %s", err)
+			}
+
+			// the status was already sent, aborting the connection is the only way to tell the client
+			// that the export is incomplete
+			panic(http.ErrAbortHandler)
+		}
+
+		return writer.flush()
+	}
+}
+
+func (s *IndexerServer) configureExportRoutes(routeGroup *echo.Group) {
+	basicOutputsExport := exportRoute(s, "basic", basicOutputExportColumns, s.basicOutputsFilters, s.Indexer.ExportBasicOutputs)
+	routeGroup.GET(RouteOutputsBasicExport, basicOutputsExport)
+	routeGroup.POST(RouteOutputsBasicExport, basicOutputsExport, bodyToQueryParams)
+
+	aliasesExport := exportRoute(s, "alias", aliasExportColumns, s.aliasesFilters, s.Indexer.ExportAliasOutputs)
+	routeGroup.GET(RouteOutputsAliasesExport, aliasesExport)
+	routeGroup.POST(RouteOutputsAliasesExport, aliasesExport, bodyToQueryParams)
+
+	nftsExport := exportRoute(s, "nft", nftExportColumns, s.nftsFilters, s.Indexer.ExportNFTOutputs)
+	routeGroup.GET(RouteOutputsNFTsExport, nftsExport)
+	routeGroup.POST(RouteOutputsNFTsExport, nftsExport, bodyToQueryParams)
+
+	foundriesExport := exportRoute(s, "foundry", foundryExportColumns, s.foundriesFilters, s.Indexer.ExportFoundryOutputs)
+	routeGroup.GET(RouteOutputsFoundriesExport, foundriesExport)
+	routeGroup.POST(RouteOutputsFoundriesExport, foundriesExport, bodyToQueryParams)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/core/generics/options"
+)
+
+func newExportTestServer(t *testing.T, opts ...options.Option[IndexerServer]) *IndexerServer {
+	t.Helper()
+
+	return options.Apply(&IndexerServer{
+		Indexer:                 newTestIndexer(t, 10),
+		RestAPILimitsMaxResults: 1000,
+		cursorSigningKey:        []byte("key"),
+	}, opts)
+}
+
+func export(s *IndexerServer, target string) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	handler := exportRoute(s, "basic", basicOutputExportColumns, s.basicOutputsFilters, s.Indexer.ExportBasicOutputs)
+
+	return rec, handler(echo.New().NewContext(req, rec))
+}
+
+func TestExportIgnoresPaging(t *testing.T) {
+	s := newExportTestServer(t, WithExport(0))
+
+	rec, err := export(s, RouteOutputsBasicExport+"?format=ndjson&pageSize=abc&cursor=invalid&hasNativeTokens=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ledgerIndex := rec.Header().Get(HeaderLedgerIndex); ledgerIndex != "10" {
+		t.Errorf("expected the export at ledger index 10, got %s", ledgerIndex)
+	}
+
+	// the other filters are still validated
+	if _, err := export(s, RouteOutputsBasicExport+"?cursor=invalid&hasNativeTokens=maybe"); err == nil {
+		t.Error("expected an invalid filter to be rejected")
+	}
+}
+
+func TestExportConcurrencyLimit(t *testing.T) {
+	s := newExportTestServer(t, WithExport(1))
+
+	// an export that is still running
+	s.exportSlots <- struct{}{}
+
+	_, err := export(s, RouteOutputsBasicExport)
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %v", http.StatusTooManyRequests, err)
+	}
+	if !strings.Contains(err.Error(), "maximum of 1 concurrent exports") {
+		t.Errorf("expected the limit in the error, got %q", err.Error())
+	}
+
+	<-s.exportSlots
+	rec, err := export(s, RouteOutputsBasicExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d after the running export finished, got %d", http.StatusOK, rec.Code)
+	}
+	if len(s.exportSlots) != 0 {
+		t.Error("expected the slot of the finished export to be released")
+	}
+}
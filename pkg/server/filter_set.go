@@ -0,0 +1,73 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// FilterSetNone is the filter set of requests without filters.
+	FilterSetNone = "none"
+	// FilterSetExpression is the filter set of requests with a filter expression.
+	FilterSetExpression = "expression"
+)
+
+var (
+	// filterQueryParameters are the query parameters that filter the outputs.
+	// Other query parameters are not part of the filter set, so clients can't create arbitrary filter sets.
+	filterQueryParameters = map[string]struct{}{
+		QueryParameterAddress:                     {},
+		QueryParameterNotAddress:                  {},
+		QueryParameterAliasAddress:                {},
+		QueryParameterIssuer:                      {},
+		QueryParameterNotIssuer:                   {},
+		QueryParameterSender:                      {},
+		QueryParameterNotSender:                   {},
+		QueryParameterTag:                         {},
+		QueryParameterNotTag:                      {},
+		QueryParameterTagPrefix:                   {},
+		QueryParameterTagContains:                 {},
+		QueryParameterHasStorageDepositReturn:     {},
+		QueryParameterStorageDepositReturnAddress: {},
+		QueryParameterHasExpiration:               {},
+		QueryParameterExpiresBefore:               {},
+		QueryParameterExpiresAfter:                {},
+		QueryParameterExpirationReturnAddress:     {},
+		QueryParameterHasTimelock:                 {},
+		QueryParameterTimelockedBefore:            {},
+		QueryParameterTimelockedAfter:             {},
+		QueryParameterStateController:             {},
+		QueryParameterGovernor:                    {},
+		QueryParameterCreatedBefore:               {},
+		QueryParameterCreatedAfter:                {},
+		QueryParameterHasNativeTokens:             {},
+		QueryParameterMinNativeTokenCount:         {},
+		QueryParameterMaxNativeTokenCount:         {},
+	}
+)
+
+// FilterSet returns the sorted names of the filters of a request, joined by ",".
+// It is meant to group the requests of a route by the combination of filters, e.g. for metrics,
+// the values of the filters are not part of it.
+// It has to be called after the request was handled, so the filters of a POST body are included.
+func FilterSet(c echo.Context) string {
+	if strings.HasSuffix(c.Path(), "/query") {
+		return FilterSetExpression
+	}
+
+	filters := make([]string, 0)
+	for name := range c.Request().URL.Query() {
+		if _, isFilter := filterQueryParameters[name]; isFilter {
+			filters = append(filters, name)
+		}
+	}
+
+	if len(filters) == 0 {
+		return FilterSetNone
+	}
+	sort.Strings(filters)
+
+	return strings.Join(filters, ",")
+}
@@ -0,0 +1,673 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// RouteGraphQL is the route of the GraphQL endpoint.
+	// GET with the query parameters "query", "operationName" and "variables", or POST with a JSON body containing these fields.
+	RouteGraphQL = "/graphql"
+
+	// GraphQLDefaultPageSize is the amount of items returned by a connection if "first" is not given.
+	GraphQLDefaultPageSize = 10
+
+	graphQLArgumentFilter = "filter"
+	graphQLArgumentFirst  = "first"
+	graphQLArgumentAfter  = "after"
+)
+
+// graphQLRequest is a GraphQL request as sent by the clients.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLNode is the source of the output types in the schema.
+type graphQLNode struct {
+	OutputID iotago.OutputID
+	// ID is the aliasID, nftID or foundryID of the output, if known.
+	ID []byte
+	// Address is the address of an alias or NFT, which can own other outputs.
+	Address iotago.Address
+}
+
+// graphQLAddress is the source of the address type in the schema.
+type graphQLAddress struct {
+	Address iotago.Address
+}
+
+var graphQLFilterExpression = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "FilterExpression",
+	Description: "A filter expression as used by the query routes of the REST API, e.g. {or: [{tag: \"0x01\"}, {hasTimelock: false}]}.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue:   graphQLVariableValue,
+	ParseLiteral: graphQLLiteralValue,
+})
+
+// graphQLVariableValue converts the numbers of a decoded JSON variable to the same values a JSON decoder returns with UseNumber.
+// The variables are decoded without UseNumber, since GraphQL can not coerce json.Number to its number types.
+func graphQLVariableValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			object[key] = graphQLVariableValue(fieldValue)
+		}
+
+		return object
+
+	case []interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, listValue := range v {
+			list = append(list, graphQLVariableValue(listValue))
+		}
+
+		return list
+
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+
+	default:
+		return value
+	}
+}
+
+// graphQLLiteralValue converts a literal of the query to the same values a JSON decoder returns.
+func graphQLLiteralValue(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			fieldValue := graphQLLiteralValue(field.Value)
+			if fieldValue == nil {
+				return nil
+			}
+			object[field.Name.Value] = fieldValue
+		}
+
+		return object
+
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(value.Values))
+		for _, v := range value.Values {
+			listValue := graphQLLiteralValue(v)
+			if listValue == nil {
+				return nil
+			}
+			list = append(list, listValue)
+		}
+
+		return list
+
+	case *ast.StringValue:
+		return value.Value
+
+	case *ast.IntValue:
+		return json.Number(value.Value)
+
+	case *ast.FloatValue:
+		return json.Number(value.Value)
+
+	case *ast.BooleanValue:
+		return value.Value
+
+	default:
+		return nil
+	}
+}
+
+func (s *IndexerServer) configureGraphQLRoutes(routeGroup *echo.Group) error {
+	schema, err := s.graphQLSchema()
+	if err != nil {
+		return err
+	}
+
+	routeGroup.GET(RouteGraphQL, func(c echo.Context) error {
+		request := &graphQLRequest{
+			Query:         c.QueryParam("query"),
+			OperationName: c.QueryParam("operationName"),
+		}
+
+		if variables := c.QueryParam("variables"); len(variables) > 0 {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid query parameter variables, error: %s", err)
+			}
+		}
+
+		return s.graphQL(c, schema, request)
+	})
+
+	routeGroup.POST(RouteGraphQL, func(c echo.Context) error {
+		req := c.Request()
+		req.Body = http.MaxBytesReader(c.Response(), req.Body, maxFilterBodySize)
+
+		request := &graphQLRequest{}
+		if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+			return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request body, error: %s", err)
+		}
+
+		return s.graphQL(c, schema, request)
+	})
+
+	return nil
+}
+
+func (s *IndexerServer) graphQL(c echo.Context, schema graphql.Schema, request *graphQLRequest) error {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+	}
+
+	complexity, depth := graphQLQueryComplexity(document, request.OperationName, request.Variables, s.maxPageSize(c.Request().Context()), s.graphQLMaxComplexity)
+	if depth > s.graphQLMaxDepth {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.Errorf("query is too deep, depth %d exceeds the max. depth of %d", depth, s.graphQLMaxDepth))})
+	}
+	if complexity > s.graphQLMaxComplexity {
+		return c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.Errorf("query is too complex, complexity %d exceeds the max. complexity of %d", complexity, s.graphQLMaxComplexity))})
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        c.Request().Context(),
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (s *IndexerServer) graphQLSchema() (graphql.Schema, error) {
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	newConnectionType := func(name string, nodeType *graphql.Object) *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name: name,
+			Fields: graphql.Fields{
+				"ledgerIndex": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "The ledger index at which the outputs were queried."},
+				"nodes":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nodeType)))},
+				"pageInfo":    &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			},
+		})
+	}
+
+	var basicOutputConnectionType, aliasConnectionType, nftConnectionType, foundryConnectionType *graphql.Object
+
+	outputIDField := &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			//nolint:forcetypeassert // the source of all output types is a graphQLNode
+			return p.Source.(*graphQLNode).OutputID.ToHex(), nil
+		},
+	}
+
+	idField := &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			//nolint:forcetypeassert // the source of all output types is a graphQLNode
+			node := p.Source.(*graphQLNode)
+			if node.ID == nil {
+				// the output was consumed after it was returned by the connection
+				//nolint:nilnil // null is a valid value in GraphQL
+				return nil, nil
+			}
+
+			return iotago.EncodeHex(node.ID), nil
+		},
+	}
+
+	addressField := &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			//nolint:forcetypeassert // the source of all output types is a graphQLNode
+			node := p.Source.(*graphQLNode)
+			if node.Address == nil {
+				//nolint:nilnil // null is a valid value in GraphQL
+				return nil, nil
+			}
+
+			return node.Address.Bech32(s.Bech32HRP), nil
+		},
+	}
+
+	// ownedOutputsFields returns the connections of the outputs owned by the address of the source.
+	ownedOutputsFields := func(owner func(source interface{}) iotago.Address) graphql.Fields {
+		return graphql.Fields{
+			"basicOutputs": &graphql.Field{
+				Type:        basicOutputConnectionType,
+				Description: "The basic outputs that can be unlocked by the address.",
+				Args:        graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					address := owner(p.Source)
+					if address == nil {
+						//nolint:nilnil // null is a valid value in GraphQL
+						return nil, nil
+					}
+
+					return graphQLConnection(s, p, s.basicOutputsQuery(), address.Bech32(s.Bech32HRP), s.graphQLNodes, indexer.BasicOutputUnlockableByAddress(address))
+				},
+			},
+			"aliases": &graphql.Field{
+				Type:        aliasConnectionType,
+				Description: "The aliases that have the address as state controller.",
+				Args:        graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					address := owner(p.Source)
+					if address == nil {
+						//nolint:nilnil // null is a valid value in GraphQL
+						return nil, nil
+					}
+
+					return graphQLConnection(s, p, s.aliasesQuery(), address.Bech32(s.Bech32HRP), s.graphQLAliasNodes, indexer.AliasStateController(address))
+				},
+			},
+			"nfts": &graphql.Field{
+				Type:        nftConnectionType,
+				Description: "The NFTs that can be unlocked by the address.",
+				Args:        graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					address := owner(p.Source)
+					if address == nil {
+						//nolint:nilnil // null is a valid value in GraphQL
+						return nil, nil
+					}
+
+					return graphQLConnection(s, p, s.nftsQuery(), address.Bech32(s.Bech32HRP), s.graphQLNFTNodes, indexer.NFTUnlockableByAddress(address))
+				},
+			},
+			"foundries": &graphql.Field{
+				Type:        foundryConnectionType,
+				Description: "The foundries controlled by the address, only alias addresses can control foundries.",
+				Args:        graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					aliasAddress, ok := owner(p.Source).(*iotago.AliasAddress)
+					if !ok {
+						//nolint:nilnil // null is a valid value in GraphQL
+						return nil, nil
+					}
+
+					return graphQLConnection(s, p, s.foundriesQuery(), aliasAddress.Bech32(s.Bech32HRP), s.graphQLFoundryNodes, indexer.FoundryWithAliasAddress(aliasAddress))
+				},
+			},
+		}
+	}
+
+	nodeOwner := func(source interface{}) iotago.Address {
+		//nolint:forcetypeassert // the source of all output types is a graphQLNode
+		return source.(*graphQLNode).Address
+	}
+
+	withFields := func(fields graphql.Fields, additional graphql.Fields) graphql.Fields {
+		for name, field := range additional {
+			fields[name] = field
+		}
+
+		return fields
+	}
+
+	basicOutputType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BasicOutput",
+		Fields: graphql.Fields{
+			"outputId": outputIDField,
+		},
+	})
+
+	aliasType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Alias",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return withFields(graphql.Fields{
+				"outputId": outputIDField,
+				"aliasId":  idField,
+				"address":  addressField,
+			}, ownedOutputsFields(nodeOwner))
+		}),
+	})
+
+	nftType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NFT",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return withFields(graphql.Fields{
+				"outputId": outputIDField,
+				"nftId":    idField,
+				"address":  addressField,
+			}, ownedOutputsFields(nodeOwner))
+		}),
+	})
+
+	foundryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Foundry",
+		Fields: graphql.Fields{
+			"outputId":  outputIDField,
+			"foundryId": idField,
+		},
+	})
+
+	addressType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Address",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return withFields(graphql.Fields{
+				"bech32": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						//nolint:forcetypeassert // the source of the address type is a graphQLAddress
+						return p.Source.(*graphQLAddress).Address.Bech32(s.Bech32HRP), nil
+					},
+				},
+			}, ownedOutputsFields(func(source interface{}) iotago.Address {
+				//nolint:forcetypeassert // the source of the address type is a graphQLAddress
+				return source.(*graphQLAddress).Address
+			}))
+		}),
+	})
+
+	basicOutputConnectionType = newConnectionType("BasicOutputConnection", basicOutputType)
+	aliasConnectionType = newConnectionType("AliasConnection", aliasType)
+	nftConnectionType = newConnectionType("NFTConnection", nftType)
+	foundryConnectionType = newConnectionType("FoundryConnection", foundryType)
+
+	idArgs := func(name string) graphql.FieldConfigArgument {
+		return graphql.FieldConfigArgument{
+			name: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		}
+	}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"basicOutputs": &graphql.Field{
+				Type: basicOutputConnectionType,
+				Args: graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphQLConnection(s, p, s.basicOutputsQuery(), "", s.graphQLNodes)
+				},
+			},
+			"aliases": &graphql.Field{
+				Type: aliasConnectionType,
+				Args: graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphQLConnection(s, p, s.aliasesQuery(), "", s.graphQLAliasNodes)
+				},
+			},
+			"nfts": &graphql.Field{
+				Type: nftConnectionType,
+				Args: graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphQLConnection(s, p, s.nftsQuery(), "", s.graphQLNFTNodes)
+				},
+			},
+			"foundries": &graphql.Field{
+				Type: foundryConnectionType,
+				Args: graphQLConnectionArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphQLConnection(s, p, s.foundriesQuery(), "", s.graphQLFoundryNodes)
+				},
+			},
+			"alias": &graphql.Field{
+				Type: aliasType,
+				Args: idArgs("aliasId"),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					aliasID := iotago.AliasID{}
+					if err := graphQLParseID(p, "aliasId", aliasID[:]); err != nil {
+						return nil, err
+					}
+
+					return graphQLSingleNode(s.Indexer.AliasOutput(p.Context, &aliasID), aliasID[:], aliasID.ToAddress())
+				},
+			},
+			"nft": &graphql.Field{
+				Type: nftType,
+				Args: idArgs("nftId"),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nftID := iotago.NFTID{}
+					if err := graphQLParseID(p, "nftId", nftID[:]); err != nil {
+						return nil, err
+					}
+
+					return graphQLSingleNode(s.Indexer.NFTOutput(p.Context, &nftID), nftID[:], nftID.ToAddress())
+				},
+			},
+			"foundry": &graphql.Field{
+				Type: foundryType,
+				Args: idArgs("foundryId"),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					foundryID := iotago.FoundryID{}
+					if err := graphQLParseID(p, "foundryId", foundryID[:]); err != nil {
+						return nil, err
+					}
+
+					return graphQLSingleNode(s.Indexer.FoundryOutput(p.Context, &foundryID), foundryID[:], nil)
+				},
+			},
+			"address": &graphql.Field{
+				Type: addressType,
+				Args: idArgs("bech32"),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					//nolint:forcetypeassert // the argument is a non-null string
+					address, err := s.parseFilterExpressionAddress("bech32", p.Args["bech32"].(string))
+					if err != nil {
+						return nil, err
+					}
+
+					return &graphQLAddress{Address: address}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: queryType,
+	})
+}
+
+func graphQLConnectionArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		graphQLArgumentFilter: &graphql.ArgumentConfig{Type: graphQLFilterExpression, Description: "A filter expression over the filters of the output type."},
+		graphQLArgumentFirst:  &graphql.ArgumentConfig{Type: graphql.Int, Description: fmt.Sprintf("The amount of outputs to return, %d if not given.", GraphQLDefaultPageSize)},
+		graphQLArgumentAfter:  &graphql.ArgumentConfig{Type: graphql.String, Description: "The endCursor of the previous page."},
+	}
+}
+
+func graphQLParseID(p graphql.ResolveParams, name string, id []byte) error {
+	//nolint:forcetypeassert // the argument is a non-null string
+	value := strings.ToLower(p.Args[name].(string))
+
+	idBytes, err := iotago.DecodeHex(value)
+	if err != nil || len(idBytes) != len(id) {
+		return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid %s: %s", name, value)
+	}
+	copy(id, idBytes)
+
+	return nil
+}
+
+func graphQLSingleNode(result *indexer.IndexerResult, id []byte, address iotago.Address) (interface{}, error) {
+	if result.Error != nil {
+		return nil, errorFromResult(result)
+	}
+	if len(result.OutputIDs) == 0 {
+		//nolint:nilnil // null is a valid value in GraphQL
+		return nil, nil
+	}
+
+	return &graphQLNode{OutputID: result.OutputIDs[0], ID: id, Address: address}, nil
+}
+
+// graphQLConnection queries a page of outputs matching the arguments of the connection and the given filters.
+// The scope identifies the parent of a nested connection and is part of the filter hash the cursors are bound to.
+func graphQLConnection[T any](s *IndexerServer, p graphql.ResolveParams, q *outputsQuery[T], scope string, nodes func(context.Context, iotago.OutputIDs) ([]*graphQLNode, error), filters ...T) (interface{}, error) {
+	maxPageSize := s.maxPageSize(p.Context)
+	pageSize := GraphQLDefaultPageSize
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	if first, ok := p.Args[graphQLArgumentFirst].(int); ok {
+		if first < 1 || first > maxPageSize {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "argument %s has to be between 1 and %d", graphQLArgumentFirst, maxPageSize)
+		}
+		pageSize = first
+	}
+	filters = append(filters, q.pageSize(uint32(pageSize)))
+
+	var expr *indexer.FilterExpression[T]
+	var canonicalFilter []byte
+	if filter, ok := p.Args[graphQLArgumentFilter]; ok && filter != nil {
+		nodesCount := 0
+		var err error
+		if expr, err = parseFilterExpression(s, q.predicates, filter, 1, &nodesCount); err != nil {
+			return nil, err
+		}
+
+		if canonicalFilter, err = json.Marshal(filter); err != nil {
+			return nil, err
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(RouteGraphQL))
+	hash.Write([]byte{0})
+	hash.Write([]byte(p.Info.FieldName))
+	hash.Write([]byte{0})
+	hash.Write([]byte(scope))
+	hash.Write([]byte{0})
+	hash.Write(canonicalFilter)
+	filterHash := hash.Sum(nil)[:cursorFilterHashLength]
+
+	if after, ok := p.Args[graphQLArgumentAfter].(string); ok && len(after) > 0 {
+		cursor, _, backward, err := s.decodeCursor(after, "argument "+graphQLArgumentAfter, filterHash, maxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if backward {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "argument %s has to be the endCursor of a page", graphQLArgumentAfter)
+		}
+		filters = append(filters, q.cursor(cursor))
+	}
+
+	var result *indexer.IndexerResult
+	if expr != nil {
+		result = q.query(p.Context, expr, filters...)
+	} else {
+		result = q.queryWithFilters(p.Context, filters...)
+	}
+	if result.Error != nil {
+		return nil, errorFromResult(result)
+	}
+
+	var endCursor *string
+	if result.Cursor != nil {
+		cursor, err := s.encodeCursorWithFilterHash(filterHash, *result.Cursor, uint32(pageSize), false)
+		if err != nil {
+			return nil, err
+		}
+		endCursor = &cursor
+	}
+
+	resultNodes, err := nodes(p.Context, result.OutputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"ledgerIndex": result.LedgerIndex,
+		"nodes":       resultNodes,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": endCursor != nil,
+			"endCursor":   endCursor,
+		},
+	}, nil
+}
+
+func (s *IndexerServer) graphQLNodes(_ context.Context, outputIDs iotago.OutputIDs) ([]*graphQLNode, error) {
+	nodes := make([]*graphQLNode, 0, len(outputIDs))
+	for _, outputID := range outputIDs {
+		nodes = append(nodes, &graphQLNode{OutputID: outputID})
+	}
+
+	return nodes, nil
+}
+
+func (s *IndexerServer) graphQLAliasNodes(ctx context.Context, outputIDs iotago.OutputIDs) ([]*graphQLNode, error) {
+	aliasIDs, err := s.Indexer.AliasIDs(ctx, outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*graphQLNode, 0, len(outputIDs))
+	for _, outputID := range outputIDs {
+		node := &graphQLNode{OutputID: outputID}
+		if aliasID, ok := aliasIDs[outputID]; ok {
+			node.ID = aliasID[:]
+			node.Address = aliasID.ToAddress()
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func (s *IndexerServer) graphQLNFTNodes(ctx context.Context, outputIDs iotago.OutputIDs) ([]*graphQLNode, error) {
+	nftIDs, err := s.Indexer.NFTIDs(ctx, outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*graphQLNode, 0, len(outputIDs))
+	for _, outputID := range outputIDs {
+		node := &graphQLNode{OutputID: outputID}
+		if nftID, ok := nftIDs[outputID]; ok {
+			node.ID = nftID[:]
+			node.Address = nftID.ToAddress()
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func (s *IndexerServer) graphQLFoundryNodes(ctx context.Context, outputIDs iotago.OutputIDs) ([]*graphQLNode, error) {
+	foundryIDs, err := s.Indexer.FoundryIDs(ctx, outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*graphQLNode, 0, len(outputIDs))
+	for _, outputID := range outputIDs {
+		node := &graphQLNode{OutputID: outputID}
+		if foundryID, ok := foundryIDs[outputID]; ok {
+			node.ID = foundryID[:]
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
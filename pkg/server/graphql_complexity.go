@@ -0,0 +1,192 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// graphQLComplexityWalker calculates the complexity of a GraphQL operation.
+type graphQLComplexityWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// maxPageSize is used for connections where the amount of items can not be determined before the execution.
+	maxPageSize int
+	// maxComplexity is the limit of the complexity, the walk stops as soon as it is exceeded.
+	maxComplexity int
+	// visiting contains the fragments on the current path, to stop at cyclic fragments.
+	visiting map[string]bool
+}
+
+// graphQLQueryComplexity returns the complexity and the depth of the operation that is executed for the request.
+// Every field counts one, the fields below a connection count once for every requested item.
+// Nested connections multiply quickly, so the complexity is capped at limit+1 and the walk stops once
+// the limit is exceeded. The depth is incomplete in that case, but the query is rejected anyway.
+// The query is not validated yet, so it might still be rejected during the execution.
+func graphQLQueryComplexity(document *ast.Document, operationName string, variables map[string]interface{}, maxPageSize int, limit int) (int, int) {
+	walker := &graphQLComplexityWalker{
+		fragments:     make(map[string]*ast.FragmentDefinition),
+		variables:     variables,
+		maxPageSize:   maxPageSize,
+		maxComplexity: limit,
+		visiting:      make(map[string]bool),
+	}
+
+	var operations []*ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch def := definition.(type) {
+		case *ast.FragmentDefinition:
+			walker.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operations = append(operations, def)
+			}
+		}
+	}
+
+	// without an operation name only a single operation is executed, but we take the most expensive one to be safe
+	maxComplexity, maxDepth := 0, 0
+	for _, operation := range operations {
+		complexity, depth := walker.selectionSet(operation.SelectionSet)
+		if complexity > maxComplexity {
+			maxComplexity = complexity
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		if walker.exceeded(maxComplexity) {
+			break
+		}
+	}
+
+	return maxComplexity, maxDepth
+}
+
+// exceeded checks whether the complexity is above the limit.
+func (w *graphQLComplexityWalker) exceeded(complexity int) bool {
+	return complexity > w.maxComplexity
+}
+
+// add returns the sum of the complexities, capped at the first value above the limit.
+func (w *graphQLComplexityWalker) add(a int, b int) int {
+	if w.exceeded(a) || w.exceeded(b) || a > w.maxComplexity-b {
+		return w.maxComplexity + 1
+	}
+
+	return a + b
+}
+
+// multiply returns the product of the complexities, capped at the first value above the limit.
+func (w *graphQLComplexityWalker) multiply(a int, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if w.exceeded(a) || w.exceeded(b) || a > w.maxComplexity/b {
+		return w.maxComplexity + 1
+	}
+
+	return a * b
+}
+
+func (w *graphQLComplexityWalker) selectionSet(selectionSet *ast.SelectionSet) (int, int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+
+	complexity, depth := 0, 0
+	for _, selection := range selectionSet.Selections {
+		var selectionComplexity, selectionDepth int
+
+		switch sel := selection.(type) {
+		case *ast.Field:
+			childComplexity, childDepth := w.selectionSet(sel.SelectionSet)
+			selectionComplexity = w.add(1, w.multiply(w.fieldMultiplier(sel), childComplexity))
+			selectionDepth = 1 + childDepth
+
+		case *ast.InlineFragment:
+			selectionComplexity, selectionDepth = w.selectionSet(sel.SelectionSet)
+
+		case *ast.FragmentSpread:
+			fragment, ok := w.fragments[sel.Name.Value]
+			if !ok || w.visiting[sel.Name.Value] {
+				continue
+			}
+			w.visiting[sel.Name.Value] = true
+			selectionComplexity, selectionDepth = w.selectionSet(fragment.SelectionSet)
+			delete(w.visiting, sel.Name.Value)
+		}
+
+		complexity = w.add(complexity, selectionComplexity)
+		if selectionDepth > depth {
+			depth = selectionDepth
+		}
+		if w.exceeded(complexity) {
+			break
+		}
+	}
+
+	return complexity, depth
+}
+
+// fieldMultiplier returns the amount of items a connection field returns, or 1 for all other fields.
+func (w *graphQLComplexityWalker) fieldMultiplier(field *ast.Field) int {
+	isConnection := false
+	first := GraphQLDefaultPageSize
+
+	for _, argument := range field.Arguments {
+		switch argument.Name.Value {
+		case graphQLArgumentFilter, graphQLArgumentAfter:
+			isConnection = true
+
+		case graphQLArgumentFirst:
+			isConnection = true
+			first = w.maxPageSize
+			if value, ok := w.intValue(argument.Value); ok && value > 0 && value < w.maxPageSize {
+				first = value
+			}
+		}
+	}
+
+	switch field.Name.Value {
+	case "basicOutputs", "aliases", "nfts", "foundries":
+		isConnection = true
+	}
+
+	if !isConnection {
+		return 1
+	}
+
+	return first
+}
+
+func (w *graphQLComplexityWalker) intValue(value ast.Value) (int, bool) {
+	var raw string
+
+	switch v := value.(type) {
+	case *ast.IntValue:
+		raw = v.Value
+
+	case *ast.Variable:
+		variable, ok := w.variables[v.Name.Value]
+		if !ok {
+			return 0, false
+		}
+
+		// the variables are decoded without UseNumber
+		number, ok := variable.(float64)
+		if !ok {
+			return 0, false
+		}
+		raw = strconv.FormatFloat(number, 'f', -1, 64)
+
+	default:
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestGraphQLQueryComplexity(t *testing.T) {
+	const (
+		maxPageSize = 1000
+		limit       = 5000
+	)
+
+	// nestedConnections returns a query with the given amount of nested connections that request maxPageSize items each.
+	nestedConnections := func(levels int) string {
+		return "{ address(bech32: \"rms1\") { " +
+			strings.Repeat("nfts(first: 1000) { nodes { ", levels) +
+			"outputId" +
+			strings.Repeat(" } }", levels) +
+			" } }"
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		complexity    int
+		depth         int
+	}{
+		{
+			name:       "single field",
+			query:      `{ nft(nftId: "0x01") { outputId } }`,
+			complexity: 2,
+			depth:      2,
+		},
+		{
+			name:       "connection with the default page size",
+			query:      `{ basicOutputs { nodes { outputId } } }`,
+			complexity: 1 + GraphQLDefaultPageSize*2,
+			depth:      3,
+		},
+		{
+			name:       "connection with first",
+			query:      `{ basicOutputs(first: 20) { ledgerIndex nodes { outputId } } }`,
+			complexity: 1 + 20*3,
+			depth:      3,
+		},
+		{
+			name:       "first above the max page size",
+			query:      `{ basicOutputs(first: 5000) { nodes { outputId } } }`,
+			complexity: 1 + maxPageSize*2,
+			depth:      3,
+		},
+		{
+			name:       "first of a variable",
+			query:      `query q($first: Int) { basicOutputs(first: $first) { nodes { outputId } } }`,
+			variables:  map[string]interface{}{"first": float64(5)},
+			complexity: 1 + 5*2,
+			depth:      3,
+		},
+		{
+			name:       "nested connections",
+			query:      `{ alias(aliasId: "0x01") { foundries(first: 5) { nodes { outputId } } nfts(first: 2) { nodes { nftId } } } }`,
+			complexity: 1 + (1 + 5*2) + (1 + 2*2),
+			depth:      4,
+		},
+		{
+			name:       "fragments",
+			query:      `query { basicOutputs(first: 3) { ...page } } fragment page on BasicOutputConnection { nodes { outputId } }`,
+			complexity: 1 + 3*2,
+			depth:      3,
+		},
+		{
+			name:       "cyclic fragments",
+			query:      `query { nft(nftId: "0x01") { ...a } } fragment a on NFT { outputId ...b } fragment b on NFT { nftId ...a }`,
+			complexity: 3,
+			depth:      2,
+		},
+		{
+			name:          "most expensive operation",
+			query:         `query a { nft(nftId: "0x01") { outputId } } query b { basicOutputs(first: 4) { nodes { outputId } } }`,
+			operationName: "",
+			complexity:    1 + 4*2,
+			depth:         3,
+		},
+		{
+			name:          "named operation",
+			query:         `query a { nft(nftId: "0x01") { outputId } } query b { basicOutputs(first: 4) { nodes { outputId } } }`,
+			operationName: "a",
+			complexity:    2,
+			depth:         2,
+		},
+		{
+			// 1000^10 overflows an int, the complexity has to stay above the limit instead of wrapping around
+			name:       "nested connections that overflow",
+			query:      nestedConnections(10),
+			complexity: limit + 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			document, err := parser.Parse(parser.ParseParams{Source: test.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			complexity, depth := graphQLQueryComplexity(document, test.operationName, test.variables, maxPageSize, limit)
+			if complexity != test.complexity {
+				t.Errorf("expected complexity %d, got %d", test.complexity, complexity)
+			}
+			// the walk stops early once the limit is exceeded, so the depth is incomplete
+			if test.depth != 0 && depth != test.depth {
+				t.Errorf("expected depth %d, got %d", test.depth, depth)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package server
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const graphQLTestHRP = iotago.PrefixTestnet
+
+var (
+	// graphQLTestOwner owns the alias and the basic outputs.
+	graphQLTestOwner = &iotago.Ed25519Address{0x1}
+	graphQLTestOther = &iotago.Ed25519Address{0x2}
+	graphQLTestAlias = iotago.AliasID{0xa1}
+)
+
+// graphQLTestOutputs returns an alias of the owner, which controls two foundries and owns three NFTs,
+// an NFT of another address and five basic outputs of the owner.
+func graphQLTestOutputs() map[iotago.OutputID]iotago.Output {
+	//nolint:forcetypeassert // the address of an alias ID is an alias address
+	aliasAddress := graphQLTestAlias.ToAddress().(*iotago.AliasAddress)
+
+	foundry := func(serialNumber uint32) iotago.Output {
+		return &iotago.FoundryOutput{
+			Amount:       1_000_000,
+			SerialNumber: serialNumber,
+			TokenScheme: &iotago.SimpleTokenScheme{
+				MintedTokens:  big.NewInt(10),
+				MeltedTokens:  big.NewInt(0),
+				MaximumSupply: big.NewInt(100),
+			},
+			Conditions: iotago.UnlockConditions{&iotago.ImmutableAliasUnlockCondition{Address: aliasAddress}},
+		}
+	}
+
+	nft := func(id byte, owner iotago.Address) iotago.Output {
+		return &iotago.NFTOutput{
+			Amount:     1_000_000,
+			NFTID:      iotago.NFTID{id},
+			Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: owner}},
+		}
+	}
+
+	outputs := map[iotago.OutputID]iotago.Output{
+		{0x10}: &iotago.AliasOutput{
+			Amount:         1_000_000,
+			AliasID:        graphQLTestAlias,
+			FoundryCounter: 2,
+			Conditions: iotago.UnlockConditions{
+				&iotago.StateControllerAddressUnlockCondition{Address: graphQLTestOwner},
+				&iotago.GovernorAddressUnlockCondition{Address: graphQLTestOwner},
+			},
+		},
+		{0x20}: foundry(1),
+		{0x21}: foundry(2),
+		{0x30}: nft(0x31, aliasAddress),
+		{0x32}: nft(0x33, aliasAddress),
+		{0x34}: nft(0x35, aliasAddress),
+		{0x36}: nft(0x37, graphQLTestOther),
+	}
+
+	for i := byte(0); i < 5; i++ {
+		outputs[iotago.OutputID{0x40 + i}] = &iotago.BasicOutput{
+			Amount:     1_000_000,
+			Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: graphQLTestOwner}},
+		}
+	}
+
+	return outputs
+}
+
+func newGraphQLTestServer(t *testing.T, maxDepth int, maxComplexity int) *echo.Echo {
+	t.Helper()
+
+	s := &IndexerServer{
+		Indexer:                 newTestIndexerWithOutputs(t, 10, graphQLTestOutputs()),
+		Bech32HRP:               graphQLTestHRP,
+		RestAPILimitsMaxResults: 1000,
+		cursorSigningKey:        []byte("key"),
+		graphQLMaxDepth:         maxDepth,
+		graphQLMaxComplexity:    maxComplexity,
+	}
+
+	e := echo.New()
+	if err := s.configureGraphQLRoutes(e.Group("")); err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+type graphQLTestResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (r *graphQLTestResponse) errorMessages() string {
+	messages := make([]string, 0, len(r.Errors))
+	for _, err := range r.Errors {
+		messages = append(messages, err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func graphQLTestQuery(t *testing.T, e *echo.Echo, query string, variables map[string]interface{}) (int, *graphQLTestResponse) {
+	t.Helper()
+
+	body, err := json.Marshal(&graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, RouteGraphQL, strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	response := &graphQLTestResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+		t.Fatalf("invalid response %q: %s", rec.Body.String(), err)
+	}
+
+	return rec.Code, response
+}
+
+// graphQLTestPath returns the value at the path of a response, the numbers select an item of a list.
+func graphQLTestPath(t *testing.T, value interface{}, path ...interface{}) interface{} {
+	t.Helper()
+
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected an object at %v, got %v", key, value)
+			}
+			value = object[k]
+		case int:
+			list, ok := value.([]interface{})
+			if !ok || k >= len(list) {
+				t.Fatalf("expected a list with item %d, got %v", k, value)
+			}
+			value = list[k]
+		}
+	}
+
+	return value
+}
+
+// graphQLTestNodeValues returns the sorted values of a field of the nodes of a connection.
+func graphQLTestNodeValues(t *testing.T, connection interface{}, field string) []string {
+	t.Helper()
+
+	nodes, ok := graphQLTestPath(t, connection, "nodes").([]interface{})
+	if !ok {
+		t.Fatalf("expected the nodes of a connection, got %v", connection)
+	}
+
+	values := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		value, _ := graphQLTestPath(t, node, field).(string)
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	return values
+}
+
+func TestGraphQLNestedResolution(t *testing.T) {
+	e := newGraphQLTestServer(t, 10, 5000)
+
+	aliasAddress := graphQLTestAlias.ToAddress()
+
+	code, response := graphQLTestQuery(t, e, `query($address: String!) {
+		address(bech32: $address) {
+			bech32
+			aliases {
+				ledgerIndex
+				nodes {
+					aliasId
+					address
+					foundries { nodes { foundryId } }
+					nfts { nodes { nftId address } }
+				}
+			}
+			nfts { nodes { nftId } }
+		}
+	}`, map[string]interface{}{"address": graphQLTestOwner.Bech32(graphQLTestHRP)})
+	if code != http.StatusOK || len(response.Errors) > 0 {
+		t.Fatalf("expected a successful query, got %d: %s", code, response.errorMessages())
+	}
+
+	address := graphQLTestPath(t, response.Data, "address")
+	aliases := graphQLTestPath(t, address, "aliases")
+	if ledgerIndex := graphQLTestPath(t, aliases, "ledgerIndex"); ledgerIndex != float64(10) {
+		t.Errorf("expected ledger index 10, got %v", ledgerIndex)
+	}
+	if aliasIDs := graphQLTestNodeValues(t, aliases, "aliasId"); !reflect.DeepEqual(aliasIDs, []string{iotago.EncodeHex(graphQLTestAlias[:])}) {
+		t.Fatalf("expected the alias of the owner, got %v", aliasIDs)
+	}
+
+	alias := graphQLTestPath(t, aliases, "nodes", 0)
+	if bech32 := graphQLTestPath(t, alias, "address"); bech32 != aliasAddress.Bech32(graphQLTestHRP) {
+		t.Errorf("expected the alias address %s, got %v", aliasAddress.Bech32(graphQLTestHRP), bech32)
+	}
+
+	outputs := graphQLTestOutputs()
+	var foundryIDs []string
+	for _, outputID := range []iotago.OutputID{{0x20}, {0x21}} {
+		//nolint:forcetypeassert // the test outputs are known
+		foundryID, err := outputs[outputID].(*iotago.FoundryOutput).ID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		foundryIDs = append(foundryIDs, iotago.EncodeHex(foundryID[:]))
+	}
+	sort.Strings(foundryIDs)
+	if ids := graphQLTestNodeValues(t, graphQLTestPath(t, alias, "foundries"), "foundryId"); !reflect.DeepEqual(ids, foundryIDs) {
+		t.Errorf("expected the foundries %v of the alias, got %v", foundryIDs, ids)
+	}
+
+	var nftIDs []string
+	for _, id := range []byte{0x31, 0x33, 0x35} {
+		nftID := iotago.NFTID{id}
+		nftIDs = append(nftIDs, iotago.EncodeHex(nftID[:]))
+	}
+	if ids := graphQLTestNodeValues(t, graphQLTestPath(t, alias, "nfts"), "nftId"); !reflect.DeepEqual(ids, nftIDs) {
+		t.Errorf("expected the NFTs %v owned by the alias, got %v", nftIDs, ids)
+	}
+
+	// the owner itself holds no NFTs, they are owned by its alias
+	if ids := graphQLTestNodeValues(t, graphQLTestPath(t, address, "nfts"), "nftId"); len(ids) != 0 {
+		t.Errorf("expected no NFTs of the owner, got %v", ids)
+	}
+}
+
+func TestGraphQLConnectionPages(t *testing.T) {
+	e := newGraphQLTestServer(t, 10, 5000)
+
+	const query = `query($address: String!, $after: String) {
+		address(bech32: $address) {
+			basicOutputs(first: 2, after: $after) {
+				nodes { outputId }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	var outputIDs []string
+	var after interface{}
+	var cursors []string
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("expected the pages to end")
+		}
+
+		code, response := graphQLTestQuery(t, e, query, map[string]interface{}{"address": graphQLTestOwner.Bech32(graphQLTestHRP), "after": after})
+		if code != http.StatusOK || len(response.Errors) > 0 {
+			t.Fatalf("expected a successful query, got %d: %s", code, response.errorMessages())
+		}
+
+		connection := graphQLTestPath(t, response.Data, "address", "basicOutputs")
+		pageOutputIDs := graphQLTestNodeValues(t, connection, "outputId")
+		if len(pageOutputIDs) > 2 {
+			t.Fatalf("expected at most 2 outputs per page, got %d", len(pageOutputIDs))
+		}
+		outputIDs = append(outputIDs, pageOutputIDs...)
+
+		if hasNextPage := graphQLTestPath(t, connection, "pageInfo", "hasNextPage"); hasNextPage != true {
+			break
+		}
+		endCursor, ok := graphQLTestPath(t, connection, "pageInfo", "endCursor").(string)
+		if !ok {
+			t.Fatal("expected an end cursor if there is a next page")
+		}
+		after = endCursor
+		cursors = append(cursors, endCursor)
+	}
+
+	var expected []string
+	for i := byte(0); i < 5; i++ {
+		expected = append(expected, iotago.OutputID{0x40 + i}.ToHex())
+	}
+	sort.Strings(outputIDs)
+	if !reflect.DeepEqual(outputIDs, expected) {
+		t.Errorf("expected all outputs of the owner once, got %v", outputIDs)
+	}
+	if len(cursors) == 0 {
+		t.Fatal("expected more than one page")
+	}
+
+	// the cursors are bound to the connection, its parent and its filter
+	rejected := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+	}{
+		{
+			name:      "other parent",
+			query:     query,
+			variables: map[string]interface{}{"address": graphQLTestOther.Bech32(graphQLTestHRP), "after": cursors[0]},
+		},
+		{
+			name:      "top level connection",
+			query:     `query($after: String) { basicOutputs(first: 2, after: $after) { nodes { outputId } } }`,
+			variables: map[string]interface{}{"after": cursors[0]},
+		},
+		{
+			name: "other filter",
+			query: `query($address: String!, $after: String) {
+				address(bech32: $address) { basicOutputs(first: 2, after: $after, filter: {hasNativeTokens: false}) { nodes { outputId } } }
+			}`,
+			variables: map[string]interface{}{"address": graphQLTestOwner.Bech32(graphQLTestHRP), "after": cursors[0]},
+		},
+		{
+			name: "other connection",
+			query: `query($address: String!, $after: String) {
+				address(bech32: $address) { nfts(first: 2, after: $after) { nodes { outputId } } }
+			}`,
+			variables: map[string]interface{}{"address": graphQLTestOwner.Bech32(graphQLTestHRP), "after": cursors[0]},
+		},
+		{
+			name:      "tampered cursor",
+			query:     query,
+			variables: map[string]interface{}{"address": graphQLTestOwner.Bech32(graphQLTestHRP), "after": cursors[0][:len(cursors[0])-2] + "AA"},
+		},
+	}
+
+	for _, test := range rejected {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, response := graphQLTestQuery(t, e, test.query, test.variables)
+			if !strings.Contains(response.errorMessages(), "argument after") {
+				t.Errorf("expected the cursor to be rejected, got %q", response.errorMessages())
+			}
+		})
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	e := newGraphQLTestServer(t, 4, 100)
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{
+			name:  "within the limits",
+			query: `{ basicOutputs(first: 5) { nodes { outputId } } }`,
+		},
+		{
+			name:    "too deep",
+			query:   `{ address(bech32: "rms1") { aliases(first: 1) { nodes { nfts(first: 1) { nodes { outputId } } } } } }`,
+			message: "query is too deep",
+		},
+		{
+			name:    "too complex",
+			query:   `{ basicOutputs(first: 60) { nodes { outputId } } }`,
+			message: "query is too complex",
+		},
+		{
+			name:    "too complex without first",
+			query:   `{ a: basicOutputs { nodes { outputId } } b: nfts { nodes { outputId } } c: aliases { nodes { outputId } } d: foundries { nodes { outputId } } e: basicOutputs { nodes { outputId } } f: nfts { nodes { outputId } } }`,
+			message: "query is too complex",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			code, response := graphQLTestQuery(t, e, test.query, nil)
+
+			if test.message == "" {
+				if code != http.StatusOK || len(response.Errors) > 0 {
+					t.Fatalf("expected a successful query, got %d: %s", code, response.errorMessages())
+				}
+
+				return
+			}
+
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+			}
+			if !strings.Contains(response.errorMessages(), test.message) {
+				t.Errorf("expected %q, got %q", test.message, response.errorMessages())
+			}
+			if response.Data != nil {
+				t.Error("expected the rejected query not to be executed")
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const (
+	// RouteHealth is the route for the liveness of the indexer.
+	// GET returns 200 as long as the API server is running, also while the initial ledger is imported.
+	RouteHealth = "/health"
+
+	// RouteReady is the route for the readiness of the indexer.
+	// GET returns 200 if the initial ledger was imported and the ledger index does not lag behind the
+	// confirmed milestone of the node by more than the maximum lag, otherwise 503.
+	RouteReady = "/ready"
+
+	// RouteIndexerStatus is the route for the sync status of the indexer.
+	// GET returns the status of the database, the confirmed milestone index of the node,
+	// the lag behind it and the progress of the import of the initial ledger.
+	RouteIndexerStatus = "/indexer/status"
+)
+
+var (
+	// ErrNotInitialized is returned by the output routes while the initial ledger is imported.
+	ErrNotInitialized = echo.NewHTTPError(http.StatusServiceUnavailable, "indexer is importing the initial ledger")
+)
+
+// importProgressResponse defines the progress of the import of the initial ledger.
+type importProgressResponse struct {
+	// Whether the import is running.
+	Running bool `json:"running"`
+	// The amount of outputs received from the node.
+	OutputsReceived uint64 `json:"outputsReceived"`
+	// The amount of outputs inserted into the database.
+	OutputsInserted uint64 `json:"outputsInserted"`
+}
+
+// indexerStatusResponse defines the response of a GET indexer status REST API call.
+type indexerStatusResponse struct {
+	// Whether the initial ledger was imported.
+	Initialized bool `json:"initialized"`
+	// Whether the indexer is ready to answer queries.
+	Ready bool `json:"ready"`
+	// The latest ledger index written to the database.
+	LedgerIndex uint32 `json:"ledgerIndex"`
+	// The protocol version of the indexed network.
+	ProtocolVersion byte `json:"protocolVersion,omitempty"`
+	// The name of the indexed network.
+	NetworkName string `json:"networkName,omitempty"`
+	// The version of the database schema.
+	DatabaseVersion uint32 `json:"databaseVersion,omitempty"`
+	// The index of the confirmed milestone of the node.
+	ConfirmedMilestoneIndex uint32 `json:"confirmedMilestoneIndex"`
+	// The amount of milestones the ledger index lags behind the confirmed milestone of the node.
+	Lag uint32 `json:"lag"`
+	// The maximum lag before the indexer is not ready anymore.
+	MaxLag uint32 `json:"maxLag"`
+	// The progress of the running or the last import of the initial ledger.
+	Import importProgressResponse `json:"import"`
+}
+
+// MarkInitialized is called once the initial ledger was imported, it enables the output routes.
+func (s *IndexerServer) MarkInitialized() {
+	s.initialized.Store(true)
+}
+
+// Initialized returns whether MarkInitialized was called.
+func (s *IndexerServer) Initialized() bool {
+	return s.initialized.Load()
+}
+
+// requireInitialized is a middleware that rejects requests while the initial ledger is imported.
+func (s *IndexerServer) requireInitialized(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !s.initialized.Load() {
+			return ErrNotInitialized
+		}
+
+		return next(c)
+	}
+}
+
+// lag returns the confirmed milestone index of the node and the amount of milestones the ledger index lags behind it.
+func (s *IndexerServer) lag() (uint32, uint32) {
+	if s.confirmedMilestoneIndex == nil {
+		return 0, 0
+	}
+
+	confirmedIndex := s.confirmedMilestoneIndex()
+	ledgerIndex := s.Indexer.LedgerIndex()
+	if ledgerIndex >= confirmedIndex {
+		return confirmedIndex, 0
+	}
+
+	return confirmedIndex, confirmedIndex - ledgerIndex
+}
+
+// ready returns nil if the indexer is ready to answer queries, otherwise the reason.
+func (s *IndexerServer) ready() error {
+	if !s.initialized.Load() {
+		return ErrNotInitialized
+	}
+
+	if _, lag := s.lag(); lag > s.maxReadyLag {
+		return errors.WithMessagef(echo.ErrServiceUnavailable, "indexer lags %d milestones behind the node, max. %d", lag, s.maxReadyLag)
+	}
+
+	return nil
+}
+
+func (s *IndexerServer) indexerStatus(c echo.Context) (*indexerStatusResponse, error) {
+	confirmedIndex, lag := s.lag()
+	progress := s.Indexer.ImportProgress()
+
+	resp := &indexerStatusResponse{
+		Initialized:             s.initialized.Load(),
+		Ready:                   s.ready() == nil,
+		LedgerIndex:             s.Indexer.LedgerIndex(),
+		ConfirmedMilestoneIndex: confirmedIndex,
+		Lag:                     lag,
+		MaxLag:                  s.maxReadyLag,
+		Import: importProgressResponse{
+			Running:         progress.Running,
+			OutputsReceived: progress.OutputsAdded,
+			OutputsInserted: progress.OutputsInserted,
+		},
+	}
+
+	// the status does not exist yet while the initial ledger is imported
+	status, err := s.Indexer.ReadStatus(c.Request().Context())
+	if err != nil && !errors.Is(err, indexer.ErrNotFound) {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading status failed: %s", err)
+	}
+	if status != nil {
+		resp.ProtocolVersion = status.ProtocolVersion
+		resp.NetworkName = status.NetworkName
+		resp.DatabaseVersion = status.DatabaseVersion
+	}
+
+	return resp, nil
+}
+
+func (s *IndexerServer) configureHealthRoutes(routeGroup *echo.Group) {
+
+	routeGroup.GET(RouteHealth, func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	routeGroup.GET(RouteReady, func(c echo.Context) error {
+		if err := s.ready(); err != nil {
+			return err
+		}
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	routeGroup.GET(RouteIndexerStatus, func(c echo.Context) error {
+		resp, err := s.indexerStatus(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+}
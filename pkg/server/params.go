@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// MaxFilterValuesPerParameter is the maximum amount of values that can be given for a single filter parameter.
+	MaxFilterValuesPerParameter = 128
+
+	// maxFilterBodySize is the maximum size of a request body that contains filter parameters.
+	maxFilterBodySize = 1 << 20
+)
+
+// filterQueryParamValues returns all values of a query parameter that can be given multiple times.
+func filterQueryParamValues(c echo.Context, paramName string) ([]string, error) {
+	values := c.QueryParams()[paramName]
+	if len(values) > MaxFilterValuesPerParameter {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "query parameter %s given too often, max. %d values but got %d", paramName, MaxFilterValuesPerParameter, len(values))
+	}
+
+	return values, nil
+}
+
+func (s *IndexerServer) parseBech32AddressesQueryParam(c echo.Context, paramName string) ([]iotago.Address, error) {
+	values, err := filterQueryParamValues(c, paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]iotago.Address, 0, len(values))
+	for _, value := range values {
+		address, err := s.parseBech32Address(value)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+func (s *IndexerServer) parseBech32AddressParam(c echo.Context, paramName string) (iotago.Address, error) {
+	return s.parseBech32Address(c.Param(paramName))
+}
+
+func (s *IndexerServer) parseBech32Address(value string) (iotago.Address, error) {
+	addressParam := strings.ToLower(value)
+
+	hrp, address, err := iotago.ParseBech32(addressParam)
+	if err != nil {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid address: %s, error: %s", addressParam, err)
+	}
+
+	if hrp != s.Bech32HRP {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid bech32 address, expected prefix: %s", s.Bech32HRP)
+	}
+
+	return address, nil
+}
+
+func parseHexQueryParams(c echo.Context, paramName string, maxLen int) ([][]byte, error) {
+	values, err := filterQueryParamValues(c, paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]byte, 0, len(values))
+	for _, value := range values {
+		paramBytes, err := iotago.DecodeHex(value)
+		if err != nil {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid param: %s, error: %s", paramName, err)
+		}
+		if len(paramBytes) > maxLen {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "query parameter %s too long, max. %d bytes but is %d", paramName, maxLen, len(paramBytes))
+		}
+		result = append(result, paramBytes)
+	}
+
+	return result, nil
+}
+
+func parseUTF8QueryParams(c echo.Context, paramName string, maxLen int) ([]string, error) {
+	values, err := filterQueryParamValues(c, paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		if !utf8.ValidString(value) {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "query parameter %s is not valid UTF-8", paramName)
+		}
+		if len(value) > maxLen {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "query parameter %s too long, max. %d bytes but is %d", paramName, maxLen, len(value))
+		}
+	}
+
+	return values, nil
+}
+
+// bodyToQueryParams is a middleware that merges the filter parameters given in the body of a POST request into the query parameters.
+// This allows to pass more filter values than fit into a URL.
+// The body is either form encoded or a JSON object, where each value is a string, number, boolean or an array of these.
+func bodyToQueryParams(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		req.Body = http.MaxBytesReader(c.Response(), req.Body, maxFilterBodySize)
+
+		var bodyParams map[string][]string
+
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+		switch mediaType {
+		case echo.MIMEApplicationJSON:
+			params, err := jsonBodyParams(req.Body)
+			if err != nil {
+				return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request body, error: %s", err)
+			}
+			bodyParams = params
+
+		case echo.MIMEApplicationForm:
+			if err := req.ParseForm(); err != nil {
+				return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request body, error: %s", err)
+			}
+			bodyParams = req.PostForm
+
+		default:
+			return echo.ErrUnsupportedMediaType
+		}
+
+		query := req.URL.Query()
+		for key, values := range bodyParams {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+
+		return next(c)
+	}
+}
+
+func jsonBodyParams(body io.Reader) (map[string][]string, error) {
+	var rawParams map[string]interface{}
+
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&rawParams); err != nil {
+		return nil, err
+	}
+
+	params := make(map[string][]string, len(rawParams))
+	for key, value := range rawParams {
+		values, isList := value.([]interface{})
+		if !isList {
+			values = []interface{}{value}
+		}
+
+		for _, v := range values {
+			switch v.(type) {
+			case string, json.Number, bool:
+				params[key] = append(params[key], fmt.Sprint(v))
+			default:
+				return nil, errors.Errorf("unsupported value for parameter %s", key)
+			}
+		}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,469 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// MaxFilterExpressionDepth is the maximum nesting depth of a filter expression.
+	MaxFilterExpressionDepth = 16
+
+	// MaxFilterExpressionNodes is the maximum amount of operators and filters in a filter expression.
+	MaxFilterExpressionNodes = 256
+
+	filterExpressionAnd = "and"
+	filterExpressionOr  = "or"
+	filterExpressionNot = "not"
+
+	// contextKeyFilterExpression holds the canonical JSON of the filter expression of a query request.
+	contextKeyFilterExpression = "filterExpression"
+)
+
+// outputsQueryRequest is the body of a query request.
+type outputsQueryRequest struct {
+	// Filter is a tree of filters, combined with "and", "or" and "not".
+	Filter interface{} `json:"filter"`
+}
+
+// queryPredicate parses the value of a filter in a filter expression.
+type queryPredicate[T any] func(s *IndexerServer, name string, value interface{}) ([]T, error)
+
+// outputsQuery describes the filter expression queries of an output type.
+type outputsQuery[T any] struct {
+	// predicates are the filters allowed in the filter expression.
+	predicates map[string]queryPredicate[T]
+	pageSize   func(uint32) T
+	cursor     func(string) T
+	prevCursor func(string) T
+	query      func(context.Context, *indexer.FilterExpression[T], ...T) *indexer.IndexerResult
+	// queryWithFilters is used if no filter expression is given.
+	queryWithFilters func(context.Context, ...T) *indexer.IndexerResult
+}
+
+func (s *IndexerServer) basicOutputsQuery() *outputsQuery[indexer.BasicOutputFilterOption] {
+	return &outputsQuery[indexer.BasicOutputFilterOption]{
+		predicates: map[string]queryPredicate[indexer.BasicOutputFilterOption]{
+			QueryParameterHasNativeTokens:             boolPredicate(indexer.BasicOutputHasNativeTokens),
+			QueryParameterMinNativeTokenCount:         uint32Predicate(indexer.BasicOutputMinNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterMaxNativeTokenCount:         uint32Predicate(indexer.BasicOutputMaxNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterAddress:                     addressesPredicate(indexer.BasicOutputUnlockableByAddress),
+			QueryParameterNotAddress:                  addressesPredicate(indexer.BasicOutputNotUnlockableByAddress),
+			QueryParameterHasStorageDepositReturn:     boolPredicate(indexer.BasicOutputHasStorageDepositReturnCondition),
+			QueryParameterStorageDepositReturnAddress: addressPredicate(indexer.BasicOutputStorageDepositReturnAddress),
+			QueryParameterHasExpiration:               boolPredicate(indexer.BasicOutputHasExpirationCondition),
+			QueryParameterExpiresBefore:               timestampPredicate(indexer.BasicOutputExpiresBefore),
+			QueryParameterExpiresAfter:                timestampPredicate(indexer.BasicOutputExpiresAfter),
+			QueryParameterExpirationReturnAddress:     addressPredicate(indexer.BasicOutputExpirationReturnAddress),
+			QueryParameterHasTimelock:                 boolPredicate(indexer.BasicOutputHasTimelockCondition),
+			QueryParameterTimelockedBefore:            timestampPredicate(indexer.BasicOutputTimelockedBefore),
+			QueryParameterTimelockedAfter:             timestampPredicate(indexer.BasicOutputTimelockedAfter),
+			QueryParameterSender:                      addressesPredicate(indexer.BasicOutputSender),
+			QueryParameterNotSender:                   addressesPredicate(indexer.BasicOutputNotSender),
+			QueryParameterTag:                         hexPredicate(indexer.BasicOutputTag, iotago.MaxTagLength),
+			QueryParameterNotTag:                      hexPredicate(indexer.BasicOutputNotTag, iotago.MaxTagLength),
+			QueryParameterTagPrefix:                   hexPredicate(indexer.BasicOutputTagPrefix, iotago.MaxTagLength),
+			QueryParameterTagContains:                 utf8Predicate(indexer.BasicOutputTagContains, iotago.MaxTagLength),
+			QueryParameterCreatedBefore:               timestampPredicate(indexer.BasicOutputCreatedBefore),
+			QueryParameterCreatedAfter:                timestampPredicate(indexer.BasicOutputCreatedAfter),
+		},
+		pageSize:         indexer.BasicOutputPageSize,
+		cursor:           indexer.BasicOutputCursor,
+		prevCursor:       indexer.BasicOutputPrevCursor,
+		query:            s.Indexer.BasicOutputsWithFilterExpression,
+		queryWithFilters: s.Indexer.BasicOutputsWithFilters,
+	}
+}
+
+func (s *IndexerServer) aliasesQuery() *outputsQuery[indexer.AliasFilterOption] {
+	return &outputsQuery[indexer.AliasFilterOption]{
+		predicates: map[string]queryPredicate[indexer.AliasFilterOption]{
+			QueryParameterHasNativeTokens:     boolPredicate(indexer.AliasHasNativeTokens),
+			QueryParameterMinNativeTokenCount: uint32Predicate(indexer.AliasMinNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterMaxNativeTokenCount: uint32Predicate(indexer.AliasMaxNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterStateController:     addressPredicate(indexer.AliasStateController),
+			QueryParameterGovernor:            addressPredicate(indexer.AliasGovernor),
+			QueryParameterIssuer:              addressesPredicate(indexer.AliasIssuer),
+			QueryParameterNotIssuer:           addressesPredicate(indexer.AliasNotIssuer),
+			QueryParameterSender:              addressesPredicate(indexer.AliasSender),
+			QueryParameterNotSender:           addressesPredicate(indexer.AliasNotSender),
+			QueryParameterCreatedBefore:       timestampPredicate(indexer.AliasCreatedBefore),
+			QueryParameterCreatedAfter:        timestampPredicate(indexer.AliasCreatedAfter),
+		},
+		pageSize:         indexer.AliasPageSize,
+		cursor:           indexer.AliasCursor,
+		prevCursor:       indexer.AliasPrevCursor,
+		query:            s.Indexer.AliasOutputsWithFilterExpression,
+		queryWithFilters: s.Indexer.AliasOutputsWithFilters,
+	}
+}
+
+func (s *IndexerServer) nftsQuery() *outputsQuery[indexer.NFTFilterOption] {
+	return &outputsQuery[indexer.NFTFilterOption]{
+		predicates: map[string]queryPredicate[indexer.NFTFilterOption]{
+			QueryParameterHasNativeTokens:             boolPredicate(indexer.NFTHasNativeTokens),
+			QueryParameterMinNativeTokenCount:         uint32Predicate(indexer.NFTMinNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterMaxNativeTokenCount:         uint32Predicate(indexer.NFTMaxNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterAddress:                     addressesPredicate(indexer.NFTUnlockableByAddress),
+			QueryParameterNotAddress:                  addressesPredicate(indexer.NFTNotUnlockableByAddress),
+			QueryParameterHasStorageDepositReturn:     boolPredicate(indexer.NFTHasStorageDepositReturnCondition),
+			QueryParameterStorageDepositReturnAddress: addressPredicate(indexer.NFTStorageDepositReturnAddress),
+			QueryParameterHasExpiration:               boolPredicate(indexer.NFTHasExpirationCondition),
+			QueryParameterExpiresBefore:               timestampPredicate(indexer.NFTExpiresBefore),
+			QueryParameterExpiresAfter:                timestampPredicate(indexer.NFTExpiresAfter),
+			QueryParameterExpirationReturnAddress:     addressPredicate(indexer.NFTExpirationReturnAddress),
+			QueryParameterHasTimelock:                 boolPredicate(indexer.NFTHasTimelockCondition),
+			QueryParameterTimelockedBefore:            timestampPredicate(indexer.NFTTimelockedBefore),
+			QueryParameterTimelockedAfter:             timestampPredicate(indexer.NFTTimelockedAfter),
+			QueryParameterIssuer:                      addressesPredicate(indexer.NFTIssuer),
+			QueryParameterNotIssuer:                   addressesPredicate(indexer.NFTNotIssuer),
+			QueryParameterSender:                      addressesPredicate(indexer.NFTSender),
+			QueryParameterNotSender:                   addressesPredicate(indexer.NFTNotSender),
+			QueryParameterTag:                         hexPredicate(indexer.NFTTag, iotago.MaxTagLength),
+			QueryParameterNotTag:                      hexPredicate(indexer.NFTNotTag, iotago.MaxTagLength),
+			QueryParameterTagPrefix:                   hexPredicate(indexer.NFTTagPrefix, iotago.MaxTagLength),
+			QueryParameterTagContains:                 utf8Predicate(indexer.NFTTagContains, iotago.MaxTagLength),
+			QueryParameterCreatedBefore:               timestampPredicate(indexer.NFTCreatedBefore),
+			QueryParameterCreatedAfter:                timestampPredicate(indexer.NFTCreatedAfter),
+		},
+		pageSize:         indexer.NFTPageSize,
+		cursor:           indexer.NFTCursor,
+		prevCursor:       indexer.NFTPrevCursor,
+		query:            s.Indexer.NFTOutputsWithFilterExpression,
+		queryWithFilters: s.Indexer.NFTOutputsWithFilters,
+	}
+}
+
+func (s *IndexerServer) foundriesQuery() *outputsQuery[indexer.FoundryFilterOption] {
+	return &outputsQuery[indexer.FoundryFilterOption]{
+		predicates: map[string]queryPredicate[indexer.FoundryFilterOption]{
+			QueryParameterHasNativeTokens:     boolPredicate(indexer.FoundryHasNativeTokens),
+			QueryParameterMinNativeTokenCount: uint32Predicate(indexer.FoundryMinNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterMaxNativeTokenCount: uint32Predicate(indexer.FoundryMaxNativeTokenCount, iotago.MaxNativeTokenCountPerOutput),
+			QueryParameterAliasAddress: func(s *IndexerServer, name string, value interface{}) ([]indexer.FoundryFilterOption, error) {
+				address, err := s.parseFilterExpressionAddress(name, value)
+				if err != nil {
+					return nil, err
+				}
+				aliasAddress, ok := address.(*iotago.AliasAddress)
+				if !ok {
+					return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid address: %s, not an alias address", address.Bech32(s.Bech32HRP))
+				}
+
+				return []indexer.FoundryFilterOption{indexer.FoundryWithAliasAddress(aliasAddress)}, nil
+			},
+			QueryParameterCreatedBefore: timestampPredicate(indexer.FoundryCreatedBefore),
+			QueryParameterCreatedAfter:  timestampPredicate(indexer.FoundryCreatedAfter),
+		},
+		pageSize:         indexer.FoundryPageSize,
+		cursor:           indexer.FoundryCursor,
+		prevCursor:       indexer.FoundryPrevCursor,
+		query:            s.Indexer.FoundryOutputsWithFilterExpression,
+		queryWithFilters: s.Indexer.FoundryOutputsWithFilters,
+	}
+}
+
+// outputsWithFilterExpression parses the filter expression of the request body and runs the query.
+// The page size and the cursor are passed as query parameters, like for the other routes.
+func outputsWithFilterExpression[T any](s *IndexerServer, c echo.Context, q *outputsQuery[T]) (*outputsResponse, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxFilterBodySize)
+
+	request := &outputsQueryRequest{}
+	decoder := json.NewDecoder(req.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(request); err != nil {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request body, error: %s", err)
+	}
+
+	nodes := 0
+	expr, err := parseFilterExpression(s, q.predicates, request.Filter, 1, &nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// bind the cursors to the filter expression
+	canonicalFilter, err := json.Marshal(request.Filter)
+	if err != nil {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request body, error: %s", err)
+	}
+	c.Set(contextKeyFilterExpression, canonicalFilter)
+
+	filters := []T{q.pageSize(s.pageSizeFromContext(c))}
+	if len(c.QueryParam(QueryParameterCursor)) > 0 {
+		cursor, pageSize, backward, err := s.parseCursorQueryParameter(c)
+		if err != nil {
+			return nil, err
+		}
+		if backward {
+			filters = append(filters, q.prevCursor(cursor), q.pageSize(pageSize))
+		} else {
+			filters = append(filters, q.cursor(cursor), q.pageSize(pageSize))
+		}
+	}
+
+	return s.outputsResponseFromResult(c, q.query(c.Request().Context(), expr, filters...))
+}
+
+// parseFilterExpression validates a node of the filter expression and converts it to the expression of the indexer.
+// A node is either an object with exactly one of the operators "and", "or" or "not",
+// or an object with one or more filters, which all have to match.
+func parseFilterExpression[T any](s *IndexerServer, predicates map[string]queryPredicate[T], node interface{}, depth int, nodes *int) (*indexer.FilterExpression[T], error) {
+	if depth > MaxFilterExpressionDepth {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter expression too deep, max. depth is %d", MaxFilterExpressionDepth)
+	}
+
+	*nodes++
+	if *nodes > MaxFilterExpressionNodes {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter expression too large, max. %d operators and filters", MaxFilterExpressionNodes)
+	}
+
+	object, ok := node.(map[string]interface{})
+	if !ok || len(object) == 0 {
+		return nil, errors.WithMessage(httpserver.ErrInvalidParameter, "filter expression has to be a non-empty object")
+	}
+
+	parseOperands := func(operator string, value interface{}) ([]*indexer.FilterExpression[T], error) {
+		operands, ok := value.([]interface{})
+		if !ok || len(operands) == 0 {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "operator %s needs a non-empty list of filter expressions", operator)
+		}
+
+		exprs := make([]*indexer.FilterExpression[T], 0, len(operands))
+		for _, operand := range operands {
+			expr, err := parseFilterExpression(s, predicates, operand, depth+1, nodes)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+
+		return exprs, nil
+	}
+
+	for key, value := range object {
+		if key != filterExpressionAnd && key != filterExpressionOr && key != filterExpressionNot {
+			continue
+		}
+
+		if len(object) != 1 {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "operator %s can not be combined with other operators or filters in the same object", key)
+		}
+
+		switch key {
+		case filterExpressionAnd:
+			exprs, err := parseOperands(key, value)
+			if err != nil {
+				return nil, err
+			}
+
+			return &indexer.FilterExpression[T]{And: exprs}, nil
+
+		case filterExpressionOr:
+			exprs, err := parseOperands(key, value)
+			if err != nil {
+				return nil, err
+			}
+
+			return &indexer.FilterExpression[T]{Or: exprs}, nil
+
+		default:
+			expr, err := parseFilterExpression(s, predicates, value, depth+1, nodes)
+			if err != nil {
+				return nil, err
+			}
+
+			return &indexer.FilterExpression[T]{Not: expr}, nil
+		}
+	}
+
+	filters := make([]T, 0, len(object))
+	for name, value := range object {
+		predicate, ok := predicates[name]
+		if !ok {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "unknown filter %s", name)
+		}
+
+		options, err := predicate(s, name, value)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, options...)
+	}
+
+	return &indexer.FilterExpression[T]{Filters: filters}, nil
+}
+
+func boolPredicate[T any](filter func(bool) T) queryPredicate[T] {
+	return func(_ *IndexerServer, name string, value interface{}) ([]T, error) {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a boolean", name)
+		}
+
+		return []T{filter(b)}, nil
+	}
+}
+
+func parseFilterExpressionUint32(name string, value interface{}, maxValue uint32) (uint32, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a number", name)
+	}
+
+	i, err := number.Int64()
+	if err != nil || i < 0 || i > int64(maxValue) {
+		return 0, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a number between 0 and %d", name, maxValue)
+	}
+
+	return uint32(i), nil
+}
+
+func uint32Predicate[T any](filter func(uint32) T, maxValue uint32) queryPredicate[T] {
+	return func(_ *IndexerServer, name string, value interface{}) ([]T, error) {
+		i, err := parseFilterExpressionUint32(name, value, maxValue)
+		if err != nil {
+			return nil, err
+		}
+
+		return []T{filter(i)}, nil
+	}
+}
+
+func timestampPredicate[T any](filter func(time.Time) T) queryPredicate[T] {
+	return func(_ *IndexerServer, name string, value interface{}) ([]T, error) {
+		timestamp, err := parseFilterExpressionUint32(name, value, ^uint32(0))
+		if err != nil {
+			return nil, err
+		}
+
+		return []T{filter(time.Unix(int64(timestamp), 0))}, nil
+	}
+}
+
+func (s *IndexerServer) parseFilterExpressionAddress(name string, value interface{}) (iotago.Address, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a bech32 address", name)
+	}
+
+	addressParam := strings.ToLower(str)
+	hrp, address, err := iotago.ParseBech32(addressParam)
+	if err != nil {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid address: %s, error: %s", addressParam, err)
+	}
+
+	if hrp != s.Bech32HRP {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid bech32 address, expected prefix: %s", s.Bech32HRP)
+	}
+
+	return address, nil
+}
+
+func addressPredicate[T any](filter func(iotago.Address) T) queryPredicate[T] {
+	return func(s *IndexerServer, name string, value interface{}) ([]T, error) {
+		address, err := s.parseFilterExpressionAddress(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return []T{filter(address)}, nil
+	}
+}
+
+// filterExpressionValues returns the values of a filter that accepts a single value or a list of values.
+func filterExpressionValues(name string, value interface{}) ([]interface{}, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return []interface{}{value}, nil
+	}
+
+	if len(values) == 0 || len(values) > MaxFilterValuesPerParameter {
+		return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s needs between 1 and %d values", name, MaxFilterValuesPerParameter)
+	}
+
+	return values, nil
+}
+
+func addressesPredicate[T any](filter func(iotago.Address) T) queryPredicate[T] {
+	return func(s *IndexerServer, name string, value interface{}) ([]T, error) {
+		values, err := filterExpressionValues(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]T, 0, len(values))
+		for _, v := range values {
+			address, err := s.parseFilterExpressionAddress(name, v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, filter(address))
+		}
+
+		return result, nil
+	}
+}
+
+func hexPredicate[T any](filter func([]byte) T, maxLen int) queryPredicate[T] {
+	return func(_ *IndexerServer, name string, value interface{}) ([]T, error) {
+		values, err := filterExpressionValues(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]T, 0, len(values))
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a hex string", name)
+			}
+
+			bytes, err := iotago.DecodeHex(str)
+			if err != nil {
+				return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid filter: %s, error: %s", name, err)
+			}
+			if len(bytes) == 0 || len(bytes) > maxLen {
+				return nil, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("filter %s has to be between 1 and %d bytes but is %d", name, maxLen, len(bytes)))
+			}
+			result = append(result, filter(bytes))
+		}
+
+		return result, nil
+	}
+}
+
+func utf8Predicate[T any](filter func(string) T, maxLen int) queryPredicate[T] {
+	return func(_ *IndexerServer, name string, value interface{}) ([]T, error) {
+		values, err := filterExpressionValues(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]T, 0, len(values))
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				return nil, errors.WithMessagef(httpserver.ErrInvalidParameter, "filter %s has to be a string", name)
+			}
+
+			if len(str) == 0 || len(str) > maxLen {
+				return nil, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("filter %s has to be between 1 and %d bytes but is %d", name, maxLen, len(str)))
+			}
+			result = append(result, filter(str))
+		}
+
+		return result, nil
+	}
+}
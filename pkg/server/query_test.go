@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+// describeFilterExpression returns the structure of an expression, e.g. and(not(1),2) for an "and" of a negated filter and two filters.
+func describeFilterExpression[T any](expr *indexer.FilterExpression[T]) string {
+	operands := func(exprs []*indexer.FilterExpression[T]) string {
+		descriptions := make([]string, 0, len(exprs))
+		for _, e := range exprs {
+			descriptions = append(descriptions, describeFilterExpression(e))
+		}
+
+		return strings.Join(descriptions, ",")
+	}
+
+	switch {
+	case len(expr.And) > 0:
+		return "and(" + operands(expr.And) + ")"
+	case len(expr.Or) > 0:
+		return "or(" + operands(expr.Or) + ")"
+	case expr.Not != nil:
+		return "not(" + describeFilterExpression(expr.Not) + ")"
+	default:
+		return strings.Repeat("|", len(expr.Filters))
+	}
+}
+
+func parseTestFilterExpression(s *IndexerServer, filter string) (*indexer.FilterExpression[indexer.BasicOutputFilterOption], error) {
+	decoder := json.NewDecoder(strings.NewReader(filter))
+	decoder.UseNumber()
+
+	var node interface{}
+	if err := decoder.Decode(&node); err != nil {
+		return nil, err
+	}
+
+	nodes := 0
+
+	return parseFilterExpression(s, s.basicOutputsQuery().predicates, node, 1, &nodes)
+}
+
+func TestParseFilterExpression(t *testing.T) {
+	s := &IndexerServer{}
+
+	tests := []struct {
+		name      string
+		filter    string
+		structure string
+	}{
+		{
+			name:      "filters",
+			filter:    `{"hasNativeTokens":true,"minNativeTokenCount":2}`,
+			structure: "||",
+		},
+		{
+			name:      "operators",
+			filter:    `{"or":[{"and":[{"hasTimelock":true},{"not":{"hasExpiration":false}}]},{"hasNativeTokens":true}]}`,
+			structure: "or(and(|,not(|)),|)",
+		},
+		{
+			name:      "maximum depth",
+			filter:    strings.Repeat(`{"not":`, MaxFilterExpressionDepth-1) + `{"hasTimelock":true}` + strings.Repeat(`}`, MaxFilterExpressionDepth-1),
+			structure: strings.Repeat("not(", MaxFilterExpressionDepth-1) + "|" + strings.Repeat(")", MaxFilterExpressionDepth-1),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := parseTestFilterExpression(s, test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if structure := describeFilterExpression(expr); structure != test.structure {
+				t.Errorf("expected %s, got %s", test.structure, structure)
+			}
+		})
+	}
+}
+
+func TestParseFilterExpressionRejected(t *testing.T) {
+	s := &IndexerServer{}
+
+	tooManyNodes := strings.TrimSuffix(strings.Repeat(`{"hasTimelock":true},`, MaxFilterExpressionNodes), ",")
+
+	tests := []struct {
+		name    string
+		filter  string
+		message string
+	}{
+		{
+			name:    "not an object",
+			filter:  `[{"hasTimelock":true}]`,
+			message: "has to be a non-empty object",
+		},
+		{
+			name:    "empty object",
+			filter:  `{}`,
+			message: "has to be a non-empty object",
+		},
+		{
+			name:    "operator combined with a filter",
+			filter:  `{"not":{"hasTimelock":true},"hasExpiration":true}`,
+			message: "can not be combined with other operators or filters",
+		},
+		{
+			name:    "operator without operands",
+			filter:  `{"and":[]}`,
+			message: "needs a non-empty list of filter expressions",
+		},
+		{
+			name:    "unknown filter",
+			filter:  `{"or":[{"hasTimelock":true},{"cursor":"abc"}]}`,
+			message: "unknown filter cursor",
+		},
+		{
+			name:    "invalid filter value",
+			filter:  `{"hasTimelock":"yes"}`,
+			message: "has to be a boolean",
+		},
+		{
+			name:    "invalid number",
+			filter:  `{"minNativeTokenCount":-1}`,
+			message: "has to be a number between",
+		},
+		{
+			name:    "too deep",
+			filter:  strings.Repeat(`{"not":`, MaxFilterExpressionDepth) + `{"hasTimelock":true}` + strings.Repeat(`}`, MaxFilterExpressionDepth),
+			message: "filter expression too deep",
+		},
+		{
+			name:    "too many nodes",
+			filter:  `{"or":[` + tooManyNodes + `]}`,
+			message: "filter expression too large",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseTestFilterExpression(s, test.filter)
+			if !errors.Is(err, httpserver.ErrInvalidParameter) {
+				t.Fatalf("expected an invalid parameter error, got %v", err)
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error %q, got %q", test.message, err.Error())
+			}
+		})
+	}
+}
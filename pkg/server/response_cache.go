@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const (
+	// HeaderETag is the header that contains the entity tag of a cached response.
+	HeaderETag = "ETag"
+	// HeaderIfNoneMatch is the header that contains the entity tags of the responses a client already has.
+	HeaderIfNoneMatch = "If-None-Match"
+
+	// maxCachedRequestBodySize is the maximum size of a request body that is part of the key of a cached response.
+	// Requests with bigger bodies are not cached.
+	maxCachedRequestBodySize = 64 * 1024
+)
+
+// cachedResponse is a successful response of the API.
+type cachedResponse struct {
+	key         string
+	contentType string
+	body        []byte
+	etag        string
+}
+
+// responseCache keeps the most recently used responses of the API for the current ledger index.
+// All responses are dropped once a new milestone was applied, since the results might have changed.
+type responseCache struct {
+	maxEntries int
+
+	entriesLock sync.Mutex
+	entries     map[string]*list.Element
+	// lru contains the cached responses, the most recently used one at the front.
+	lru *list.List
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (rc *responseCache) get(key string) *cachedResponse {
+	rc.entriesLock.Lock()
+	defer rc.entriesLock.Unlock()
+
+	element, exists := rc.entries[key]
+	if !exists {
+		return nil
+	}
+	rc.lru.MoveToFront(element)
+
+	//nolint:forcetypeassert // only cached responses are added to the list
+	return element.Value.(*cachedResponse)
+}
+
+func (rc *responseCache) add(response *cachedResponse) {
+	rc.entriesLock.Lock()
+	defer rc.entriesLock.Unlock()
+
+	if element, exists := rc.entries[response.key]; exists {
+		element.Value = response
+		rc.lru.MoveToFront(element)
+
+		return
+	}
+
+	if rc.maxEntries > 0 && rc.lru.Len() >= rc.maxEntries {
+		oldest := rc.lru.Back()
+		//nolint:forcetypeassert // only cached responses are added to the list
+		delete(rc.entries, oldest.Value.(*cachedResponse).key)
+		rc.lru.Remove(oldest)
+	}
+
+	rc.entries[response.key] = rc.lru.PushFront(response)
+}
+
+func (rc *responseCache) purge() {
+	rc.entriesLock.Lock()
+	defer rc.entriesLock.Unlock()
+
+	rc.entries = make(map[string]*list.Element)
+	rc.lru.Init()
+}
+
+// responseRecorder buffers the response of a handler, so it can be cached before it is sent.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+// responseETag returns the strong entity tag of a response body.
+// It only depends on the body, so a client polling every milestone gets a 304 as long as its results did not change.
+func responseETag(body []byte) string {
+	hash := sha256.Sum256(body)
+
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// etagMatches checks whether the If-None-Match header of a request contains the given entity tag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// responseCacheKey returns the key of the response to a request at the given ledger index.
+// The key contains the route, the filters and the cursor of the request, either as query parameters or in the body,
+// and the maximum page size of the client, which is the default page size of its requests.
+func (s *IndexerServer) responseCacheKey(c echo.Context, ledgerIndex uint32) (string, bool) {
+	req := c.Request()
+
+	var body []byte
+	if req.Body != nil && req.ContentLength != 0 {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(req.Body, maxCachedRequestBodySize+1))
+		if err != nil {
+			return "", false
+		}
+		// the handler reads the body again, the unread rest is kept if it was too big
+		req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+		if len(body) > maxCachedRequestBodySize {
+			return "", false
+		}
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{req.Method, req.URL.Path, req.URL.Query().Encode(), req.Header.Get(echo.HeaderContentType)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	var numbers [8]byte
+	binary.BigEndian.PutUint32(numbers[:4], ledgerIndex)
+	binary.BigEndian.PutUint32(numbers[4:], uint32(s.maxPageSize(req.Context())))
+	hash.Write(numbers[:])
+	hash.Write(body)
+
+	return string(hash.Sum(nil)), true
+}
+
+// writeCachedResponse sends a cached response, or 304 if the client already has it.
+func writeCachedResponse(c echo.Context, response *cachedResponse) error {
+	c.Response().Header().Set(HeaderETag, response.etag)
+	if etagMatches(c.Request().Header.Get(HeaderIfNoneMatch), response.etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, response.contentType, response.body)
+}
+
+// responseCacheMiddleware answers the requests from the cache of the current ledger index
+// and adds the successful responses of the other requests to it.
+func (s *IndexerServer) responseCacheMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ledgerIndex := s.Indexer.LedgerIndex()
+
+		key, cacheable := s.responseCacheKey(c, ledgerIndex)
+		if !cacheable {
+			return next(c)
+		}
+
+		if response := s.responseCache.get(key); response != nil {
+			return writeCachedResponse(c, response)
+		}
+
+		ctx, ledgerIndexes := indexer.WithLedgerIndexRecorder(c.Request().Context())
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		writer := c.Response().Writer
+		recorder := &responseRecorder{ResponseWriter: writer, status: http.StatusOK}
+		c.Response().Writer = recorder
+		err := next(c)
+		c.Response().Writer = writer
+
+		if !c.Response().Committed {
+			// the handler returned an error, it is written by the error handler
+			return err
+		}
+
+		if recorder.status != http.StatusOK {
+			writer.WriteHeader(recorder.status)
+			_, writeErr := writer.Write(recorder.body.Bytes())
+
+			return writeErr
+		}
+
+		response := &cachedResponse{
+			key:         key,
+			contentType: c.Response().Header().Get(echo.HeaderContentType),
+			body:        recorder.body.Bytes(),
+			etag:        responseETag(recorder.body.Bytes()),
+		}
+
+		// the response might contain the results of a newer milestone if the ledger changed during the query,
+		// or of an older one if a read replica answered it, so it is only cached if it belongs to the ledger index of the key
+		if ledgerIndexes.ReadAt(ledgerIndex) && s.Indexer.LedgerIndex() == ledgerIndex {
+			s.responseCache.add(response)
+		}
+
+		c.Response().Header().Set(HeaderETag, response.etag)
+		if etagMatches(c.Request().Header.Get(HeaderIfNoneMatch), response.etag) {
+			c.Response().Status = http.StatusNotModified
+			writer.WriteHeader(http.StatusNotModified)
+
+			return nil
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		_, writeErr := writer.Write(response.body)
+
+		return writeErr
+	}
+}
+
+// configureResponseCache drops the cached responses once a new milestone was applied.
+func (s *IndexerServer) configureResponseCache() {
+	s.Indexer.Events.LedgerUpdated.Hook(event.NewClosure(func(_ *nodebridge.LedgerUpdate) {
+		s.responseCache.purge()
+	}))
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var initTestLogger sync.Once
+
+// newTestIndexer returns an indexer with empty tables at the given ledger index.
+func newTestIndexer(t *testing.T, ledgerIndex uint32) *indexer.Indexer {
+	t.Helper()
+
+	return newTestIndexerWithOutputs(t, ledgerIndex, nil)
+}
+
+// newTestIndexerWithOutputs returns an indexer that contains the given outputs at the given ledger index.
+func newTestIndexerWithOutputs(t *testing.T, ledgerIndex uint32, outputs map[iotago.OutputID]iotago.Output) *indexer.Indexer {
+	t.Helper()
+
+	initTestLogger.Do(func() {
+		if err := logger.InitGlobalLogger(configuration.New()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	idx, err := indexer.NewIndexer(database.Params{Engine: database.EngineSQLite, Path: t.TempDir()}, logger.NewLogger("Indexer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = idx.CloseDatabase() })
+
+	if err := idx.CreateTables(); err != nil {
+		t.Fatal(err)
+	}
+	importer := idx.ImportTransaction(context.Background())
+	for outputID, output := range outputs {
+		if err := importer.AddOutput(outputID, output, 1_700_000_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := importer.Finalize(ledgerIndex, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Status(); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := responseETag([]byte(`{"items":[]}`))
+	if etag != responseETag([]byte(`{"items":[]}`)) {
+		t.Fatal("expected the entity tag to only depend on the body")
+	}
+	if etag == responseETag([]byte(`{"items":["0x01"]}`)) {
+		t.Fatal("expected different bodies to have different entity tags")
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		matches     bool
+	}{
+		{ifNoneMatch: "", matches: false},
+		{ifNoneMatch: etag, matches: true},
+		{ifNoneMatch: "W/" + etag, matches: true},
+		{ifNoneMatch: `"other", ` + etag, matches: true},
+		{ifNoneMatch: "*", matches: true},
+		{ifNoneMatch: `"other"`, matches: false},
+		{ifNoneMatch: strings.Trim(etag, `"`), matches: false},
+	}
+
+	for _, test := range tests {
+		if matches := etagMatches(test.ifNoneMatch, etag); matches != test.matches {
+			t.Errorf("expected %q to match %t, got %t", test.ifNoneMatch, test.matches, matches)
+		}
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	s := &IndexerServer{RestAPILimitsMaxResults: 1000}
+
+	newContext := func(method string, target string, body string, client *auth.Client) echo.Context {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		if client != nil {
+			req = req.WithContext(auth.WithClient(req.Context(), client))
+		}
+
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	base, cacheable := s.responseCacheKey(newContext(http.MethodGet, "/outputs/basic?tag=0x01&pageSize=10", "", nil), 10)
+	if !cacheable {
+		t.Fatal("expected the request to be cacheable")
+	}
+
+	tests := []struct {
+		name        string
+		context     echo.Context
+		ledgerIndex uint32
+		equal       bool
+	}{
+		{
+			name:        "same request",
+			context:     newContext(http.MethodGet, "/outputs/basic?tag=0x01&pageSize=10", "", nil),
+			ledgerIndex: 10,
+			equal:       true,
+		},
+		{
+			name:        "other order of the query parameters",
+			context:     newContext(http.MethodGet, "/outputs/basic?pageSize=10&tag=0x01", "", nil),
+			ledgerIndex: 10,
+			equal:       true,
+		},
+		{
+			name:        "other ledger index",
+			context:     newContext(http.MethodGet, "/outputs/basic?tag=0x01&pageSize=10", "", nil),
+			ledgerIndex: 11,
+		},
+		{
+			name:        "other query parameter",
+			context:     newContext(http.MethodGet, "/outputs/basic?tag=0x02&pageSize=10", "", nil),
+			ledgerIndex: 10,
+		},
+		{
+			name:        "other route",
+			context:     newContext(http.MethodGet, "/outputs/nft?tag=0x01&pageSize=10", "", nil),
+			ledgerIndex: 10,
+		},
+		{
+			name:        "other maximum page size of the client",
+			context:     newContext(http.MethodGet, "/outputs/basic?tag=0x01&pageSize=10", "", &auth.Client{Name: "explorer", MaxPageSize: 50}),
+			ledgerIndex: 10,
+		},
+		{
+			name:        "body",
+			context:     newContext(http.MethodPost, "/outputs/basic?tag=0x01&pageSize=10", `{"filter":{"hasTimelock":true}}`, nil),
+			ledgerIndex: 10,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			key, cacheable := s.responseCacheKey(test.context, test.ledgerIndex)
+			if !cacheable {
+				t.Fatal("expected the request to be cacheable")
+			}
+			if equal := key == base; equal != test.equal {
+				t.Errorf("expected equal keys %t, got %t", test.equal, equal)
+			}
+		})
+	}
+
+	t.Run("body is kept for the handler", func(t *testing.T) {
+		body := `{"filter":{"hasTimelock":true}}`
+		c := newContext(http.MethodPost, "/outputs/basic", body, nil)
+		if _, cacheable := s.responseCacheKey(c, 10); !cacheable {
+			t.Fatal("expected the request to be cacheable")
+		}
+
+		read, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(read) != body {
+			t.Errorf("expected body %q, got %q", body, read)
+		}
+	})
+
+	t.Run("big body", func(t *testing.T) {
+		body := `{"filter":"` + strings.Repeat("a", maxCachedRequestBodySize) + `"}`
+		c := newContext(http.MethodPost, "/outputs/basic", body, nil)
+		if _, cacheable := s.responseCacheKey(c, 10); cacheable {
+			t.Fatal("expected a request with a big body not to be cacheable")
+		}
+
+		read, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(read) != body {
+			t.Errorf("expected the whole body of %d bytes, got %d", len(body), len(read))
+		}
+	})
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	rc := newResponseCache(2)
+	rc.add(&cachedResponse{key: "a"})
+	rc.add(&cachedResponse{key: "b"})
+
+	// a is used more recently than b
+	if rc.get("a") == nil {
+		t.Fatal("expected a to be cached")
+	}
+	rc.add(&cachedResponse{key: "c"})
+
+	if rc.get("b") != nil {
+		t.Error("expected the least recently used response to be evicted")
+	}
+	if rc.get("a") == nil || rc.get("c") == nil {
+		t.Error("expected the recently used responses to be kept")
+	}
+
+	rc.purge()
+	if rc.get("a") != nil || rc.get("c") != nil {
+		t.Error("expected all responses to be dropped")
+	}
+}
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	s := &IndexerServer{
+		Indexer:                 newTestIndexer(t, 10),
+		RestAPILimitsMaxResults: 1000,
+		responseCache:           newResponseCache(10),
+	}
+
+	calls := 0
+	handler := s.responseCacheMiddleware(func(c echo.Context) error {
+		calls++
+
+		// the read records the ledger index the results were read at
+		result := s.Indexer.BasicOutputsWithFilters(c.Request().Context(), indexer.BasicOutputPageSize(10))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return c.JSON(http.StatusOK, &outputsResponse{LedgerIndex: result.LedgerIndex, PageSize: result.PageSize, Items: result.OutputIDs.ToHex()})
+	})
+
+	request := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/outputs/basic", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(HeaderIfNoneMatch, ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		if err := handler(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+
+		return rec
+	}
+
+	first := request("")
+	if first.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("expected the handler to answer the first request, got status %d after %d calls", first.Code, calls)
+	}
+	etag := first.Header().Get(HeaderETag)
+	if etag != responseETag(first.Body.Bytes()) {
+		t.Fatalf("expected the entity tag of the body, got %s", etag)
+	}
+
+	second := request("")
+	if second.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("expected the second request to be answered from the cache, got status %d after %d calls", second.Code, calls)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get(HeaderETag) != etag {
+		t.Error("expected the cached response to be equal to the first one")
+	}
+
+	notModified := request(etag)
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got status %d with %d bytes", notModified.Code, notModified.Body.Len())
+	}
+
+	s.responseCache.purge()
+	if third := request(etag); third.Code != http.StatusNotModified || calls != 2 {
+		t.Errorf("expected 304 for an unchanged response of the handler, got status %d after %d calls", third.Code, calls)
+	}
+}
@@ -10,21 +10,42 @@ const (
 	// ParameterNFTID is used to identify a nft by its ID.
 	ParameterNFTID = "nftID"
 
+	// ParameterBech32Address is used to identify an address by its bech32 representation.
+	ParameterBech32Address = "bech32"
+
 	// QueryParameterAddress is used to filter for a certain address.
 	QueryParameterAddress = "address"
 
+	// QueryParameterNotAddress is used to exclude a certain address.
+	QueryParameterNotAddress = "notAddress"
+
 	// QueryParameterAliasAddress is used to filter for a certain alias address.
 	QueryParameterAliasAddress = "aliasAddress"
 
 	// QueryParameterIssuer is used to filter for a certain issuer.
 	QueryParameterIssuer = "issuer"
 
+	// QueryParameterNotIssuer is used to exclude a certain issuer.
+	QueryParameterNotIssuer = "notIssuer"
+
 	// QueryParameterSender is used to filter for a certain sender.
 	QueryParameterSender = "sender"
 
+	// QueryParameterNotSender is used to exclude a certain sender.
+	QueryParameterNotSender = "notSender"
+
 	// QueryParameterTag is used to filter for a certain tag.
 	QueryParameterTag = "tag"
 
+	// QueryParameterNotTag is used to exclude a certain tag.
+	QueryParameterNotTag = "notTag"
+
+	// QueryParameterTagPrefix is used to filter for tags that start with a certain prefix.
+	QueryParameterTagPrefix = "tagPrefix"
+
+	// QueryParameterTagContains is used to filter for tags that contain a certain UTF-8 string.
+	QueryParameterTagContains = "tagContains"
+
 	// QueryParameterHasStorageDepositReturn is used to filter for outputs having a storage deposit return unlock condition.
 	QueryParameterHasStorageDepositReturn = "hasStorageDepositReturn"
 
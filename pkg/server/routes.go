@@ -1,10 +1,7 @@
 package server
 
 import (
-	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -19,35 +16,58 @@ const (
 	// RouteOutputsBasic is the route for getting basic outputs filtered by the given parameters.
 	// GET with query parameter returns all outputIDs that fit these filter criteria.
 	// Query parameters: "hasNativeTokens", "minNativeTokenCount", "maxNativeTokenCount",
-	//					 "address", "hasStorageDepositReturn", "storageDepositReturnAddress",
+	//					 "address", "notAddress", "hasStorageDepositReturn", "storageDepositReturnAddress",
 	// 					 "hasExpiration", "expiresBefore", "expiresAfter", "expirationReturnAddress",
-	//					 "hasTimelock", "timelockedBefore", "timelockedAfter", "sender", "tag",
-	//					 "createdBefore", "createdAfter"
+	//					 "hasTimelock", "timelockedBefore", "timelockedAfter", "sender", "notSender", "tag", "notTag",
+	//					 "tagPrefix", "tagContains", "createdBefore", "createdAfter"
+	// "address", "sender", "tag", "tagPrefix", "tagContains" and the negations can be given multiple times.
+	// POST accepts the same parameters as form encoded or JSON body.
 	// Returns an empty list if no results are found.
 	RouteOutputsBasic = "/outputs/basic"
 
+	// RouteOutputsBasicQuery is the route for getting basic outputs filtered by a filter expression.
+	// POST with a JSON body containing the filter expression in "filter", which combines the filters
+	// of the GET route with the operators "and", "or" and "not". "pageSize" and "cursor" are query parameters.
+	// Returns an empty list if no results are found.
+	RouteOutputsBasicQuery = "/outputs/basic/query"
+
 	// RouteOutputsAliases is the route for getting aliases filtered by the given parameters.
 	// GET with query parameter returns all outputIDs that fit these filter criteria.
 	// Query parameters: "hasNativeTokens", "minNativeTokenCount", "maxNativeTokenCount",
-	//					 "stateController", "governor", "issuer", "sender",
+	//					 "stateController", "governor", "issuer", "notIssuer", "sender", "notSender",
 	//					 "createdBefore", "createdAfter"
-	// Query parameters:
+	// "issuer" and "sender" and their negations can be given multiple times.
+	// POST accepts the same parameters as form encoded or JSON body.
 	// Returns an empty list if no results are found.
 	RouteOutputsAliases = "/outputs/alias"
 
+	// RouteOutputsAliasesQuery is the route for getting aliases filtered by a filter expression.
+	// POST with a JSON body containing the filter expression in "filter", which combines the filters
+	// of the GET route with the operators "and", "or" and "not". "pageSize" and "cursor" are query parameters.
+	// Returns an empty list if no results are found.
+	RouteOutputsAliasesQuery = "/outputs/alias/query"
+
 	// RouteOutputsAliasByID is the route for getting aliases by their aliasID.
 	// GET returns the outputIDs or 404 if no record is found.
 	RouteOutputsAliasByID = "/outputs/alias/:" + ParameterAliasID
 
 	// RouteOutputsNFTs is the route for getting NFT filtered by the given parameters.
 	// Query parameters: "hasNativeTokens", "minNativeTokenCount", "maxNativeTokenCount",
-	//					 "address", "hasStorageDepositReturn", "storageDepositReturnAddress",
+	//					 "address", "notAddress", "hasStorageDepositReturn", "storageDepositReturnAddress",
 	// 					 "hasExpiration", "expiresBefore", "expiresAfter", "expirationReturnAddress",
-	//					 "hasTimelock", "timelockedBefore", "timelockedAfter", "issuer", "sender", "tag",
-	//					 "createdBefore", "createdAfter"
+	//					 "hasTimelock", "timelockedBefore", "timelockedAfter", "issuer", "notIssuer",
+	//					 "sender", "notSender", "tag", "notTag", "tagPrefix", "tagContains", "createdBefore", "createdAfter"
+	// "address", "issuer", "sender", "tag", "tagPrefix", "tagContains" and the negations can be given multiple times.
+	// POST accepts the same parameters as form encoded or JSON body.
 	// Returns an empty list if no results are found.
 	RouteOutputsNFTs = "/outputs/nft"
 
+	// RouteOutputsNFTsQuery is the route for getting NFT filtered by a filter expression.
+	// POST with a JSON body containing the filter expression in "filter", which combines the filters
+	// of the GET route with the operators "and", "or" and "not". "pageSize" and "cursor" are query parameters.
+	// Returns an empty list if no results are found.
+	RouteOutputsNFTsQuery = "/outputs/nft/query"
+
 	// RouteOutputsNFTByID is the route for getting NFT by their nftID.
 	// GET returns the outputIDs or 404 if no record is found.
 	RouteOutputsNFTByID = "/outputs/nft/:" + ParameterNFTID
@@ -56,12 +76,35 @@ const (
 	// GET with query parameter returns all outputIDs that fit these filter criteria.
 	// Query parameters: "hasNativeTokens", "minNativeTokenCount", "maxNativeTokenCount",
 	//					 "aliasAddress", "createdBefore", "createdAfter"
+	// POST accepts the same parameters as form encoded or JSON body.
 	// Returns an empty list if no results are found.
 	RouteOutputsFoundries = "/outputs/foundry"
 
+	// RouteOutputsFoundriesQuery is the route for getting foundries filtered by a filter expression.
+	// POST with a JSON body containing the filter expression in "filter", which combines the filters
+	// of the GET route with the operators "and", "or" and "not". "pageSize" and "cursor" are query parameters.
+	// Returns an empty list if no results are found.
+	RouteOutputsFoundriesQuery = "/outputs/foundry/query"
+
 	// RouteOutputsFoundryByID is the route for getting foundries by their foundryID.
 	// GET returns the outputIDs or 404 if no record is found.
 	RouteOutputsFoundryByID = "/outputs/foundry/:" + ParameterFoundryID
+
+	// RouteAddressSummary is the route for getting the amount of outputs that are related to an address.
+	// GET returns the counts of the outputs owned or controlled by the address and of the outputs with pending
+	// timelock, expiration or storage deposit return conditions, all taken at the same ledger index.
+	RouteAddressSummary = "/addresses/:" + ParameterBech32Address + "/summary"
+
+	// RouteAddressStorageDepositReturns is the route for getting the storage deposits that have to be returned to an address.
+	// GET returns the unspent basic and NFT outputs with a storage deposit return unlock condition to the address,
+	// together with the returned amount and the expiration time, ordered by their creation time.
+	// Query parameters: "pageSize", "cursor"
+	RouteAddressStorageDepositReturns = "/addresses/:" + ParameterBech32Address + "/storage-deposit-returns"
+)
+
+var (
+	// ErrQueryTimeout is returned if a query exceeded the maximum query duration.
+	ErrQueryTimeout = echo.NewHTTPError(http.StatusGatewayTimeout, "query timeout")
 )
 
 func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
@@ -75,6 +118,15 @@ func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
 		return c.JSON(http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteOutputsBasic, func(c echo.Context) error {
+		resp, err := s.basicOutputsWithFilter(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}, bodyToQueryParams)
+
 	routeGroup.GET(RouteOutputsAliases, func(c echo.Context) error {
 		resp, err := s.aliasesWithFilter(c)
 		if err != nil {
@@ -84,6 +136,15 @@ func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
 		return c.JSON(http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteOutputsAliases, func(c echo.Context) error {
+		resp, err := s.aliasesWithFilter(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}, bodyToQueryParams)
+
 	routeGroup.GET(RouteOutputsAliasByID, func(c echo.Context) error {
 		resp, err := s.aliasByID(c)
 		if err != nil {
@@ -102,6 +163,15 @@ func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
 		return c.JSON(http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteOutputsNFTs, func(c echo.Context) error {
+		resp, err := s.nftsWithFilter(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}, bodyToQueryParams)
+
 	routeGroup.GET(RouteOutputsNFTByID, func(c echo.Context) error {
 		resp, err := s.nftByID(c)
 		if err != nil {
@@ -120,6 +190,15 @@ func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
 		return c.JSON(http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteOutputsFoundries, func(c echo.Context) error {
+		resp, err := s.foundriesWithFilter(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}, bodyToQueryParams)
+
 	routeGroup.GET(RouteOutputsFoundryByID, func(c echo.Context) error {
 		resp, err := s.foundryByID(c)
 		if err != nil {
@@ -128,9 +207,73 @@ func (s *IndexerServer) configureRoutes(routeGroup *echo.Group) {
 
 		return c.JSON(http.StatusOK, resp)
 	})
+
+	routeGroup.GET(RouteAddressSummary, func(c echo.Context) error {
+		resp, err := s.addressSummary(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.GET(RouteAddressStorageDepositReturns, func(c echo.Context) error {
+		resp, err := s.storageDepositReturns(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.POST(RouteOutputsBasicQuery, func(c echo.Context) error {
+		resp, err := outputsWithFilterExpression(s, c, s.basicOutputsQuery())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.POST(RouteOutputsAliasesQuery, func(c echo.Context) error {
+		resp, err := outputsWithFilterExpression(s, c, s.aliasesQuery())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.POST(RouteOutputsNFTsQuery, func(c echo.Context) error {
+		resp, err := outputsWithFilterExpression(s, c, s.nftsQuery())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.POST(RouteOutputsFoundriesQuery, func(c echo.Context) error {
+		resp, err := outputsWithFilterExpression(s, c, s.foundriesQuery())
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
 }
 
 func (s *IndexerServer) basicOutputsWithFilter(c echo.Context) (*outputsResponse, error) {
+	filters, err := s.basicOutputsFilters(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.outputsResponseFromResult(c, s.Indexer.BasicOutputsWithFilters(c.Request().Context(), filters...))
+}
+
+// basicOutputsFilters parses the filters of the query parameters.
+func (s *IndexerServer) basicOutputsFilters(c echo.Context) ([]indexer.BasicOutputFilterOption, error) {
 	filters := []indexer.BasicOutputFilterOption{indexer.BasicOutputPageSize(s.pageSizeFromContext(c))}
 
 	if len(c.QueryParam(QueryParameterHasNativeTokens)) > 0 {
@@ -158,11 +301,23 @@ func (s *IndexerServer) basicOutputsWithFilter(c echo.Context) (*outputsResponse
 	}
 
 	if len(c.QueryParam(QueryParameterAddress)) > 0 {
-		addr, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterAddress)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterAddress)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.BasicOutputUnlockableByAddress(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotAddress)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotAddress)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.BasicOutputUnlockableByAddress(addr))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.BasicOutputNotUnlockableByAddress(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterHasStorageDepositReturn)) > 0 {
@@ -238,27 +393,75 @@ func (s *IndexerServer) basicOutputsWithFilter(c echo.Context) (*outputsResponse
 	}
 
 	if len(c.QueryParam(QueryParameterSender)) > 0 {
-		addr, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterSender)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterSender)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.BasicOutputSender(addr))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.BasicOutputSender(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotSender)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotSender)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.BasicOutputNotSender(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterTag)) > 0 {
-		tagBytes, err := httpserver.ParseHexQueryParam(c, QueryParameterTag, iotago.MaxTagLength)
+		tags, err := parseHexQueryParams(c, QueryParameterTag, iotago.MaxTagLength)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.BasicOutputTag(tagBytes))
+		for _, tagBytes := range tags {
+			filters = append(filters, indexer.BasicOutputTag(tagBytes))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotTag)) > 0 {
+		tags, err := parseHexQueryParams(c, QueryParameterNotTag, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, tagBytes := range tags {
+			filters = append(filters, indexer.BasicOutputNotTag(tagBytes))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterTagPrefix)) > 0 {
+		prefixes, err := parseHexQueryParams(c, QueryParameterTagPrefix, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range prefixes {
+			filters = append(filters, indexer.BasicOutputTagPrefix(prefix))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterTagContains)) > 0 {
+		substrings, err := parseUTF8QueryParams(c, QueryParameterTagContains, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, substring := range substrings {
+			filters = append(filters, indexer.BasicOutputTagContains(substring))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCursor)) > 0 {
-		cursor, pageSize, err := s.parseCursorQueryParameter(c)
+		cursor, pageSize, backward, err := s.parseCursorQueryParameter(c)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.BasicOutputCursor(cursor), indexer.BasicOutputPageSize(pageSize))
+		if backward {
+			filters = append(filters, indexer.BasicOutputPrevCursor(cursor), indexer.BasicOutputPageSize(pageSize))
+		} else {
+			filters = append(filters, indexer.BasicOutputCursor(cursor), indexer.BasicOutputPageSize(pageSize))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCreatedBefore)) > 0 {
@@ -277,7 +480,7 @@ func (s *IndexerServer) basicOutputsWithFilter(c echo.Context) (*outputsResponse
 		filters = append(filters, indexer.BasicOutputCreatedAfter(timestamp))
 	}
 
-	return outputsResponseFromResult(s.Indexer.BasicOutputsWithFilters(filters...))
+	return filters, nil
 }
 
 func (s *IndexerServer) aliasByID(c echo.Context) (*outputsResponse, error) {
@@ -286,10 +489,20 @@ func (s *IndexerServer) aliasByID(c echo.Context) (*outputsResponse, error) {
 		return nil, err
 	}
 
-	return singleOutputResponseFromResult(s.Indexer.AliasOutput(aliasID))
+	return s.singleOutputResponseFromResult(c, s.Indexer.AliasOutput(c.Request().Context(), aliasID))
 }
 
 func (s *IndexerServer) aliasesWithFilter(c echo.Context) (*outputsResponse, error) {
+	filters, err := s.aliasesFilters(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.outputsResponseFromResult(c, s.Indexer.AliasOutputsWithFilters(c.Request().Context(), filters...))
+}
+
+// aliasesFilters parses the filters of the query parameters.
+func (s *IndexerServer) aliasesFilters(c echo.Context) ([]indexer.AliasFilterOption, error) {
 	filters := []indexer.AliasFilterOption{indexer.AliasPageSize(s.pageSizeFromContext(c))}
 
 	if len(c.QueryParam(QueryParameterHasNativeTokens)) > 0 {
@@ -333,27 +546,55 @@ func (s *IndexerServer) aliasesWithFilter(c echo.Context) (*outputsResponse, err
 	}
 
 	if len(c.QueryParam(QueryParameterIssuer)) > 0 {
-		issuer, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterIssuer)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterIssuer)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.AliasIssuer(issuer))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.AliasIssuer(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotIssuer)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotIssuer)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.AliasNotIssuer(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterSender)) > 0 {
-		sender, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterSender)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterSender)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.AliasSender(sender))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.AliasSender(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotSender)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotSender)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.AliasNotSender(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCursor)) > 0 {
-		cursor, pageSize, err := s.parseCursorQueryParameter(c)
+		cursor, pageSize, backward, err := s.parseCursorQueryParameter(c)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.AliasCursor(cursor), indexer.AliasPageSize(pageSize))
+		if backward {
+			filters = append(filters, indexer.AliasPrevCursor(cursor), indexer.AliasPageSize(pageSize))
+		} else {
+			filters = append(filters, indexer.AliasCursor(cursor), indexer.AliasPageSize(pageSize))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCreatedBefore)) > 0 {
@@ -372,7 +613,7 @@ func (s *IndexerServer) aliasesWithFilter(c echo.Context) (*outputsResponse, err
 		filters = append(filters, indexer.AliasCreatedAfter(timestamp))
 	}
 
-	return outputsResponseFromResult(s.Indexer.AliasOutputsWithFilters(filters...))
+	return filters, nil
 }
 
 func (s *IndexerServer) nftByID(c echo.Context) (*outputsResponse, error) {
@@ -381,10 +622,20 @@ func (s *IndexerServer) nftByID(c echo.Context) (*outputsResponse, error) {
 		return nil, err
 	}
 
-	return singleOutputResponseFromResult(s.Indexer.NFTOutput(nftID))
+	return s.singleOutputResponseFromResult(c, s.Indexer.NFTOutput(c.Request().Context(), nftID))
 }
 
 func (s *IndexerServer) nftsWithFilter(c echo.Context) (*outputsResponse, error) {
+	filters, err := s.nftsFilters(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.outputsResponseFromResult(c, s.Indexer.NFTOutputsWithFilters(c.Request().Context(), filters...))
+}
+
+// nftsFilters parses the filters of the query parameters.
+func (s *IndexerServer) nftsFilters(c echo.Context) ([]indexer.NFTFilterOption, error) {
 	filters := []indexer.NFTFilterOption{indexer.NFTPageSize(s.pageSizeFromContext(c))}
 
 	if len(c.QueryParam(QueryParameterHasNativeTokens)) > 0 {
@@ -412,11 +663,23 @@ func (s *IndexerServer) nftsWithFilter(c echo.Context) (*outputsResponse, error)
 	}
 
 	if len(c.QueryParam(QueryParameterAddress)) > 0 {
-		addr, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterAddress)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterAddress)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.NFTUnlockableByAddress(addr))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTUnlockableByAddress(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotAddress)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotAddress)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTNotUnlockableByAddress(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterHasStorageDepositReturn)) > 0 {
@@ -492,35 +755,95 @@ func (s *IndexerServer) nftsWithFilter(c echo.Context) (*outputsResponse, error)
 	}
 
 	if len(c.QueryParam(QueryParameterIssuer)) > 0 {
-		addr, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterIssuer)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterIssuer)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTIssuer(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotIssuer)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotIssuer)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.NFTIssuer(addr))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTNotIssuer(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterSender)) > 0 {
-		addr, err := httpserver.ParseBech32AddressQueryParam(c, s.Bech32HRP, QueryParameterSender)
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterSender)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTSender(addr))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotSender)) > 0 {
+		addrs, err := s.parseBech32AddressesQueryParam(c, QueryParameterNotSender)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.NFTSender(addr))
+		for _, addr := range addrs {
+			filters = append(filters, indexer.NFTNotSender(addr))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterTag)) > 0 {
-		tagBytes, err := httpserver.ParseHexQueryParam(c, QueryParameterTag, iotago.MaxTagLength)
+		tags, err := parseHexQueryParams(c, QueryParameterTag, iotago.MaxTagLength)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.NFTTag(tagBytes))
+		for _, tagBytes := range tags {
+			filters = append(filters, indexer.NFTTag(tagBytes))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterNotTag)) > 0 {
+		tags, err := parseHexQueryParams(c, QueryParameterNotTag, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, tagBytes := range tags {
+			filters = append(filters, indexer.NFTNotTag(tagBytes))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterTagPrefix)) > 0 {
+		prefixes, err := parseHexQueryParams(c, QueryParameterTagPrefix, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range prefixes {
+			filters = append(filters, indexer.NFTTagPrefix(prefix))
+		}
+	}
+
+	if len(c.QueryParam(QueryParameterTagContains)) > 0 {
+		substrings, err := parseUTF8QueryParams(c, QueryParameterTagContains, iotago.MaxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		for _, substring := range substrings {
+			filters = append(filters, indexer.NFTTagContains(substring))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCursor)) > 0 {
-		cursor, pageSize, err := s.parseCursorQueryParameter(c)
+		cursor, pageSize, backward, err := s.parseCursorQueryParameter(c)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.NFTCursor(cursor), indexer.NFTPageSize(pageSize))
+		if backward {
+			filters = append(filters, indexer.NFTPrevCursor(cursor), indexer.NFTPageSize(pageSize))
+		} else {
+			filters = append(filters, indexer.NFTCursor(cursor), indexer.NFTPageSize(pageSize))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCreatedBefore)) > 0 {
@@ -539,7 +862,7 @@ func (s *IndexerServer) nftsWithFilter(c echo.Context) (*outputsResponse, error)
 		filters = append(filters, indexer.NFTCreatedAfter(timestamp))
 	}
 
-	return outputsResponseFromResult(s.Indexer.NFTOutputsWithFilters(filters...))
+	return filters, nil
 }
 
 func (s *IndexerServer) foundryByID(c echo.Context) (*outputsResponse, error) {
@@ -548,10 +871,20 @@ func (s *IndexerServer) foundryByID(c echo.Context) (*outputsResponse, error) {
 		return nil, err
 	}
 
-	return singleOutputResponseFromResult(s.Indexer.FoundryOutput(foundryID))
+	return s.singleOutputResponseFromResult(c, s.Indexer.FoundryOutput(c.Request().Context(), foundryID))
 }
 
 func (s *IndexerServer) foundriesWithFilter(c echo.Context) (*outputsResponse, error) {
+	filters, err := s.foundriesFilters(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.outputsResponseFromResult(c, s.Indexer.FoundryOutputsWithFilters(c.Request().Context(), filters...))
+}
+
+// foundriesFilters parses the filters of the query parameters.
+func (s *IndexerServer) foundriesFilters(c echo.Context) ([]indexer.FoundryFilterOption, error) {
 	filters := []indexer.FoundryFilterOption{indexer.FoundryPageSize(s.pageSizeFromContext(c))}
 
 	if len(c.QueryParam(QueryParameterHasNativeTokens)) > 0 {
@@ -592,11 +925,15 @@ func (s *IndexerServer) foundriesWithFilter(c echo.Context) (*outputsResponse, e
 	}
 
 	if len(c.QueryParam(QueryParameterCursor)) > 0 {
-		cursor, pageSize, err := s.parseCursorQueryParameter(c)
+		cursor, pageSize, backward, err := s.parseCursorQueryParameter(c)
 		if err != nil {
 			return nil, err
 		}
-		filters = append(filters, indexer.FoundryCursor(cursor), indexer.FoundryPageSize(pageSize))
+		if backward {
+			filters = append(filters, indexer.FoundryPrevCursor(cursor), indexer.FoundryPageSize(pageSize))
+		} else {
+			filters = append(filters, indexer.FoundryCursor(cursor), indexer.FoundryPageSize(pageSize))
+		}
 	}
 
 	if len(c.QueryParam(QueryParameterCreatedBefore)) > 0 {
@@ -615,70 +952,80 @@ func (s *IndexerServer) foundriesWithFilter(c echo.Context) (*outputsResponse, e
 		filters = append(filters, indexer.FoundryCreatedAfter(timestamp))
 	}
 
-	return outputsResponseFromResult(s.Indexer.FoundryOutputsWithFilters(filters...))
+	return filters, nil
 }
 
-func singleOutputResponseFromResult(result *indexer.IndexerResult) (*outputsResponse, error) {
+func (s *IndexerServer) singleOutputResponseFromResult(c echo.Context, result *indexer.IndexerResult) (*outputsResponse, error) {
 	if result.Error != nil {
-		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading outputIDs failed: %s", result.Error)
+		return nil, errorFromResult(result)
 	}
 	if len(result.OutputIDs) == 0 {
 		return nil, errors.WithMessage(echo.ErrNotFound, "record not found")
 	}
 
-	return outputsResponseFromResult(result)
+	return s.outputsResponseFromResult(c, result)
 }
 
-func outputsResponseFromResult(result *indexer.IndexerResult) (*outputsResponse, error) {
+func (s *IndexerServer) outputsResponseFromResult(c echo.Context, result *indexer.IndexerResult) (*outputsResponse, error) {
 	if result.Error != nil {
-		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading outputIDs failed: %s", result.Error)
+		return nil, errorFromResult(result)
 	}
 
-	var cursor *string
-	if result.Cursor != nil {
-		// Add the pageSize to the cursor we expose in the API
-		cursorWithPageSize := fmt.Sprintf("%s.%d", *result.Cursor, result.PageSize)
-		cursor = &cursorWithPageSize
+	cursor, prevCursor, err := s.opaqueCursors(c, result.Cursor, result.PrevCursor, result.PageSize)
+	if err != nil {
+		return nil, err
 	}
 
 	return &outputsResponse{
 		LedgerIndex: result.LedgerIndex,
 		PageSize:    result.PageSize,
 		Cursor:      cursor,
+		PrevCursor:  prevCursor,
 		Items:       result.OutputIDs.ToHex(),
 	}, nil
 }
 
-func (s *IndexerServer) parseCursorQueryParameter(c echo.Context) (string, uint32, error) {
-	cursorWithPageSize := c.QueryParam(QueryParameterCursor)
-
-	components := strings.Split(cursorWithPageSize, ".")
-	if len(components) != 2 {
-		return "", 0, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("query parameter %s has wrong format", QueryParameterCursor))
+// opaqueCursors wraps the cursors and the pageSize into the opaque cursors we expose in the API.
+func (s *IndexerServer) opaqueCursors(c echo.Context, nextCursor *string, prevCursor *string, pageSize uint32) (*string, *string, error) {
+	var cursor *string
+	if nextCursor != nil {
+		opaqueCursor, err := s.encodeCursor(c, *nextCursor, pageSize, false)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(echo.ErrInternalServerError, "encoding cursor failed: %s", err)
+		}
+		cursor = &opaqueCursor
 	}
 
-	if len(components[0]) != indexer.CursorLength {
-		return "", 0, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("query parameter %s has wrong format", QueryParameterCursor))
+	var opaquePrevCursor *string
+	if prevCursor != nil {
+		opaqueCursor, err := s.encodeCursor(c, *prevCursor, pageSize, true)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(echo.ErrInternalServerError, "encoding cursor failed: %s", err)
+		}
+		opaquePrevCursor = &opaqueCursor
 	}
 
-	size, err := strconv.ParseUint(components[1], 10, 32)
-	if err != nil {
-		return "", 0, errors.WithMessage(httpserver.ErrInvalidParameter, fmt.Sprintf("query parameter %s has wrong format", QueryParameterCursor))
-	}
+	return cursor, opaquePrevCursor, nil
+}
 
-	pageSize := uint32(size)
-	if pageSize > uint32(s.RestAPILimitsMaxResults) {
-		pageSize = uint32(s.RestAPILimitsMaxResults)
+func errorFromResult(result *indexer.IndexerResult) error {
+	if errors.Is(result.Error, indexer.ErrQueryTimeout) {
+		return errors.WithMessagef(ErrQueryTimeout, "reading outputIDs failed: %s", result.Error)
+	}
+	if errors.Is(result.Error, indexer.ErrQueryTooExpensive) {
+		return errors.WithMessagef(httpserver.ErrInvalidParameter, "reading outputIDs failed: %s", result.Error)
 	}
 
-	return components[0], pageSize, nil
+	return errors.WithMessagef(echo.ErrInternalServerError, "reading outputIDs failed: %s", result.Error)
 }
 
+// pageSizeFromContext returns the page size of the request, limited to the maximum page size of the client.
+// Invalid page sizes fall back to the maximum, including 0, which would turn off the pagination of the query.
 func (s *IndexerServer) pageSizeFromContext(c echo.Context) uint32 {
-	pageSize := uint32(s.RestAPILimitsMaxResults)
+	pageSize := uint32(s.maxPageSize(c.Request().Context()))
 	if len(c.QueryParam(QueryParameterPageSize)) > 0 {
 		i, err := httpserver.ParseUint32QueryParam(c, QueryParameterPageSize, pageSize)
-		if err != nil {
+		if err != nil || i == 0 {
 			return pageSize
 		}
 		pageSize = i
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/iotaledger/inx-indexer/pkg/auth"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestPageSizeFromContext(t *testing.T) {
+	s := &IndexerServer{RestAPILimitsMaxResults: 1000}
+
+	tests := []struct {
+		name     string
+		query    string
+		client   *auth.Client
+		pageSize uint32
+	}{
+		{
+			name:     "no page size",
+			pageSize: 1000,
+		},
+		{
+			name:     "page size",
+			query:    "pageSize=10",
+			pageSize: 10,
+		},
+		{
+			name:     "zero",
+			query:    "pageSize=0",
+			pageSize: 1000,
+		},
+		{
+			name:     "above the maximum",
+			query:    "pageSize=5000",
+			pageSize: 1000,
+		},
+		{
+			name:     "invalid",
+			query:    "pageSize=abc",
+			pageSize: 1000,
+		},
+		{
+			name:     "zero with the maximum of the client",
+			query:    "pageSize=0",
+			client:   &auth.Client{Name: "explorer", MaxPageSize: 200},
+			pageSize: 200,
+		},
+		{
+			name:     "above the maximum of the client",
+			query:    "pageSize=500",
+			client:   &auth.Client{Name: "explorer", MaxPageSize: 200},
+			pageSize: 200,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := newCursorTestContext(RouteOutputsBasic, test.query, nil)
+			if test.client != nil {
+				c.SetRequest(c.Request().WithContext(auth.WithClient(c.Request().Context(), test.client)))
+			}
+
+			if pageSize := s.pageSizeFromContext(c); pageSize != test.pageSize {
+				t.Errorf("expected page size %d, got %d", test.pageSize, pageSize)
+			}
+		})
+	}
+}
+
+// TestRepeatedFilterParameters checks that the values of a repeated filter parameter are combined with "or",
+// instead of the last value replacing the earlier ones, and that the negated filters exclude from the matches of the others.
+func TestRepeatedFilterParameters(t *testing.T) {
+	const hrp = iotago.PrefixTestnet
+
+	addressA := &iotago.Ed25519Address{0xa}
+	addressB := &iotago.Ed25519Address{0xb}
+	addressC := &iotago.Ed25519Address{0xc}
+	senderX := &iotago.Ed25519Address{0x1}
+	senderY := &iotago.Ed25519Address{0x2}
+
+	output := func(address iotago.Address, sender iotago.Address, tag string) iotago.Output {
+		features := iotago.Features{&iotago.SenderFeature{Address: sender}}
+		if tag != "" {
+			features = append(features, &iotago.TagFeature{Tag: []byte(tag)})
+		}
+
+		return &iotago.BasicOutput{
+			Amount:     1_000_000,
+			Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: address}},
+			Features:   features,
+		}
+	}
+
+	outputs := map[iotago.OutputID]iotago.Output{
+		{1}: output(addressA, senderX, "a"),
+		{2}: output(addressB, senderY, "b"),
+		{3}: output(addressC, senderX, "b"),
+		{4}: output(addressA, senderY, ""),
+	}
+
+	s := &IndexerServer{
+		Indexer:                 newTestIndexerWithOutputs(t, 10, outputs),
+		Bech32HRP:               hrp,
+		RestAPILimitsMaxResults: 1000,
+		cursorSigningKey:        []byte("key"),
+	}
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		outputIDs []byte
+	}{
+		{
+			name:      "address",
+			query:     url.Values{QueryParameterAddress: {addressA.Bech32(hrp)}},
+			outputIDs: []byte{1, 4},
+		},
+		{
+			name:      "repeated address",
+			query:     url.Values{QueryParameterAddress: {addressA.Bech32(hrp), addressB.Bech32(hrp)}},
+			outputIDs: []byte{1, 2, 4},
+		},
+		{
+			name:      "repeated tag",
+			query:     url.Values{QueryParameterTag: {iotago.EncodeHex([]byte("a")), iotago.EncodeHex([]byte("b"))}},
+			outputIDs: []byte{1, 2, 3},
+		},
+		{
+			name:      "not address",
+			query:     url.Values{QueryParameterNotAddress: {addressA.Bech32(hrp)}},
+			outputIDs: []byte{2, 3},
+		},
+		{
+			name:      "repeated not address",
+			query:     url.Values{QueryParameterNotAddress: {addressA.Bech32(hrp), addressB.Bech32(hrp)}},
+			outputIDs: []byte{3},
+		},
+		{
+			name: "repeated address and not sender",
+			query: url.Values{
+				QueryParameterAddress:   {addressA.Bech32(hrp), addressC.Bech32(hrp)},
+				QueryParameterNotSender: {senderX.Bech32(hrp)},
+			},
+			outputIDs: []byte{4},
+		},
+		{
+			name: "sender and not address",
+			query: url.Values{
+				QueryParameterSender:     {senderX.Bech32(hrp)},
+				QueryParameterNotAddress: {addressC.Bech32(hrp)},
+			},
+			outputIDs: []byte{1},
+		},
+		{
+			name: "repeated tag and not tag",
+			query: url.Values{
+				QueryParameterTag:    {iotago.EncodeHex([]byte("a")), iotago.EncodeHex([]byte("b"))},
+				QueryParameterNotTag: {iotago.EncodeHex([]byte("a"))},
+			},
+			outputIDs: []byte{2, 3},
+		},
+		{
+			name: "address and the same not address",
+			query: url.Values{
+				QueryParameterAddress:    {addressA.Bech32(hrp)},
+				QueryParameterNotAddress: {addressA.Bech32(hrp)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := newCursorTestContext(RouteOutputsBasic, test.query.Encode(), nil)
+
+			response, err := s.basicOutputsWithFilter(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := make([]string, 0, len(test.outputIDs))
+			for _, outputID := range test.outputIDs {
+				expected = append(expected, iotago.OutputID{outputID}.ToHex())
+			}
+			items := append([]string{}, response.Items...)
+			sort.Strings(items)
+
+			if !reflect.DeepEqual(items, expected) {
+				t.Errorf("expected the outputs %v, got %v", expected, items)
+			}
+		})
+	}
+}
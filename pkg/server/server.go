@@ -1,25 +1,173 @@
 package server
 
 import (
+	"context"
+	"crypto/rand"
+	"sync/atomic"
+	"time"
+
 	"github.com/labstack/echo/v4"
 
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
 	"github.com/iotaledger/inx-indexer/pkg/indexer"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+const (
+	cursorSigningKeyLength = 32
+)
+
 type IndexerServer struct {
 	Indexer                 *indexer.Indexer
 	Bech32HRP               iotago.NetworkPrefix
 	RestAPILimitsMaxResults int
+
+	// cursorSigningKey is the key used to sign the cursors returned by the API.
+	cursorSigningKey []byte
+
+	graphQLEnabled       bool
+	graphQLMaxDepth      int
+	graphQLMaxComplexity int
+
+	// confirmedMilestoneIndex returns the index of the confirmed milestone of the node.
+	confirmedMilestoneIndex func() uint32
+	// maxReadyLag is the maximum amount of milestones the indexer may lag behind the node before it is not ready.
+	maxReadyLag uint32
+	// initialized is set once the initial ledger was imported.
+	initialized atomic.Bool
+
+	// slowQueries logs the queries that took longer than its threshold, nil if disabled.
+	slowQueries *slowQueryLog
+
+	// responseCache keeps the responses of the current ledger index, nil if disabled.
+	responseCache *responseCache
+
+	// exportEnabled is set if the CSV and NDJSON export routes are enabled.
+	exportEnabled bool
+	// exportSlots limits the amount of concurrent exports, nil if unlimited.
+	exportSlots chan struct{}
+
+	// authenticator checks the API keys of the requests and enforces the limits of the clients, nil if disabled.
+	authenticator *auth.Authenticator
+}
+
+// WithCursorSigningKey sets the key used to sign the cursors returned by the API.
+// A random key is used if none is given, which invalidates all cursors on restart.
+func WithCursorSigningKey(key []byte) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.cursorSigningKey = key
+	}
+}
+
+// WithGraphQL enables the GraphQL endpoint with the given limits for the depth and the complexity of the queries.
+func WithGraphQL(maxDepth int, maxComplexity int) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.graphQLEnabled = true
+		s.graphQLMaxDepth = maxDepth
+		s.graphQLMaxComplexity = maxComplexity
+	}
+}
+
+// WithReadiness sets the function that returns the confirmed milestone index of the node and the maximum amount
+// of milestones the indexer may lag behind it before the readiness route fails.
+func WithReadiness(confirmedMilestoneIndex func() uint32, maxLag uint32) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.confirmedMilestoneIndex = confirmedMilestoneIndex
+		s.maxReadyLag = maxLag
+	}
+}
+
+// WithSlowQueryLog logs the queries that took longer than the threshold together with their route and filter fingerprint.
+// The slow queries of up to maxFingerprints combinations of route and filters are aggregated for the slow queries route.
+func WithSlowQueryLog(log *logger.Logger, threshold time.Duration, maxFingerprints int) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.slowQueries = newSlowQueryLog(log, threshold, maxFingerprints)
+	}
+}
+
+// WithResponseCache caches up to maxEntries responses of the current ledger index and adds an ETag to the responses,
+// so clients get a 304 if their results did not change.
+func WithResponseCache(maxEntries int) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.responseCache = newResponseCache(maxEntries)
+	}
+}
+
+// WithExport enables the routes that stream all outputs matching the filters as CSV or NDJSON.
+// At most maxConcurrent exports run at the same time, 0 means unlimited.
+func WithExport(maxConcurrent int) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.exportEnabled = true
+		if maxConcurrent > 0 {
+			s.exportSlots = make(chan struct{}, maxConcurrent)
+		}
+	}
+}
+
+// WithAuthenticator requires an API key or JWT for all routes except the health routes.
+// The maximum page size of a client overrides the maximum page size of the API.
+func WithAuthenticator(authenticator *auth.Authenticator) options.Option[IndexerServer] {
+	return func(s *IndexerServer) {
+		s.authenticator = authenticator
+	}
 }
 
-func NewIndexerServer(indexer *indexer.Indexer, group *echo.Group, prefix iotago.NetworkPrefix, maxPageSize int) *IndexerServer {
-	s := &IndexerServer{
+// NewIndexerServer adds the routes of the API to the given group.
+// The output routes answer with 503 until MarkInitialized was called, the health routes are available immediately.
+func NewIndexerServer(indexer *indexer.Indexer, group *echo.Group, prefix iotago.NetworkPrefix, maxPageSize int, opts ...options.Option[IndexerServer]) (*IndexerServer, error) {
+	s := options.Apply(&IndexerServer{
 		Indexer:                 indexer,
 		Bech32HRP:               prefix,
 		RestAPILimitsMaxResults: maxPageSize,
+	}, opts)
+
+	if len(s.cursorSigningKey) == 0 {
+		s.cursorSigningKey = make([]byte, cursorSigningKeyLength)
+		if _, err := rand.Read(s.cursorSigningKey); err != nil {
+			return nil, err
+		}
+	}
+
+	s.configureHealthRoutes(group)
+
+	// the authentication is checked first, so clients are rejected and rate limited even while the ledger is imported
+	authGroup := group.Group("")
+	if s.authenticator != nil {
+		authGroup.Use(s.authenticator.Middleware())
+	}
+
+	// the exports are streamed, so they are neither buffered by the response cache nor measured as slow queries
+	if s.exportEnabled {
+		s.configureExportRoutes(authGroup.Group("", s.requireInitialized))
+	}
+
+	outputsGroup := authGroup.Group("", s.requireInitialized)
+	if s.slowQueries != nil {
+		s.configureSlowQueryRoutes(authGroup)
+		outputsGroup.Use(s.slowQueryMiddleware)
+	}
+	if s.responseCache != nil {
+		s.configureResponseCache()
+		outputsGroup.Use(s.responseCacheMiddleware)
+	}
+	s.configureRoutes(outputsGroup)
+
+	if s.graphQLEnabled {
+		if err := s.configureGraphQLRoutes(outputsGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// maxPageSize returns the maximum amount of results per page for the client of the request.
+func (s *IndexerServer) maxPageSize(ctx context.Context) int {
+	if client := auth.ClientFromContext(ctx); client != nil && client.MaxPageSize > 0 {
+		return client.MaxPageSize
 	}
-	s.configureRoutes(group)
 
-	return s
+	return s.RestAPILimitsMaxResults
 }
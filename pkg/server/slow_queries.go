@@ -0,0 +1,277 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/iotaledger/hive.go/core/logger"
+)
+
+const (
+	// RouteSlowQueries is the route for the slow queries of the API.
+	// GET returns the slow queries grouped by their route and filter fingerprint, ordered by their total duration.
+	RouteSlowQueries = "/debug/slow-queries"
+
+	// fingerprintValue replaces the values of the filters in a fingerprint.
+	fingerprintValue = "?"
+)
+
+// slowQueryStats are the statistics of the slow queries with the same route and fingerprint.
+type slowQueryStats struct {
+	method      string
+	route       string
+	fingerprint string
+	count       uint64
+	total       time.Duration
+	max         time.Duration
+	maxPageSize uint32
+	lastSeen    time.Time
+}
+
+// slowQueryResponse defines the statistics of the slow queries with the same route and fingerprint.
+type slowQueryResponse struct {
+	// The HTTP method of the queries.
+	Method string `json:"method"`
+	// The route of the queries.
+	Route string `json:"route"`
+	// The normalized filters of the queries, without their values.
+	Fingerprint string `json:"fingerprint"`
+	// The database engine that answered the queries.
+	Engine string `json:"engine"`
+	// The amount of slow queries.
+	Count uint64 `json:"count"`
+	// The total duration of the slow queries in milliseconds.
+	TotalDurationMs int64 `json:"totalDurationMs"`
+	// The average duration of the slow queries in milliseconds.
+	AvgDurationMs int64 `json:"avgDurationMs"`
+	// The maximum duration of a slow query in milliseconds.
+	MaxDurationMs int64 `json:"maxDurationMs"`
+	// The biggest page size of the slow queries.
+	MaxPageSize uint32 `json:"maxPageSize"`
+	// The unix timestamp of the last slow query.
+	LastSeen int64 `json:"lastSeen"`
+}
+
+// slowQueriesResponse defines the response of a GET slow queries REST API call.
+type slowQueriesResponse struct {
+	// The minimum duration of a query to be logged as slow query in milliseconds.
+	ThresholdMs int64 `json:"thresholdMs"`
+	// The slow queries grouped by route and fingerprint.
+	Items []*slowQueryResponse `json:"items"`
+}
+
+// slowQueryLog logs the queries that took longer than the threshold and aggregates them by route and fingerprint.
+type slowQueryLog struct {
+	log             *logger.Logger
+	threshold       time.Duration
+	maxFingerprints int
+
+	statsLock sync.Mutex
+	stats     map[string]*slowQueryStats
+}
+
+func newSlowQueryLog(log *logger.Logger, threshold time.Duration, maxFingerprints int) *slowQueryLog {
+	return &slowQueryLog{
+		log:             log,
+		threshold:       threshold,
+		maxFingerprints: maxFingerprints,
+		stats:           make(map[string]*slowQueryStats),
+	}
+}
+
+// add records a slow query. If the maximum amount of fingerprints is reached, the least recently seen one is dropped.
+func (l *slowQueryLog) add(method string, route string, fingerprint string, pageSize uint32, duration time.Duration) {
+	l.statsLock.Lock()
+	defer l.statsLock.Unlock()
+
+	key := method + " " + route + " " + fingerprint
+	stats, exists := l.stats[key]
+	if !exists {
+		if l.maxFingerprints > 0 && len(l.stats) >= l.maxFingerprints {
+			l.evictLeastRecentlySeen()
+		}
+
+		stats = &slowQueryStats{
+			method:      method,
+			route:       route,
+			fingerprint: fingerprint,
+		}
+		l.stats[key] = stats
+	}
+
+	stats.count++
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	if pageSize > stats.maxPageSize {
+		stats.maxPageSize = pageSize
+	}
+	stats.lastSeen = time.Now()
+}
+
+func (l *slowQueryLog) evictLeastRecentlySeen() {
+	var oldestKey string
+	var oldest time.Time
+	for key, stats := range l.stats {
+		if oldestKey == "" || stats.lastSeen.Before(oldest) {
+			oldestKey = key
+			oldest = stats.lastSeen
+		}
+	}
+	delete(l.stats, oldestKey)
+}
+
+func (l *slowQueryLog) response(engine string) *slowQueriesResponse {
+	l.statsLock.Lock()
+	defer l.statsLock.Unlock()
+
+	items := make([]*slowQueryResponse, 0, len(l.stats))
+	for _, stats := range l.stats {
+		items = append(items, &slowQueryResponse{
+			Method:          stats.method,
+			Route:           stats.route,
+			Fingerprint:     stats.fingerprint,
+			Engine:          engine,
+			Count:           stats.count,
+			TotalDurationMs: stats.total.Milliseconds(),
+			AvgDurationMs:   (stats.total / time.Duration(stats.count)).Milliseconds(),
+			MaxDurationMs:   stats.max.Milliseconds(),
+			MaxPageSize:     stats.maxPageSize,
+			LastSeen:        stats.lastSeen.Unix(),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].TotalDurationMs > items[j].TotalDurationMs
+	})
+
+	return &slowQueriesResponse{
+		ThresholdMs: l.threshold.Milliseconds(),
+		Items:       items,
+	}
+}
+
+// FilterFingerprint returns the normalized filters of a request without their values.
+// Unlike FilterSet, it keeps the values of boolean filters and whether a cursor was given, since they change the query plan.
+// The fingerprint of a filter expression is its JSON with all values except booleans replaced by "?".
+// It has to be called after the request was handled, so the filters of a POST body are included.
+func FilterFingerprint(c echo.Context) string {
+	if filterExpression, ok := c.Get(contextKeyFilterExpression).([]byte); ok {
+		var filter interface{}
+		if err := json.Unmarshal(filterExpression, &filter); err == nil {
+			if normalized, err := json.Marshal(normalizeFilterExpression(filter)); err == nil {
+				return string(normalized)
+			}
+		}
+
+		return FilterSetExpression
+	}
+
+	if strings.HasSuffix(c.Path(), "/query") {
+		// the request body was invalid
+		return FilterSetExpression
+	}
+
+	query := c.Request().URL.Query()
+	filters := make([]string, 0)
+	for name, values := range query {
+		if _, isFilter := filterQueryParameters[name]; !isFilter {
+			continue
+		}
+
+		if len(values) > 0 && (values[0] == "true" || values[0] == "false") {
+			filters = append(filters, name+"="+values[0])
+
+			continue
+		}
+		filters = append(filters, name)
+	}
+	sort.Strings(filters)
+
+	if len(query.Get(QueryParameterCursor)) > 0 {
+		filters = append(filters, QueryParameterCursor)
+	}
+
+	if len(filters) == 0 {
+		return FilterSetNone
+	}
+
+	return strings.Join(filters, ",")
+}
+
+// normalizeFilterExpression replaces the values of a filter expression, the operators and the names of the filters are kept.
+func normalizeFilterExpression(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			normalized[key] = normalizeFilterExpression(child)
+		}
+
+		return normalized
+
+	case []interface{}:
+		// lists of values, e.g. addresses, are collapsed, so the amount of values does not create a new fingerprint
+		normalized := make([]interface{}, 0, len(value))
+		for _, child := range value {
+			switch child.(type) {
+			case map[string]interface{}, []interface{}:
+				normalized = append(normalized, normalizeFilterExpression(child))
+			default:
+				return fingerprintValue
+			}
+		}
+
+		return normalized
+
+	case bool:
+		return value
+
+	default:
+		return fingerprintValue
+	}
+}
+
+// slowQueryMiddleware measures the duration of the requests and logs the ones that took longer than the threshold.
+func (s *IndexerServer) slowQueryMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ts := time.Now()
+		err := next(c)
+
+		duration := time.Since(ts)
+		if duration < s.slowQueries.threshold {
+			return err
+		}
+
+		route := c.Path()
+		fingerprint := FilterFingerprint(c)
+		pageSize := s.pageSizeFromContext(c)
+		engine := string(s.Indexer.Engine())
+
+		s.slowQueries.log.Warnw("Slow query",
+			"method", c.Request().Method,
+			"route", route,
+			"fingerprint", fingerprint,
+			"pageSize", pageSize,
+			"engine", engine,
+			"duration", duration.Truncate(time.Millisecond).String(),
+		)
+		s.slowQueries.add(c.Request().Method, route, fingerprint, pageSize, duration)
+
+		return err
+	}
+}
+
+func (s *IndexerServer) configureSlowQueryRoutes(routeGroup *echo.Group) {
+
+	routeGroup.GET(RouteSlowQueries, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, s.slowQueries.response(string(s.Indexer.Engine())))
+	})
+}
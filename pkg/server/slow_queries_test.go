@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterFingerprint(t *testing.T) {
+	tests := []struct {
+		name             string
+		path             string
+		query            string
+		filterExpression string
+		fingerprint      string
+	}{
+		{
+			name:        "no filters",
+			path:        RouteOutputsBasic,
+			fingerprint: FilterSetNone,
+		},
+		{
+			name:        "only paging parameters",
+			path:        RouteOutputsBasic,
+			query:       "pageSize=10",
+			fingerprint: FilterSetNone,
+		},
+		{
+			name:        "values are removed and the filters sorted",
+			path:        RouteOutputsBasic,
+			query:       "tag=0x01&address=rms1abc&pageSize=10",
+			fingerprint: "address,tag",
+		},
+		{
+			name:        "repeated filters",
+			path:        RouteOutputsBasic,
+			query:       "tag=0x01&tag=0x02&tag=0x03",
+			fingerprint: "tag",
+		},
+		{
+			name:        "boolean filters keep their value",
+			path:        RouteOutputsBasic,
+			query:       "hasTimelock=false&address=rms1abc&hasNativeTokens=true",
+			fingerprint: "address,hasNativeTokens=true,hasTimelock=false",
+		},
+		{
+			name:        "cursor",
+			path:        RouteOutputsBasic,
+			query:       "address=rms1abc&cursor=abc&pageSize=10",
+			fingerprint: "address,cursor",
+		},
+		{
+			name:        "only a cursor",
+			path:        RouteOutputsBasic,
+			query:       "cursor=abc",
+			fingerprint: QueryParameterCursor,
+		},
+		{
+			name:        "empty cursor",
+			path:        RouteOutputsBasic,
+			query:       "address=rms1abc&cursor=",
+			fingerprint: "address",
+		},
+		{
+			name:             "filter expression",
+			path:             RouteOutputsBasicQuery,
+			filterExpression: `{"and":[{"address":"rms1abc"},{"not":{"hasTimelock":true}},{"tag":["0x01","0x02"]},{"createdAfter":1700000000}]}`,
+			fingerprint:      `{"and":[{"address":"?"},{"not":{"hasTimelock":true}},{"tag":"?"},{"createdAfter":"?"}]}`,
+		},
+		{
+			// the query parameters are ignored if a filter expression was given
+			name:             "filter expression with query parameters",
+			path:             RouteOutputsBasicQuery,
+			query:            "cursor=abc",
+			filterExpression: `{"sender":"rms1abc"}`,
+			fingerprint:      `{"sender":"?"}`,
+		},
+		{
+			name:             "invalid filter expression",
+			path:             RouteOutputsBasicQuery,
+			filterExpression: `{"sender":`,
+			fingerprint:      FilterSetExpression,
+		},
+		{
+			name:        "query route with an invalid body",
+			path:        RouteOutputsBasicQuery,
+			query:       "address=rms1abc",
+			fingerprint: FilterSetExpression,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := newCursorTestContext(test.path, test.query, nil)
+			if test.filterExpression != "" {
+				c.Set(contextKeyFilterExpression, []byte(test.filterExpression))
+			}
+
+			if fingerprint := FilterFingerprint(c); fingerprint != test.fingerprint {
+				t.Errorf("expected the fingerprint %s, got %s", test.fingerprint, fingerprint)
+			}
+		})
+	}
+}
+
+func TestNormalizeFilterExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		normalized string
+	}{
+		{
+			name:       "values",
+			expression: `{"address":"rms1abc","createdAfter":1700000000,"tag":null}`,
+			normalized: `{"address":"?","createdAfter":"?","tag":"?"}`,
+		},
+		{
+			name:       "booleans",
+			expression: `{"hasExpiration":false,"hasNativeTokens":true}`,
+			normalized: `{"hasExpiration":false,"hasNativeTokens":true}`,
+		},
+		{
+			// the amount of values does not change the fingerprint
+			name:       "collapsed value list",
+			expression: `{"address":["rms1abc","rms1def","rms1ghi"]}`,
+			normalized: `{"address":"?"}`,
+		},
+		{
+			name:       "single value list",
+			expression: `{"address":["rms1abc"]}`,
+			normalized: `{"address":"?"}`,
+		},
+		{
+			// lists of expressions are kept, their order and amount are part of the structure
+			name:       "operators",
+			expression: `{"or":[{"address":"rms1abc"},{"and":[{"sender":["rms1def"]},{"not":{"hasTimelock":true}}]}]}`,
+			normalized: `{"or":[{"address":"?"},{"and":[{"sender":"?"},{"not":{"hasTimelock":true}}]}]}`,
+		},
+		{
+			name:       "list of values and expressions",
+			expression: `{"or":[{"address":"rms1abc"},"rms1def"]}`,
+			normalized: `{"or":"?"}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var expression interface{}
+			if err := json.Unmarshal([]byte(test.expression), &expression); err != nil {
+				t.Fatal(err)
+			}
+
+			normalized, err := json.Marshal(normalizeFilterExpression(expression))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(normalized) != test.normalized {
+				t.Errorf("expected %s, got %s", test.normalized, normalized)
+			}
+		})
+	}
+}
+
+func TestSlowQueryLogEviction(t *testing.T) {
+	l := newSlowQueryLog(nil, 100*time.Millisecond, 2)
+
+	// fingerprints returns the fingerprints of the log
+	fingerprints := func() []string {
+		var result []string
+		for _, item := range l.response("sqlite").Items {
+			result = append(result, item.Fingerprint)
+		}
+
+		return result
+	}
+
+	l.add("GET", RouteOutputsBasic, "address", 10, 300*time.Millisecond)
+	l.add("GET", RouteOutputsBasic, "tag", 10, 200*time.Millisecond)
+
+	// the address fingerprint was seen more recently than the tag fingerprint
+	now := time.Now()
+	l.stats["GET "+RouteOutputsBasic+" address"].lastSeen = now
+	l.stats["GET "+RouteOutputsBasic+" tag"].lastSeen = now.Add(-time.Minute)
+
+	// known fingerprints are aggregated even if the log is full
+	l.add("GET", RouteOutputsBasic, "address", 50, 400*time.Millisecond)
+	if result := fingerprints(); !reflect.DeepEqual(result, []string{"address", "tag"}) {
+		t.Fatalf("expected both fingerprints, got %v", result)
+	}
+
+	l.add("GET", RouteOutputsBasic, "sender", 10, 100*time.Millisecond)
+	if result := fingerprints(); !reflect.DeepEqual(result, []string{"address", "sender"}) {
+		t.Fatalf("expected the least recently seen fingerprint to be dropped, got %v", result)
+	}
+
+	address := l.response("sqlite").Items[0]
+	if address.Count != 2 || address.TotalDurationMs != 700 || address.AvgDurationMs != 350 || address.MaxDurationMs != 400 || address.MaxPageSize != 50 {
+		t.Errorf("expected the statistics of the address fingerprint to be kept, got %+v", address)
+	}
+
+	// the same fingerprint of another route is another group
+	l.add("POST", RouteOutputsBasicQuery, "address", 10, 100*time.Millisecond)
+	if len(l.stats) != 2 {
+		t.Errorf("expected the log to stay at its maximum of 2 fingerprints, got %d", len(l.stats))
+	}
+	if _, exists := l.stats["POST "+RouteOutputsBasicQuery+" address"]; !exists {
+		t.Error("expected the fingerprint of the query route to be added")
+	}
+
+	// the amount of fingerprints is unlimited if the maximum is 0
+	unlimited := newSlowQueryLog(nil, 100*time.Millisecond, 0)
+	for i := 0; i < 100; i++ {
+		unlimited.add("GET", RouteOutputsBasic, string(rune('a'+i)), 10, time.Second)
+	}
+	if len(unlimited.stats) != 100 {
+		t.Errorf("expected 100 fingerprints, got %d", len(unlimited.stats))
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+func (s *IndexerServer) storageDepositReturns(c echo.Context) (*storageDepositReturnsResponse, error) {
+	address, err := s.parseBech32AddressParam(c, ParameterBech32Address)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := s.pageSizeFromContext(c)
+	var cursor *string
+	var backward bool
+	if len(c.QueryParam(QueryParameterCursor)) > 0 {
+		indexerCursor, cursorPageSize, cursorBackward, err := s.parseCursorQueryParameter(c)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &indexerCursor
+		pageSize = cursorPageSize
+		backward = cursorBackward
+	}
+
+	result := s.Indexer.StorageDepositReturns(c.Request().Context(), address, pageSize, cursor, backward)
+	if result.Error != nil {
+		if errors.Is(result.Error, indexer.ErrQueryTimeout) {
+			return nil, errors.WithMessagef(ErrQueryTimeout, "reading storage deposit returns failed: %s", result.Error)
+		}
+
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading storage deposit returns failed: %s", result.Error)
+	}
+
+	nextCursor, prevCursor, err := s.opaqueCursors(c, result.Cursor, result.PrevCursor, result.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storageDepositReturnResponse, 0, len(result.StorageDepositReturns))
+	for _, storageDepositReturn := range result.StorageDepositReturns {
+		item := &storageDepositReturnResponse{
+			OutputID:   storageDepositReturn.OutputID.ToHex(),
+			OutputType: storageDepositReturn.OutputType,
+			Owner:      storageDepositReturn.Owner.Bech32(s.Bech32HRP),
+			Amount:     strconv.FormatUint(storageDepositReturn.Amount, 10),
+		}
+		if storageDepositReturn.ExpirationTime != nil {
+			expirationTime := uint32(storageDepositReturn.ExpirationTime.Unix())
+			item.ExpirationTime = &expirationTime
+		}
+		items = append(items, item)
+	}
+
+	return &storageDepositReturnsResponse{
+		LedgerIndex: result.LedgerIndex,
+		PageSize:    result.PageSize,
+		Cursor:      nextCursor,
+		PrevCursor:  prevCursor,
+		Items:       items,
+	}, nil
+}
@@ -1,5 +1,9 @@
 package server
 
+import (
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
 // outputsResponse defines the response of a GET outputs REST API call.
 type outputsResponse struct {
 	// The ledger index at which these outputs where available at.
@@ -8,6 +12,74 @@ type outputsResponse struct {
 	PageSize uint32 `json:"pageSize"`
 	// The cursor to use for getting the next results.
 	Cursor *string `json:"cursor,omitempty"`
+	// The cursor to use for getting the previous results.
+	PrevCursor *string `json:"prevCursor,omitempty"`
 	// The output IDs (transaction hash + output index) of the outputs on this address.
 	Items []string `json:"items"`
 }
+
+// addressSummaryResponse defines the response of a GET address summary REST API call.
+type addressSummaryResponse struct {
+	// The ledger index at which all counts were taken.
+	LedgerIndex uint32 `json:"ledgerIndex"`
+	// The amount of basic outputs owned by the address.
+	BasicOutputs int64 `json:"basicOutputs"`
+	// The amount of NFT outputs owned by the address.
+	NFTOutputs int64 `json:"nftOutputs"`
+	// The amount of alias outputs controlled by the address.
+	AliasOutputs aliasOutputsSummary `json:"aliasOutputs"`
+	// The amount of foundry outputs controlled by the address, only alias addresses can control foundries.
+	FoundryOutputs int64 `json:"foundryOutputs"`
+	// The amount of basic and NFT outputs with pending unlock conditions.
+	Pending pendingConditionsSummary `json:"pending"`
+}
+
+// aliasOutputsSummary defines the amount of alias outputs controlled by an address.
+type aliasOutputsSummary struct {
+	// The amount of alias outputs the address is the state controller of.
+	StateController int64 `json:"stateController"`
+	// The amount of alias outputs the address is the governor of.
+	Governor int64 `json:"governor"`
+}
+
+// pendingConditionsSummary defines the amount of basic and NFT outputs with pending unlock conditions related to an address.
+type pendingConditionsSummary struct {
+	// The amount of outputs owned by the address that are still timelocked.
+	Timelocked int64 `json:"timelocked"`
+	// The amount of outputs owned by the address that did not expire yet.
+	Expiring int64 `json:"expiring"`
+	// The amount of outputs that return to the address once they expire.
+	ExpirationReturn int64 `json:"expirationReturn"`
+	// The amount of outputs owned by the address that have to return a storage deposit.
+	StorageDepositReturn int64 `json:"storageDepositReturn"`
+	// The amount of outputs that return a storage deposit to the address.
+	StorageDepositReturnAddress int64 `json:"storageDepositReturnAddress"`
+}
+
+// storageDepositReturnsResponse defines the response of a GET storage deposit returns REST API call.
+type storageDepositReturnsResponse struct {
+	// The ledger index at which these outputs where available at.
+	LedgerIndex uint32 `json:"ledgerIndex"`
+	// The maximum count of results that are returned by the node.
+	PageSize uint32 `json:"pageSize"`
+	// The cursor to use for getting the next results.
+	Cursor *string `json:"cursor,omitempty"`
+	// The cursor to use for getting the previous results.
+	PrevCursor *string `json:"prevCursor,omitempty"`
+	// The outputs that have to return a storage deposit to the address.
+	Items []*storageDepositReturnResponse `json:"items"`
+}
+
+// storageDepositReturnResponse defines an output that has to return a storage deposit.
+type storageDepositReturnResponse struct {
+	// The output ID (transaction hash + output index) of the output.
+	OutputID string `json:"outputId"`
+	// The type of the output.
+	OutputType iotago.OutputType `json:"outputType"`
+	// The address that owns the output and has to return the storage deposit.
+	Owner string `json:"owner"`
+	// The amount of base tokens that have to be returned.
+	Amount string `json:"amount"`
+	// The unix timestamp at which the output returns to the expiration return address.
+	ExpirationTime *uint32 `json:"expirationTime,omitempty"`
+}
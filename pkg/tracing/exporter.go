@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// OTLPProtocolGRPC sends the spans to the collector using gRPC.
+	OTLPProtocolGRPC = "grpc"
+	// OTLPProtocolHTTP sends the spans to the collector using HTTP with protobuf payloads.
+	OTLPProtocolHTTP = "http"
+)
+
+// NewOTLPExporter creates an exporter that sends the spans to an OpenTelemetry collector.
+// The connection is established in the background, so the collector does not need to be reachable at startup.
+func NewOTLPExporter(ctx context.Context, protocol string, endpoint string, insecure bool) (sdktrace.SpanExporter, error) {
+	switch protocol {
+	case OTLPProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		return otlptrace.New(ctx, otlptracegrpc.NewClient(opts...))
+	case OTLPProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		return otlptrace.New(ctx, otlptracehttp.NewClient(opts...))
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol: %s, supported protocols: %s, %s", protocol, OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+}
+
+// fileExporter writes the spans as JSON to a file and closes the file on shutdown.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+// NewFileExporter creates an exporter that appends the spans as JSON to the file at the given path.
+func NewFileExporter(path string) (sdktrace.SpanExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening trace file (%s) failed: %w", path, err)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return &fileExporter{
+		Exporter: exporter,
+		file:     file,
+	}, nil
+}
+
+// Shutdown stops the exporter and closes the file.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	if err := e.Exporter.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return e.file.Close()
+}
+
+// NewStdoutExporter creates an exporter that prints the spans as indented JSON to stdout.
+func NewStdoutExporter() (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithPrettyPrint())
+}
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormPluginName = "tracing"
+	gormSpanKey    = "tracing:span"
+)
+
+// GormPlugin creates a span for every statement that is executed by gorm.
+// The spans are children of the span in the context of the statement, so queries need to be run with WithContext.
+type GormPlugin struct {
+	dbSystem attribute.KeyValue
+}
+
+// NewGormPlugin creates a new GormPlugin.
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name returns the name of the plugin.
+func (p *GormPlugin) Name() string {
+	return gormPluginName
+}
+
+// Initialize registers the callbacks of the plugin at the given database.
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		p.dbSystem = semconv.DBSystemPostgreSQL
+	case "sqlite":
+		p.dbSystem = semconv.DBSystemSqlite
+	default:
+		p.dbSystem = semconv.DBSystemOtherSQL
+	}
+
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("*").Register(gormPluginName+":before_create", p.before("gorm.create")),
+		callbacks.Create().After("*").Register(gormPluginName+":after_create", p.after),
+		callbacks.Query().Before("*").Register(gormPluginName+":before_query", p.before("gorm.query")),
+		callbacks.Query().After("*").Register(gormPluginName+":after_query", p.after),
+		callbacks.Update().Before("*").Register(gormPluginName+":before_update", p.before("gorm.update")),
+		callbacks.Update().After("*").Register(gormPluginName+":after_update", p.after),
+		callbacks.Delete().Before("*").Register(gormPluginName+":before_delete", p.before("gorm.delete")),
+		callbacks.Delete().After("*").Register(gormPluginName+":after_delete", p.after),
+		callbacks.Row().Before("*").Register(gormPluginName+":before_row", p.before("gorm.row")),
+		callbacks.Row().After("*").Register(gormPluginName+":after_row", p.after),
+		callbacks.Raw().Before("*").Register(gormPluginName+":before_raw", p.before("gorm.raw")),
+		callbacks.Raw().After("*").Register(gormPluginName+":after_raw", p.after),
+	} {
+		if err != nil {
+			return errors.Wrap(err, "registering tracing callback failed")
+		}
+	}
+
+	return nil
+}
+
+func (p *GormPlugin) before(spanName string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		// subqueries are built in a dry run, they are part of the statement that contains them
+		if db.DryRun || db.Statement.Context == nil {
+			return
+		}
+
+		_, span := Tracer().Start(db.Statement.Context, spanName, trace.WithSpanKind(trace.SpanKindClient))
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, exists := db.InstanceGet(gormSpanKey)
+	if !exists {
+		return
+	}
+
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	// the statement contains placeholders instead of the values, so no addresses or tags end up in the traces
+	span.SetAttributes(
+		p.dbSystem,
+		semconv.DBStatementKey.String(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBSQLTableKey.String(db.Statement.Table))
+	}
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
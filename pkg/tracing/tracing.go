@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer that creates the spans of the indexer.
+const TracerName = "github.com/iotaledger/inx-indexer"
+
+// Tracer returns the tracer of the global tracer provider.
+// The spans are discarded until a tracer provider is registered, so instrumented code does not need to check whether tracing is enabled.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Detach returns a context that contains the span of the given context, but is not canceled together with it.
+// It is used for database operations that must not be aborted if the daemon is shutting down.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+)
+
+const (
+	// ParameterName is used to identify a hook by its name.
+	ParameterName = "name"
+
+	// RouteWebhooks is the route for the registered hooks.
+	// GET returns all hooks of the config file and the admin API.
+	// POST adds a hook, the body contains the name, the URL, the optional secret, the events and the filter of the hook.
+	RouteWebhooks = "/webhooks"
+
+	// RouteWebhook is the route for a single hook.
+	// GET returns the hook or 404 if no hook with the name exists.
+	// DELETE removes a hook of the admin API together with its pending deliveries.
+	RouteWebhook = "/webhooks/:" + ParameterName
+)
+
+type hookResponse struct {
+	// Name is the unique name of the hook.
+	Name string `json:"name"`
+	// URL is the URL the matching outputs are POSTed to.
+	URL string `json:"url"`
+	// HasSecret is set if the requests to the hook are signed.
+	HasSecret bool `json:"hasSecret"`
+	// Events are the events the hook subscribed to.
+	Events []string `json:"events"`
+	// Filter are the criteria the outputs have to match.
+	Filter FilterParameters `json:"filter"`
+	// Configured is set if the hook is defined in the config file, these hooks can't be removed through the API.
+	Configured bool `json:"configured"`
+	// PendingDeliveries is the amount of deliveries that were not delivered yet.
+	PendingDeliveries int64 `json:"pendingDeliveries"`
+}
+
+type hooksResponse struct {
+	Items []*hookResponse `json:"items"`
+}
+
+func (m *Manager) hookResponse(hook *Hook) (*hookResponse, error) {
+	pending, err := m.PendingDeliveries(hook.Name)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading pending deliveries failed: %s", err)
+	}
+
+	params := hook.Parameters()
+	events := params.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+
+	return &hookResponse{
+		Name:              hook.Name,
+		URL:               hook.URL,
+		HasSecret:         hook.Secret != "",
+		Events:            events,
+		Filter:            params.Filter,
+		Configured:        hook.Configured,
+		PendingDeliveries: pending,
+	}, nil
+}
+
+// ConfigureRoutes adds the routes of the admin API to the given group.
+func (m *Manager) ConfigureRoutes(routeGroup *echo.Group) {
+
+	routeGroup.GET(RouteWebhooks, func(c echo.Context) error {
+		items := make([]*hookResponse, 0)
+		for _, hook := range m.Hooks() {
+			resp, err := m.hookResponse(hook)
+			if err != nil {
+				return err
+			}
+			items = append(items, resp)
+		}
+
+		return c.JSON(http.StatusOK, &hooksResponse{Items: items})
+	})
+
+	routeGroup.POST(RouteWebhooks, func(c echo.Context) error {
+		params := HookParameters{}
+		if err := c.Bind(&params); err != nil {
+			return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid request, error: %s", err)
+		}
+
+		hook, err := m.AddHook(params)
+		if err != nil {
+			if errors.Is(err, ErrHookExists) {
+				return errors.WithMessagef(echo.NewHTTPError(http.StatusConflict), "webhook %s already exists", params.Name)
+			}
+
+			return errors.WithMessagef(httpserver.ErrInvalidParameter, "invalid webhook, error: %s", err)
+		}
+
+		resp, err := m.hookResponse(hook)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, resp)
+	})
+
+	routeGroup.GET(RouteWebhook, func(c echo.Context) error {
+		hook, err := m.Hook(c.Param(ParameterName))
+		if err != nil {
+			return errors.WithMessagef(echo.ErrNotFound, "webhook %s not found", c.Param(ParameterName))
+		}
+
+		resp, err := m.hookResponse(hook)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	})
+
+	routeGroup.DELETE(RouteWebhook, func(c echo.Context) error {
+		name := c.Param(ParameterName)
+		if err := m.DeleteHook(name); err != nil {
+			switch {
+			case errors.Is(err, ErrHookNotFound):
+				return errors.WithMessagef(echo.ErrNotFound, "webhook %s not found", name)
+			case errors.Is(err, ErrHookConfigured):
+				return errors.WithMessagef(httpserver.ErrInvalidParameter, "webhook %s is configured in the config file", name)
+			default:
+				return errors.WithMessagef(echo.ErrInternalServerError, "deleting webhook %s failed: %s", name, err)
+			}
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,200 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderEvent is the header with the event of a delivery.
+	HeaderEvent = "X-Indexer-Event"
+	// HeaderDelivery is the header with the unique ID of a delivery, it stays the same for retries.
+	HeaderDelivery = "X-Indexer-Delivery"
+	// HeaderTimestamp is the header with the unix timestamp of the request, it is part of the signature.
+	HeaderTimestamp = "X-Indexer-Timestamp"
+	// HeaderSignature is the header with the HMAC-SHA256 signature of the request, if the hook has a secret.
+	HeaderSignature = "X-Indexer-Signature"
+
+	// dispatchInterval is the interval in which the due deliveries are checked if no new deliveries were enqueued.
+	dispatchInterval = time.Second
+	// maxErrorBodyLength is the maximum length of the response body that is stored as error of a failed delivery.
+	maxErrorBodyLength = 256
+)
+
+// Signature returns the value of the signature header of a request.
+// It is the hex encoded HMAC-SHA256 of the timestamp header, a dot and the body, keyed with the secret of the hook.
+func Signature(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers the pending deliveries until the context is canceled.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		m.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wakeup:
+		}
+	}
+}
+
+// dispatch sends the due deliveries, the hooks are called concurrently.
+func (m *Manager) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := m.store.dueDeliveries(time.Now())
+		if err != nil {
+			m.LogWarnf("Loading webhook deliveries failed: %s", err.Error())
+
+			return
+		}
+
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		var succeededLock sync.Mutex
+		succeeded := 0
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *webhookDelivery) {
+				defer wg.Done()
+
+				if m.deliver(ctx, delivery) {
+					succeededLock.Lock()
+					succeeded++
+					succeededLock.Unlock()
+				}
+			}(delivery)
+		}
+		wg.Wait()
+
+		// continue with the next deliveries of the hooks only if there were successful ones,
+		// otherwise all remaining deliveries wait for their backoff
+		if succeeded == 0 {
+			return
+		}
+	}
+}
+
+// deliver sends the delivery to its hook and returns whether it succeeded.
+// Failed deliveries are retried with an exponential backoff.
+func (m *Manager) deliver(ctx context.Context, delivery *webhookDelivery) bool {
+	hook, err := m.Hook(delivery.Hook)
+	if err != nil {
+		// the hook was removed from the config file, its deliveries are dropped
+		m.LogWarnf("Dropping delivery %d of unknown webhook %s", delivery.ID, delivery.Hook)
+		if err := m.store.deleteDelivery(delivery.ID); err != nil {
+			m.LogWarnf("Deleting webhook delivery %d failed: %s", delivery.ID, err.Error())
+
+			return false
+		}
+
+		return true
+	}
+
+	if err := m.post(ctx, hook, delivery); err != nil {
+		if ctx.Err() != nil {
+			// the delivery is retried after the restart
+			return false
+		}
+
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+
+		if m.maxAttempts > 0 && delivery.Attempts >= m.maxAttempts {
+			m.LogWarnf("Dropping delivery %d of webhook %s for milestone %d after %d attempts: %s", delivery.ID, hook.Name, delivery.MilestoneIndex, delivery.Attempts, err.Error())
+			if err := m.store.deleteDelivery(delivery.ID); err != nil {
+				m.LogWarnf("Deleting webhook delivery %d failed: %s", delivery.ID, err.Error())
+
+				return false
+			}
+
+			return true
+		}
+
+		delivery.NextAttempt = time.Now().Add(m.backoff(delivery.Attempts))
+		m.LogDebugf("Delivery %d of webhook %s failed (attempt %d), retrying at %s: %s", delivery.ID, hook.Name, delivery.Attempts, delivery.NextAttempt.Format(time.RFC3339), err.Error())
+		if err := m.store.retryDelivery(delivery); err != nil {
+			m.LogWarnf("Updating webhook delivery %d failed: %s", delivery.ID, err.Error())
+		}
+
+		return false
+	}
+
+	if err := m.store.deleteDelivery(delivery.ID); err != nil {
+		m.LogWarnf("Deleting webhook delivery %d failed: %s", delivery.ID, err.Error())
+
+		return false
+	}
+
+	return true
+}
+
+// backoff returns the time until the next attempt after the given amount of failed attempts.
+func (m *Manager) backoff(attempts uint32) time.Duration {
+	backoff := m.retryInitialBackoff
+	for i := uint32(1); i < attempts && backoff < m.retryMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > m.retryMaxBackoff {
+		return m.retryMaxBackoff
+	}
+
+	return backoff
+}
+
+func (m *Manager) post(ctx context.Context, hook *Hook, delivery *webhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Signature(hook.Secret, timestamp, delivery.Payload))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
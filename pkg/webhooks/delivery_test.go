@@ -0,0 +1,567 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/scheduler"
+	inx "github.com/iotaledger/inx/go"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const testMilestoneTimestamp = 1_700_000_000
+
+var initTestLogger sync.Once
+
+func newTestIndexer(t *testing.T) *indexer.Indexer {
+	t.Helper()
+
+	initTestLogger.Do(func() {
+		if err := logger.InitGlobalLogger(configuration.New()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	idx, err := indexer.NewIndexer(database.Params{Engine: database.EngineSQLite, Path: t.TempDir()}, logger.NewLogger("Indexer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = idx.CloseDatabase() })
+
+	if err := idx.CreateTables(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.ImportTransaction(context.Background()).Finalize(1, &iotago.ProtocolParameters{Version: 2, NetworkName: "test"}, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+func newTestManager(t *testing.T, idx *indexer.Indexer, opts ...options.Option[Manager]) *Manager {
+	t.Helper()
+
+	m, err := NewManager(idx.Database(), testHRP, logger.NewLogger("Webhooks"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.AddLedgerUpdateWriter(m.WriteLedgerUpdate)
+
+	return m
+}
+
+func testLedgerOutput(t *testing.T, outputID byte, owner iotago.Address) *inx.LedgerOutput {
+	t.Helper()
+
+	output, err := inx.WrapOutput(&iotago.BasicOutput{
+		Amount:     1_000_000,
+		Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: owner}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &inx.LedgerOutput{
+		OutputId:                 inx.NewOutputId(iotago.OutputID{outputID}),
+		MilestoneTimestampBooked: testMilestoneTimestamp,
+		Output:                   output,
+	}
+}
+
+// applyMilestone applies a milestone that creates an output of the owner with the given output ID.
+func applyMilestone(t *testing.T, idx *indexer.Indexer, milestoneIndex uint32, outputID byte, owner iotago.Address) error {
+	t.Helper()
+
+	return idx.UpdatedLedger(context.Background(), &nodebridge.LedgerUpdate{
+		MilestoneIndex: milestoneIndex,
+		Created:        []*inx.LedgerOutput{testLedgerOutput(t, outputID, owner)},
+	})
+}
+
+// testRequest is a request received by a testReceiver.
+type testRequest struct {
+	header  http.Header
+	payload []byte
+}
+
+// milestoneIndex returns the milestone index of the payload of the request.
+func (r *testRequest) milestoneIndex(t *testing.T) uint32 {
+	t.Helper()
+
+	payload := &ledgerUpdatePayload{}
+	if err := json.Unmarshal(r.payload, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	return payload.MilestoneIndex
+}
+
+// testReceiver is a hook that records its requests, it answers with an error until its failures are used up.
+type testReceiver struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests []*testRequest
+	// failures is the amount of requests that are answered with an error before the receiver succeeds, -1 fails forever.
+	failures int
+}
+
+func newTestReceiver(t *testing.T, failures int) *testReceiver {
+	t.Helper()
+
+	r := &testReceiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		payload, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		r.requests = append(r.requests, &testRequest{header: req.Header.Clone(), payload: payload})
+		if r.failures != 0 {
+			if r.failures > 0 {
+				r.failures--
+			}
+			http.Error(w, "receiver unavailable", http.StatusServiceUnavailable)
+
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *testReceiver) received() []*testRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]*testRequest(nil), r.requests...)
+}
+
+// receivedMilestones returns the milestone indexes of the received requests in the order they were received.
+func (r *testReceiver) receivedMilestones(t *testing.T) []uint32 {
+	t.Helper()
+
+	var milestones []uint32
+	for _, request := range r.received() {
+		milestones = append(milestones, request.milestoneIndex(t))
+	}
+
+	return milestones
+}
+
+func addTestHook(t *testing.T, m *Manager, name string, url string, secret string, owner iotago.Address) {
+	t.Helper()
+
+	if _, err := m.AddHook(HookParameters{
+		Name:   name,
+		URL:    url,
+		Secret: secret,
+		Filter: FilterParameters{Addresses: []string{owner.Bech32(testHRP)}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pendingDeliveries(t *testing.T, m *Manager, name string) int64 {
+	t.Helper()
+
+	pending, err := m.PendingDeliveries(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pending
+}
+
+// makeDeliveriesDue lets the pending deliveries be retried immediately instead of waiting for their backoff.
+func makeDeliveriesDue(t *testing.T, m *Manager) {
+	t.Helper()
+
+	if err := m.store.db.Model(&webhookDelivery{}).Where("1 = 1").Update("next_attempt", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeliverySignature(t *testing.T) {
+	const secret = "secret"
+
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	signed := newTestReceiver(t, 0)
+	unsigned := newTestReceiver(t, 0)
+	addTestHook(t, m, "signed", signed.URL, secret, testOwner)
+	addTestHook(t, m, "unsigned", unsigned.URL, "", testOwner)
+
+	if err := applyMilestone(t, idx, 2, 0x1, testOwner); err != nil {
+		t.Fatal(err)
+	}
+	m.dispatch(context.Background())
+
+	requests := signed.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	request := requests[0]
+
+	if event := request.header.Get(HeaderEvent); event != eventLedgerUpdate {
+		t.Errorf("expected the event %s, got %s", eventLedgerUpdate, event)
+	}
+	if request.header.Get(HeaderDelivery) == "" {
+		t.Error("expected the ID of the delivery")
+	}
+	timestamp := request.header.Get(HeaderTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("expected a unix timestamp, got %q", timestamp)
+	}
+
+	// the signature is the HMAC-SHA256 of the timestamp, a dot and the body
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(request.payload)))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature := request.header.Get(HeaderSignature); signature != expected {
+		t.Errorf("expected the signature %s, got %s", expected, signature)
+	}
+	if signature := Signature("other secret", timestamp, request.payload); signature == expected {
+		t.Error("expected the signature to depend on the secret")
+	}
+
+	payload := &ledgerUpdatePayload{}
+	if err := json.Unmarshal(request.payload, payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Hook != "signed" || payload.MilestoneIndex != 2 || len(payload.Created) != 1 || payload.Created[0].OutputID != (iotago.OutputID{0x1}).ToHex() {
+		t.Errorf("unexpected payload %s", request.payload)
+	}
+
+	requests = unsigned.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	if signature := requests[0].header.Get(HeaderSignature); signature != "" {
+		t.Errorf("expected no signature without a secret, got %s", signature)
+	}
+}
+
+func TestDeliveryFilter(t *testing.T) {
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	receiver := newTestReceiver(t, 0)
+	addTestHook(t, m, "owner", receiver.URL, "", testOwner)
+
+	// the output of the other address does not match the filter of the hook
+	if err := applyMilestone(t, idx, 2, 0x1, testOther); err != nil {
+		t.Fatal(err)
+	}
+	if pending := pendingDeliveries(t, m, "owner"); pending != 0 {
+		t.Fatalf("expected no delivery, got %d", pending)
+	}
+
+	// outputs that were created and consumed in the same milestone are left out
+	created := testLedgerOutput(t, 0x2, testOwner)
+	if err := idx.UpdatedLedger(context.Background(), &nodebridge.LedgerUpdate{
+		MilestoneIndex: 3,
+		Created:        []*inx.LedgerOutput{created, testLedgerOutput(t, 0x3, testOwner), testLedgerOutput(t, 0x4, testOther)},
+		Consumed:       []*inx.LedgerSpent{{Output: created, MilestoneIndexSpent: 3, MilestoneTimestampSpent: testMilestoneTimestamp}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m.dispatch(context.Background())
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+
+	payload := &ledgerUpdatePayload{}
+	if err := json.Unmarshal(requests[0].payload, payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.MilestoneIndex != 3 || len(payload.Consumed) != 0 || len(payload.Created) != 1 || payload.Created[0].OutputID != (iotago.OutputID{0x3}).ToHex() {
+		t.Errorf("expected only the created output 0x03 of milestone 3, got %s", requests[0].payload)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	const (
+		initialBackoff = time.Minute
+		maxBackoff     = 3 * time.Minute
+		maxAttempts    = 4
+	)
+
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx, WithRetryBackoff(initialBackoff, maxBackoff), WithMaxAttempts(maxAttempts))
+
+	receiver := newTestReceiver(t, -1)
+	addTestHook(t, m, "failing", receiver.URL, "", testOwner)
+
+	if err := applyMilestone(t, idx, 2, 0x1, testOwner); err != nil {
+		t.Fatal(err)
+	}
+
+	// the backoff doubles with every attempt up to the maximum
+	for attempt, backoff := range []time.Duration{initialBackoff, 2 * initialBackoff, maxBackoff} {
+		before := time.Now()
+		m.dispatch(context.Background())
+
+		if requests := len(receiver.received()); requests != attempt+1 {
+			t.Fatalf("expected %d requests, got %d", attempt+1, requests)
+		}
+
+		// the failed delivery is not retried before its backoff
+		m.dispatch(context.Background())
+		if requests := len(receiver.received()); requests != attempt+1 {
+			t.Fatalf("expected the delivery to wait for its backoff, got %d requests", requests)
+		}
+
+		delivery := &webhookDelivery{}
+		if err := m.store.db.Take(delivery).Error; err != nil {
+			t.Fatal(err)
+		}
+		if delivery.Attempts != uint32(attempt+1) {
+			t.Errorf("expected %d attempts, got %d", attempt+1, delivery.Attempts)
+		}
+		if !strings.Contains(delivery.LastError, "503") || !strings.Contains(delivery.LastError, "receiver unavailable") {
+			t.Errorf("expected the status code and body as error, got %q", delivery.LastError)
+		}
+		if delivery.NextAttempt.Before(before.Add(backoff)) || delivery.NextAttempt.After(time.Now().Add(backoff)) {
+			t.Errorf("expected the next attempt after %s, got %s", backoff, delivery.NextAttempt.Sub(before))
+		}
+
+		makeDeliveriesDue(t, m)
+	}
+
+	// the delivery is dropped after the last attempt
+	m.dispatch(context.Background())
+	if requests := len(receiver.received()); requests != maxAttempts {
+		t.Fatalf("expected %d requests, got %d", maxAttempts, requests)
+	}
+	if pending := pendingDeliveries(t, m, "failing"); pending != 0 {
+		t.Errorf("expected the delivery to be dropped after %d attempts, got %d pending deliveries", maxAttempts, pending)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	m := &Manager{retryInitialBackoff: 5 * time.Second, retryMaxBackoff: time.Minute}
+
+	for attempts, expected := range map[uint32]time.Duration{
+		1:   5 * time.Second,
+		2:   10 * time.Second,
+		3:   20 * time.Second,
+		4:   40 * time.Second,
+		5:   time.Minute,
+		100: time.Minute,
+	} {
+		if backoff := m.backoff(attempts); backoff != expected {
+			t.Errorf("expected a backoff of %s after %d attempts, got %s", expected, attempts, backoff)
+		}
+	}
+}
+
+func TestDeliveryOrder(t *testing.T) {
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	// the first request to the flaky hook fails, the other hook is not blocked by it
+	flaky := newTestReceiver(t, 1)
+	healthy := newTestReceiver(t, 0)
+	addTestHook(t, m, "flaky", flaky.URL, "", testOwner)
+	addTestHook(t, m, "healthy", healthy.URL, "", testOwner)
+
+	for milestoneIndex := uint32(2); milestoneIndex <= 4; milestoneIndex++ {
+		if err := applyMilestone(t, idx, milestoneIndex, byte(milestoneIndex), testOwner); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.dispatch(context.Background())
+
+	if milestones := healthy.receivedMilestones(t); !reflect.DeepEqual(milestones, []uint32{2, 3, 4}) {
+		t.Errorf("expected the healthy hook to receive the milestones in order, got %v", milestones)
+	}
+	// the later deliveries of the flaky hook wait for the failed one
+	if milestones := flaky.receivedMilestones(t); !reflect.DeepEqual(milestones, []uint32{2}) {
+		t.Errorf("expected the flaky hook to only receive milestone 2, got %v", milestones)
+	}
+	if pending := pendingDeliveries(t, m, "flaky"); pending != 3 {
+		t.Errorf("expected 3 pending deliveries, got %d", pending)
+	}
+
+	makeDeliveriesDue(t, m)
+	m.dispatch(context.Background())
+
+	if milestones := flaky.receivedMilestones(t); !reflect.DeepEqual(milestones, []uint32{2, 2, 3, 4}) {
+		t.Errorf("expected the flaky hook to receive the milestones in order after the retry, got %v", milestones)
+	}
+	if pending := pendingDeliveries(t, m, "flaky"); pending != 0 {
+		t.Errorf("expected no pending deliveries, got %d", pending)
+	}
+}
+
+func TestDeliveriesSurviveRestart(t *testing.T) {
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	receiver := newTestReceiver(t, 0)
+	addTestHook(t, m, "hook", receiver.URL, "secret", testOwner)
+
+	// the manager stops before it delivered the milestones
+	for milestoneIndex := uint32(2); milestoneIndex <= 3; milestoneIndex++ {
+		if err := applyMilestone(t, idx, milestoneIndex, byte(milestoneIndex), testOwner); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restarted, err := NewManager(idx.Database(), testHRP, logger.NewLogger("Webhooks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook, err := restarted.Hook("hook")
+	if err != nil {
+		t.Fatalf("expected the hook of the admin API to be loaded: %s", err)
+	}
+	if hook.Secret != "secret" || hook.Configured {
+		t.Errorf("expected the stored parameters of the hook, got %+v", hook)
+	}
+	if pending := pendingDeliveries(t, restarted, "hook"); pending != 2 {
+		t.Fatalf("expected 2 pending deliveries, got %d", pending)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		restarted.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pendingDeliveries(t, restarted, "hook") > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if milestones := receiver.receivedMilestones(t); !reflect.DeepEqual(milestones, []uint32{2, 3}) {
+		t.Errorf("expected the pending milestones to be delivered after the restart, got %v", milestones)
+	}
+}
+
+func TestDeliveriesWrittenInLedgerTransaction(t *testing.T) {
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	receiver := newTestReceiver(t, 0)
+	addTestHook(t, m, "hook", receiver.URL, "", testOwner)
+
+	// a milestone that is not committed does not leave any deliveries behind
+	errFailed := errors.New("milestone failed")
+	idx.AddLedgerUpdateWriter(func(_ *gorm.DB, update *nodebridge.LedgerUpdate) error {
+		if update.MilestoneIndex == 2 {
+			return errFailed
+		}
+
+		return nil
+	})
+
+	if err := applyMilestone(t, idx, 2, 0x2, testOwner); !errors.Is(err, errFailed) {
+		t.Fatalf("expected the milestone to fail, got %v", err)
+	}
+	if pending := pendingDeliveries(t, m, "hook"); pending != 0 {
+		t.Errorf("expected no deliveries of the failed milestone, got %d", pending)
+	}
+
+	if err := applyMilestone(t, idx, 3, 0x3, testOwner); err != nil {
+		t.Fatal(err)
+	}
+	if pending := pendingDeliveries(t, m, "hook"); pending != 1 {
+		t.Errorf("expected the delivery of the committed milestone, got %d", pending)
+	}
+}
+
+func TestScheduledDeliveriesWrittenInSchedulerTransaction(t *testing.T) {
+	idx := newTestIndexer(t)
+	m := newTestManager(t, idx)
+
+	receiver := newTestReceiver(t, 0)
+	if _, err := m.AddHook(HookParameters{
+		Name:   "hook",
+		URL:    receiver.URL,
+		Events: []string{EventExpired, EventTimelockReleased},
+		Filter: FilterParameters{Addresses: []string{testOwner.Bech32(testHRP)}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledEvent := func(owner iotago.Address) *scheduler.Event {
+		return &scheduler.Event{
+			MilestoneIndex:     2,
+			MilestoneTimestamp: time.Unix(testMilestoneTimestamp, 0),
+			Output: &indexer.ScheduledOutput{
+				OutputType: iotago.OutputBasic,
+				Owner:      owner,
+				Time:       time.Unix(testMilestoneTimestamp, 0),
+			},
+		}
+	}
+	events := &scheduler.MilestoneEvents{
+		MilestoneIndex: 2,
+		// the hook did not subscribe to the expiring soon event
+		ExpiringSoon:     []*scheduler.Event{scheduledEvent(testOwner)},
+		Expired:          []*scheduler.Event{scheduledEvent(testOwner), scheduledEvent(testOther)},
+		TimelockReleased: []*scheduler.Event{scheduledEvent(testOwner)},
+	}
+
+	// the deliveries of a transaction of the scheduler that is not committed are discarded
+	errFailed := errors.New("milestone failed")
+	if err := idx.Database().Transaction(func(tx *gorm.DB) error {
+		if err := m.WriteScheduledEvents(tx, events); err != nil {
+			return err
+		}
+
+		return errFailed
+	}); !errors.Is(err, errFailed) {
+		t.Fatalf("expected the transaction to fail, got %v", err)
+	}
+	if pending := pendingDeliveries(t, m, "hook"); pending != 0 {
+		t.Errorf("expected no deliveries of the failed transaction, got %d", pending)
+	}
+
+	if err := idx.Database().Transaction(func(tx *gorm.DB) error {
+		return m.WriteScheduledEvents(tx, events)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if pending := pendingDeliveries(t, m, "hook"); pending != 2 {
+		t.Errorf("expected the deliveries of the expired and released outputs of the owner, got %d", pending)
+	}
+}
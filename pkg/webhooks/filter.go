@@ -0,0 +1,227 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// outputTypesByName are the output types that can be selected by the filter of a hook.
+	outputTypesByName = map[string]iotago.OutputType{
+		"basic":   iotago.OutputBasic,
+		"alias":   iotago.OutputAlias,
+		"foundry": iotago.OutputFoundry,
+		"nft":     iotago.OutputNFT,
+	}
+)
+
+// FilterParameters are the filter criteria of a hook, named like the query parameters of the REST API.
+// All criteria have to match, the values of a single criterion are alternatives.
+type FilterParameters struct {
+	// OutputTypes are the types of the outputs (basic, alias, foundry, nft), all types match if empty.
+	OutputTypes []string `json:"outputTypes,omitempty"`
+	// Addresses are bech32 addresses, the output matches if one of them owns or controls it.
+	Addresses []string `json:"addresses,omitempty"`
+	// Senders are bech32 addresses, the output matches if its sender feature contains one of them.
+	Senders []string `json:"senders,omitempty"`
+	// Issuers are bech32 addresses, the output matches if its issuer feature contains one of them.
+	Issuers []string `json:"issuers,omitempty"`
+	// Tags are hex encoded tags, the output matches if its tag feature contains one of them.
+	Tags []string `json:"tags,omitempty"`
+	// HasNativeTokens filters outputs with or without native tokens.
+	HasNativeTokens *bool `json:"hasNativeTokens,omitempty"`
+	// HasStorageDepositReturn filters outputs with or without a storage deposit return unlock condition.
+	HasStorageDepositReturn *bool `json:"hasStorageDepositReturn,omitempty"`
+	// HasExpiration filters outputs with or without an expiration unlock condition.
+	HasExpiration *bool `json:"hasExpiration,omitempty"`
+	// HasTimelock filters outputs with or without a timelock unlock condition.
+	HasTimelock *bool `json:"hasTimelock,omitempty"`
+}
+
+// Filter matches outputs against the filter criteria of a hook.
+type Filter struct {
+	outputTypes             map[iotago.OutputType]struct{}
+	addresses               []iotago.Address
+	senders                 []iotago.Address
+	issuers                 []iotago.Address
+	tags                    [][]byte
+	hasNativeTokens         *bool
+	hasStorageDepositReturn *bool
+	hasExpiration           *bool
+	hasTimelock             *bool
+}
+
+func parseBech32Addresses(name string, values []string, hrp iotago.NetworkPrefix) ([]iotago.Address, error) {
+	addresses := make([]iotago.Address, 0, len(values))
+	for _, value := range values {
+		prefix, address, err := iotago.ParseBech32(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid address in %s: %s", name, value)
+		}
+		if prefix != hrp {
+			return nil, errors.Errorf("invalid address in %s: %s, wrong bech32 prefix %s instead of %s", name, value, prefix, hrp)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// NewFilter parses the filter criteria of a hook, the addresses have to use the given bech32 prefix.
+func NewFilter(params FilterParameters, hrp iotago.NetworkPrefix) (*Filter, error) {
+	filter := &Filter{
+		outputTypes:             make(map[iotago.OutputType]struct{}, len(params.OutputTypes)),
+		hasNativeTokens:         params.HasNativeTokens,
+		hasStorageDepositReturn: params.HasStorageDepositReturn,
+		hasExpiration:           params.HasExpiration,
+		hasTimelock:             params.HasTimelock,
+	}
+
+	for _, name := range params.OutputTypes {
+		outputType, ok := outputTypesByName[strings.ToLower(name)]
+		if !ok {
+			return nil, errors.Errorf("invalid output type: %s", name)
+		}
+		filter.outputTypes[outputType] = struct{}{}
+	}
+
+	var err error
+	if filter.addresses, err = parseBech32Addresses("addresses", params.Addresses, hrp); err != nil {
+		return nil, err
+	}
+	if filter.senders, err = parseBech32Addresses("senders", params.Senders, hrp); err != nil {
+		return nil, err
+	}
+	if filter.issuers, err = parseBech32Addresses("issuers", params.Issuers, hrp); err != nil {
+		return nil, err
+	}
+
+	for _, value := range params.Tags {
+		tag, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tag: %s", value)
+		}
+		if len(tag) > iotago.MaxTagLength {
+			return nil, errors.Errorf("invalid tag: %s, too long", value)
+		}
+		filter.tags = append(filter.tags, tag)
+	}
+
+	return filter, nil
+}
+
+// ownerAddresses returns the addresses that own or control the output.
+func ownerAddresses(output iotago.Output) []iotago.Address {
+	conditions := output.UnlockConditionSet()
+
+	var addresses []iotago.Address
+	if address := conditions.Address(); address != nil {
+		addresses = append(addresses, address.Address)
+	}
+	if stateController := conditions.StateControllerAddress(); stateController != nil {
+		addresses = append(addresses, stateController.Address)
+	}
+	if governor := conditions.GovernorAddress(); governor != nil {
+		addresses = append(addresses, governor.Address)
+	}
+	if immutableAlias := conditions.ImmutableAlias(); immutableAlias != nil {
+		addresses = append(addresses, immutableAlias.Address)
+	}
+
+	return addresses
+}
+
+// issuerAddress returns the address of the immutable issuer feature of the output, if it has one.
+func issuerAddress(output iotago.Output) iotago.Address {
+	chainOutput, ok := output.(iotago.ChainConstrainedOutput)
+	if !ok {
+		return nil
+	}
+
+	issuer := chainOutput.ImmutableFeatureSet().IssuerFeature()
+	if issuer == nil {
+		return nil
+	}
+
+	return issuer.Address
+}
+
+func containsAddress(addresses []iotago.Address, candidates ...iotago.Address) bool {
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		for _, address := range addresses {
+			if address.Equal(candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesBool(value *bool, actual bool) bool {
+	return value == nil || *value == actual
+}
+
+// MatchesAddresses checks whether the output type and one of the given addresses match the filter.
+// It is used for events that only know the addresses of an output but not the output itself.
+func (f *Filter) MatchesAddresses(outputType iotago.OutputType, addresses ...iotago.Address) bool {
+	if len(f.outputTypes) > 0 {
+		if _, ok := f.outputTypes[outputType]; !ok {
+			return false
+		}
+	}
+
+	return len(f.addresses) == 0 || containsAddress(f.addresses, addresses...)
+}
+
+// Matches checks whether the output matches all criteria of the filter.
+func (f *Filter) Matches(output iotago.Output) bool {
+	if !f.MatchesAddresses(output.Type(), ownerAddresses(output)...) {
+		return false
+	}
+
+	if len(f.senders) > 0 {
+		sender := output.FeatureSet().SenderFeature()
+		if sender == nil || !containsAddress(f.senders, sender.Address) {
+			return false
+		}
+	}
+
+	if len(f.issuers) > 0 && !containsAddress(f.issuers, issuerAddress(output)) {
+		return false
+	}
+
+	if len(f.tags) > 0 {
+		tag := output.FeatureSet().TagFeature()
+		if tag == nil {
+			return false
+		}
+
+		found := false
+		for _, filterTag := range f.tags {
+			if bytes.Equal(filterTag, tag.Tag) {
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	conditions := output.UnlockConditionSet()
+
+	return matchesBool(f.hasNativeTokens, len(output.NativeTokenList()) > 0) &&
+		matchesBool(f.hasStorageDepositReturn, conditions.HasStorageDepositReturnCondition()) &&
+		matchesBool(f.hasExpiration, conditions.HasExpirationCondition()) &&
+		matchesBool(f.hasTimelock, conditions.HasTimelockCondition())
+}
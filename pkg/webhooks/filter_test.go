@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"testing"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const testHRP = iotago.PrefixTestnet
+
+var (
+	testOwner  = &iotago.Ed25519Address{0x1}
+	testOther  = &iotago.Ed25519Address{0x2}
+	testSender = &iotago.Ed25519Address{0x3}
+)
+
+func TestFilterMatches(t *testing.T) {
+	truth := true
+	falsehood := false
+
+	basic := &iotago.BasicOutput{
+		Amount:     1_000_000,
+		Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: testOwner}},
+		Features: iotago.Features{
+			&iotago.SenderFeature{Address: testSender},
+			&iotago.TagFeature{Tag: []byte("tag")},
+		},
+	}
+	expiring := &iotago.BasicOutput{
+		Amount: 1_000_000,
+		Conditions: iotago.UnlockConditions{
+			&iotago.AddressUnlockCondition{Address: testOther},
+			&iotago.ExpirationUnlockCondition{ReturnAddress: testOwner, UnixTime: 1_700_000_000},
+		},
+	}
+	nft := &iotago.NFTOutput{
+		Amount:            1_000_000,
+		Conditions:        iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: testOther}},
+		ImmutableFeatures: iotago.Features{&iotago.IssuerFeature{Address: testOwner}},
+	}
+
+	tests := []struct {
+		name    string
+		params  FilterParameters
+		output  iotago.Output
+		matches bool
+	}{
+		{
+			name:    "empty filter",
+			output:  basic,
+			matches: true,
+		},
+		{
+			name:    "output type",
+			params:  FilterParameters{OutputTypes: []string{"basic"}},
+			output:  basic,
+			matches: true,
+		},
+		{
+			name:    "other output type",
+			params:  FilterParameters{OutputTypes: []string{"alias", "NFT"}},
+			output:  basic,
+			matches: false,
+		},
+		{
+			name:    "owner",
+			params:  FilterParameters{Addresses: []string{testOther.Bech32(testHRP), testOwner.Bech32(testHRP)}},
+			output:  basic,
+			matches: true,
+		},
+		{
+			name:    "other owner",
+			params:  FilterParameters{Addresses: []string{testOther.Bech32(testHRP)}},
+			output:  basic,
+			matches: false,
+		},
+		{
+			// the return address of an expiration does not own the output
+			name:    "return address",
+			params:  FilterParameters{Addresses: []string{testOwner.Bech32(testHRP)}},
+			output:  expiring,
+			matches: false,
+		},
+		{
+			name:    "sender",
+			params:  FilterParameters{Senders: []string{testSender.Bech32(testHRP)}},
+			output:  basic,
+			matches: true,
+		},
+		{
+			name:    "without sender",
+			params:  FilterParameters{Senders: []string{testSender.Bech32(testHRP)}},
+			output:  expiring,
+			matches: false,
+		},
+		{
+			name:    "issuer",
+			params:  FilterParameters{Issuers: []string{testOwner.Bech32(testHRP)}},
+			output:  nft,
+			matches: true,
+		},
+		{
+			name:    "issuer of an output without issuer feature",
+			params:  FilterParameters{Issuers: []string{testOwner.Bech32(testHRP)}},
+			output:  basic,
+			matches: false,
+		},
+		{
+			name:    "tag",
+			params:  FilterParameters{Tags: []string{"0x01", "0x746167"}},
+			output:  basic,
+			matches: true,
+		},
+		{
+			name:    "other tag",
+			params:  FilterParameters{Tags: []string{"0x01"}},
+			output:  basic,
+			matches: false,
+		},
+		{
+			name:    "has expiration",
+			params:  FilterParameters{HasExpiration: &truth},
+			output:  expiring,
+			matches: true,
+		},
+		{
+			name:    "has no expiration",
+			params:  FilterParameters{HasExpiration: &falsehood},
+			output:  expiring,
+			matches: false,
+		},
+		{
+			name:    "has no native tokens and no timelock",
+			params:  FilterParameters{HasNativeTokens: &falsehood, HasTimelock: &falsehood},
+			output:  basic,
+			matches: true,
+		},
+		{
+			// all criteria have to match
+			name: "combined criteria",
+			params: FilterParameters{
+				OutputTypes: []string{"basic"},
+				Addresses:   []string{testOwner.Bech32(testHRP)},
+				Tags:        []string{"0x01"},
+			},
+			output:  basic,
+			matches: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := NewFilter(test.params, testHRP)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if matches := filter.Matches(test.output); matches != test.matches {
+				t.Errorf("expected the filter to match: %t, got %t", test.matches, matches)
+			}
+		})
+	}
+}
+
+func TestNewFilterInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params FilterParameters
+	}{
+		{
+			name:   "unknown output type",
+			params: FilterParameters{OutputTypes: []string{"treasury"}},
+		},
+		{
+			name:   "invalid address",
+			params: FilterParameters{Addresses: []string{"rms1invalid"}},
+		},
+		{
+			name:   "address of another network",
+			params: FilterParameters{Senders: []string{testOwner.Bech32(iotago.PrefixMainnet)}},
+		},
+		{
+			name:   "invalid tag",
+			params: FilterParameters{Tags: []string{"0xzz"}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewFilter(test.params, testHRP); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// EventCreated is the event of the outputs that were created by a milestone.
+	EventCreated = "created"
+	// EventConsumed is the event of the outputs that were consumed by a milestone.
+	EventConsumed = "consumed"
+	// EventExpiringSoon is the event of the scheduler for outputs that expire soon.
+	EventExpiringSoon = "expiringSoon"
+	// EventExpired is the event of the scheduler for outputs that expired.
+	EventExpired = "expired"
+	// EventTimelockReleased is the event of the scheduler for outputs whose timelock ended.
+	EventTimelockReleased = "timelockReleased"
+)
+
+var (
+	// ErrHookNotFound is returned if a hook with the given name does not exist.
+	ErrHookNotFound = errors.New("webhook not found")
+	// ErrHookExists is returned if a hook with the given name already exists.
+	ErrHookExists = errors.New("webhook already exists")
+	// ErrHookConfigured is returned if a hook of the config file should be changed through the admin API.
+	ErrHookConfigured = errors.New("webhook is configured in the config file")
+
+	// validEvents are the events a hook can subscribe to.
+	validEvents = map[string]struct{}{
+		EventCreated:          {},
+		EventConsumed:         {},
+		EventExpiringSoon:     {},
+		EventExpired:          {},
+		EventTimelockReleased: {},
+	}
+
+	// defaultEvents are the events of a hook that does not list any.
+	defaultEvents = []string{EventCreated, EventConsumed}
+)
+
+// HookParameters define a hook, either in the config file or in the body of the admin API.
+type HookParameters struct {
+	// Name is the unique name of the hook.
+	Name string `json:"name"`
+	// URL is the URL the matching outputs are POSTed to.
+	URL string `json:"url"`
+	// Secret is the key of the HMAC signature of the requests.
+	Secret string `json:"secret,omitempty"`
+	// Events are the events the hook subscribes to, created and consumed outputs if empty.
+	Events []string `json:"events,omitempty"`
+	// Filter are the criteria the outputs have to match.
+	Filter FilterParameters `json:"filter"`
+}
+
+// Hook is a registered webhook.
+type Hook struct {
+	// Name is the unique name of the hook.
+	Name string
+	// URL is the URL the matching outputs are POSTed to.
+	URL string
+	// Secret is the key of the HMAC signature of the requests.
+	Secret string
+	// Configured is set if the hook is defined in the config file, otherwise it was added through the admin API.
+	Configured bool
+
+	events map[string]struct{}
+	filter *Filter
+	params HookParameters
+}
+
+// NewHook validates the parameters of a hook, the addresses of the filter have to use the given bech32 prefix.
+func NewHook(params HookParameters, hrp iotago.NetworkPrefix, configured bool) (*Hook, error) {
+	if params.Name == "" {
+		return nil, errors.New("webhook without name")
+	}
+
+	hookURL, err := url.Parse(params.URL)
+	if err != nil || (hookURL.Scheme != "http" && hookURL.Scheme != "https") || hookURL.Host == "" {
+		return nil, errors.Errorf("invalid URL of webhook %s: %s", params.Name, params.URL)
+	}
+
+	events := params.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+
+	hook := &Hook{
+		Name:       params.Name,
+		URL:        params.URL,
+		Secret:     params.Secret,
+		Configured: configured,
+		events:     make(map[string]struct{}, len(events)),
+		params:     params,
+	}
+
+	for _, event := range events {
+		if _, ok := validEvents[event]; !ok {
+			return nil, errors.Errorf("invalid event of webhook %s: %s", params.Name, event)
+		}
+		hook.events[event] = struct{}{}
+	}
+
+	hook.filter, err = NewFilter(params.Filter, hrp)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid filter of webhook %s", params.Name)
+	}
+
+	return hook, nil
+}
+
+// Subscribed returns whether the hook subscribed to the event.
+func (h *Hook) Subscribed(event string) bool {
+	_, ok := h.events[event]
+
+	return ok
+}
+
+// Filter returns the filter of the hook.
+func (h *Hook) Filter() *Filter {
+	return h.filter
+}
+
+// Parameters returns the parameters the hook was created with.
+func (h *Hook) Parameters() HookParameters {
+	return h.params
+}
@@ -0,0 +1,428 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/generics/options"
+	"github.com/iotaledger/hive.go/core/logger"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/scheduler"
+	inx "github.com/iotaledger/inx/go"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// eventLedgerUpdate is the event of the deliveries that contain the created and consumed outputs of a milestone.
+	eventLedgerUpdate = "ledgerUpdate"
+)
+
+// outputPayload is an output in the body of a delivery.
+type outputPayload struct {
+	OutputID string          `json:"outputId"`
+	Output   json.RawMessage `json:"output"`
+}
+
+// ledgerUpdatePayload is the body of a delivery with the matching outputs of a milestone.
+type ledgerUpdatePayload struct {
+	Hook           string           `json:"hook"`
+	Event          string           `json:"event"`
+	MilestoneIndex uint32           `json:"milestoneIndex"`
+	Created        []*outputPayload `json:"created"`
+	Consumed       []*outputPayload `json:"consumed"`
+}
+
+// scheduledPayload is the body of a delivery with an event of the scheduler.
+type scheduledPayload struct {
+	Hook               string            `json:"hook"`
+	Event              string            `json:"event"`
+	MilestoneIndex     uint32            `json:"milestoneIndex"`
+	MilestoneTimestamp uint32            `json:"milestoneTimestamp"`
+	OutputID           string            `json:"outputId"`
+	OutputType         iotago.OutputType `json:"outputType"`
+	Owner              string            `json:"owner"`
+	ReturnAddress      string            `json:"returnAddress,omitempty"`
+	Time               uint32            `json:"time"`
+}
+
+// Manager keeps the registry of the hooks and delivers the matching outputs to them.
+type Manager struct {
+	*logger.WrappedLogger
+
+	store *store
+	hrp   iotago.NetworkPrefix
+
+	// hooksUpdateLock serializes the changes of the admin API. The hooks are written to the database without holding
+	// hooksLock, so the transaction of a milestone that reads the hooks never waits for a write of the admin API.
+	hooksUpdateLock sync.Mutex
+	hooksLock       sync.RWMutex
+	hooks           map[string]*Hook
+
+	client *http.Client
+	// wakeup signals the delivery loop that new deliveries were enqueued.
+	wakeup chan struct{}
+
+	// requestTimeout is the timeout of a single request to a hook.
+	requestTimeout time.Duration
+	// retryInitialBackoff is the time until the first retry of a failed delivery, it doubles with every attempt.
+	retryInitialBackoff time.Duration
+	// retryMaxBackoff is the maximum time between two attempts of a delivery.
+	retryMaxBackoff time.Duration
+	// maxAttempts is the amount of attempts after which a delivery is dropped, 0 means unlimited.
+	maxAttempts uint32
+}
+
+// WithRequestTimeout sets the timeout of a single request to a hook.
+func WithRequestTimeout(timeout time.Duration) options.Option[Manager] {
+	return func(m *Manager) {
+		m.requestTimeout = timeout
+	}
+}
+
+// WithRetryBackoff sets the time until the first retry of a failed delivery and the maximum time between two attempts.
+func WithRetryBackoff(initial time.Duration, max time.Duration) options.Option[Manager] {
+	return func(m *Manager) {
+		m.retryInitialBackoff = initial
+		m.retryMaxBackoff = max
+	}
+}
+
+// WithMaxAttempts sets the amount of attempts after which a delivery is dropped (0 = unlimited).
+func WithMaxAttempts(maxAttempts uint32) options.Option[Manager] {
+	return func(m *Manager) {
+		m.maxAttempts = maxAttempts
+	}
+}
+
+// NewManager creates the tables of the webhooks in the given database and loads the hooks of the admin API.
+// The addresses in the filters of the hooks have to use the given bech32 prefix.
+func NewManager(db *gorm.DB, hrp iotago.NetworkPrefix, log *logger.Logger, opts ...options.Option[Manager]) (*Manager, error) {
+	s, err := newStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	m := options.Apply(&Manager{
+		WrappedLogger:       logger.NewWrappedLogger(log),
+		store:               s,
+		hrp:                 hrp,
+		hooks:               make(map[string]*Hook),
+		wakeup:              make(chan struct{}, 1),
+		requestTimeout:      10 * time.Second,
+		retryInitialBackoff: 5 * time.Second,
+		retryMaxBackoff:     time.Hour,
+	}, opts)
+	m.client = &http.Client{Timeout: m.requestTimeout}
+
+	storedHooks, err := s.hooks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, params := range storedHooks {
+		hook, err := NewHook(params, hrp, false)
+		if err != nil {
+			return nil, err
+		}
+		m.hooks[hook.Name] = hook
+	}
+
+	return m, nil
+}
+
+// AddConfiguredHooks adds the hooks of the config file.
+// They replace hooks of the admin API with the same name.
+func (m *Manager) AddConfiguredHooks(hooks []HookParameters) error {
+	m.hooksLock.Lock()
+	defer m.hooksLock.Unlock()
+
+	for _, params := range hooks {
+		hook, err := NewHook(params, m.hrp, true)
+		if err != nil {
+			return err
+		}
+
+		if existing, exists := m.hooks[hook.Name]; exists {
+			if existing.Configured {
+				return errors.WithMessagef(ErrHookExists, "webhook %s is configured twice", hook.Name)
+			}
+			m.LogWarnf("Webhook %s of the config file replaces the webhook of the admin API with the same name", hook.Name)
+		}
+		m.hooks[hook.Name] = hook
+	}
+
+	return nil
+}
+
+// Hooks returns all registered hooks ordered by their name.
+func (m *Manager) Hooks() []*Hook {
+	m.hooksLock.RLock()
+	defer m.hooksLock.RUnlock()
+
+	hooks := make([]*Hook, 0, len(m.hooks))
+	for _, hook := range m.hooks {
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].Name < hooks[j].Name })
+
+	return hooks
+}
+
+// Hook returns the hook with the given name.
+func (m *Manager) Hook(name string) (*Hook, error) {
+	m.hooksLock.RLock()
+	defer m.hooksLock.RUnlock()
+
+	hook, exists := m.hooks[name]
+	if !exists {
+		return nil, ErrHookNotFound
+	}
+
+	return hook, nil
+}
+
+// PendingDeliveries returns the amount of deliveries of the hook that were not delivered yet.
+func (m *Manager) PendingDeliveries(name string) (int64, error) {
+	return m.store.pendingDeliveries(name)
+}
+
+// AddHook validates and persists a hook of the admin API.
+func (m *Manager) AddHook(params HookParameters) (*Hook, error) {
+	hook, err := NewHook(params, m.hrp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.hooksUpdateLock.Lock()
+	defer m.hooksUpdateLock.Unlock()
+
+	if _, err := m.Hook(hook.Name); err == nil {
+		return nil, ErrHookExists
+	}
+
+	if err := m.store.addHook(params); err != nil {
+		return nil, err
+	}
+
+	m.hooksLock.Lock()
+	defer m.hooksLock.Unlock()
+	m.hooks[hook.Name] = hook
+
+	return hook, nil
+}
+
+// DeleteHook removes a hook of the admin API together with its pending deliveries.
+func (m *Manager) DeleteHook(name string) error {
+	m.hooksUpdateLock.Lock()
+	defer m.hooksUpdateLock.Unlock()
+
+	hook, err := m.Hook(name)
+	if err != nil {
+		return err
+	}
+	if hook.Configured {
+		return ErrHookConfigured
+	}
+
+	if err := m.store.deleteHook(name); err != nil {
+		return err
+	}
+
+	m.hooksLock.Lock()
+	defer m.hooksLock.Unlock()
+	delete(m.hooks, name)
+
+	return nil
+}
+
+// matchingOutputs returns the outputs that match the filter of the hook.
+func matchingOutputs(hook *Hook, outputs []*inx.LedgerOutput, unwrapped []iotago.Output) ([]*outputPayload, error) {
+	matches := make([]*outputPayload, 0)
+	for i, output := range unwrapped {
+		if !hook.filter.Matches(output) {
+			continue
+		}
+
+		outputJSON, err := json.Marshal(output)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, &outputPayload{
+			OutputID: outputs[i].GetOutputId().Unwrap().ToHex(),
+			Output:   outputJSON,
+		})
+	}
+
+	return matches, nil
+}
+
+func unwrapOutputs(outputs []*inx.LedgerOutput) ([]iotago.Output, error) {
+	unwrapped := make([]iotago.Output, 0, len(outputs))
+	for _, output := range outputs {
+		iotaOutput, err := output.UnwrapOutput(serializer.DeSeriModeNoValidation, nil)
+		if err != nil {
+			return nil, err
+		}
+		unwrapped = append(unwrapped, iotaOutput)
+	}
+
+	return unwrapped, nil
+}
+
+// WriteLedgerUpdate writes a delivery of the matching created and consumed outputs of the milestone for every hook.
+// It is called in the transaction of the milestone, so the deliveries are committed together with the changes of the ledger
+// and are not lost if the indexer stops in between. Wakeup has to be called after the transaction was committed.
+func (m *Manager) WriteLedgerUpdate(tx *gorm.DB, update *nodebridge.LedgerUpdate) error {
+	// outputs that were created and consumed in the same milestone never became part of the indexed ledger
+	consumedOutputs := make(map[iotago.OutputID]struct{}, len(update.Consumed))
+	for _, spent := range update.Consumed {
+		consumedOutputs[spent.GetOutput().GetOutputId().Unwrap()] = struct{}{}
+	}
+
+	createdOutputs := make(map[iotago.OutputID]struct{}, len(update.Created))
+	created := make([]*inx.LedgerOutput, 0, len(update.Created))
+	for _, output := range update.Created {
+		outputID := output.GetOutputId().Unwrap()
+		createdOutputs[outputID] = struct{}{}
+		if _, wasSpentInSameMilestone := consumedOutputs[outputID]; !wasSpentInSameMilestone {
+			created = append(created, output)
+		}
+	}
+
+	consumed := make([]*inx.LedgerOutput, 0, len(update.Consumed))
+	for _, spent := range update.Consumed {
+		if _, wasCreatedInSameMilestone := createdOutputs[spent.GetOutput().GetOutputId().Unwrap()]; !wasCreatedInSameMilestone {
+			consumed = append(consumed, spent.GetOutput())
+		}
+	}
+
+	unwrappedCreated, err := unwrapOutputs(created)
+	if err != nil {
+		return err
+	}
+	unwrappedConsumed, err := unwrapOutputs(consumed)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []*webhookDelivery
+	for _, hook := range m.Hooks() {
+		payload := &ledgerUpdatePayload{
+			Hook:           hook.Name,
+			Event:          eventLedgerUpdate,
+			MilestoneIndex: update.MilestoneIndex,
+			Created:        make([]*outputPayload, 0),
+			Consumed:       make([]*outputPayload, 0),
+		}
+
+		if hook.Subscribed(EventCreated) {
+			if payload.Created, err = matchingOutputs(hook, created, unwrappedCreated); err != nil {
+				return err
+			}
+		}
+		if hook.Subscribed(EventConsumed) {
+			if payload.Consumed, err = matchingOutputs(hook, consumed, unwrappedConsumed); err != nil {
+				return err
+			}
+		}
+
+		if len(payload.Created) == 0 && len(payload.Consumed) == 0 {
+			continue
+		}
+
+		delivery, err := newDelivery(hook.Name, eventLedgerUpdate, update.MilestoneIndex, payload)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return m.store.enqueue(tx, deliveries)
+}
+
+// WriteScheduledEvents writes a delivery of the events of the scheduler for every hook that subscribed to them in the
+// transaction that stores the progress of the scheduler, so the events are neither lost nor repeated after a restart.
+// The scheduler only knows the addresses of the output, so only the output types and addresses of the filters are checked.
+func (m *Manager) WriteScheduledEvents(tx *gorm.DB, events *scheduler.MilestoneEvents) error {
+	// the events are delivered in the order the scheduler triggers them
+	scheduledEvents := []struct {
+		name   string
+		events []*scheduler.Event
+	}{
+		{name: EventExpiringSoon, events: events.ExpiringSoon},
+		{name: EventExpired, events: events.Expired},
+		{name: EventTimelockReleased, events: events.TimelockReleased},
+	}
+
+	var deliveries []*webhookDelivery
+	for _, hook := range m.Hooks() {
+		for _, scheduled := range scheduledEvents {
+			event := scheduled.name
+			if !hook.Subscribed(event) {
+				continue
+			}
+
+			for _, e := range scheduled.events {
+				if !hook.filter.MatchesAddresses(e.Output.OutputType, e.Output.Owner, e.Output.ReturnAddress) {
+					continue
+				}
+
+				delivery, err := newDelivery(hook.Name, event, e.MilestoneIndex, m.scheduledPayload(hook.Name, event, e))
+				if err != nil {
+					return err
+				}
+				deliveries = append(deliveries, delivery)
+			}
+		}
+	}
+
+	return m.store.enqueue(tx, deliveries)
+}
+
+func (m *Manager) scheduledPayload(hook string, event string, e *scheduler.Event) *scheduledPayload {
+	payload := &scheduledPayload{
+		Hook:               hook,
+		Event:              event,
+		MilestoneIndex:     e.MilestoneIndex,
+		MilestoneTimestamp: uint32(e.MilestoneTimestamp.Unix()),
+		OutputID:           e.Output.OutputID.ToHex(),
+		OutputType:         e.Output.OutputType,
+		Owner:              e.Output.Owner.Bech32(m.hrp),
+		Time:               uint32(e.Output.Time.Unix()),
+	}
+	if e.Output.ReturnAddress != nil {
+		payload.ReturnAddress = e.Output.ReturnAddress.Bech32(m.hrp)
+	}
+
+	return payload
+}
+
+func newDelivery(hook string, event string, milestoneIndex uint32, payload interface{}) (*webhookDelivery, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookDelivery{
+		Hook:           hook,
+		Event:          event,
+		MilestoneIndex: milestoneIndex,
+		Payload:        data,
+		NextAttempt:    time.Now(),
+	}, nil
+}
+
+// Wakeup signals the delivery loop to send the deliveries that were enqueued.
+func (m *Manager) Wakeup() {
+	select {
+	case m.wakeup <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,122 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// webhook is a hook that was added through the admin API.
+type webhook struct {
+	Name string `gorm:"primaryKey;notnull"`
+	// Parameters are the JSON encoded HookParameters of the hook.
+	Parameters []byte `gorm:"notnull"`
+}
+
+// webhookDelivery is a request to a hook that was not delivered yet.
+// The deliveries are kept in the database until they succeeded, so they survive restarts.
+type webhookDelivery struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement"`
+	Hook           string    `gorm:"notnull;index:webhook_deliveries_hook"`
+	Event          string    `gorm:"notnull"`
+	MilestoneIndex uint32    `gorm:"notnull"`
+	Payload        []byte    `gorm:"notnull"`
+	Attempts       uint32    `gorm:"notnull"`
+	NextAttempt    time.Time `gorm:"notnull"`
+	LastError      string
+}
+
+// store persists the hooks of the admin API and the pending deliveries.
+type store struct {
+	db *gorm.DB
+}
+
+func newStore(db *gorm.DB) (*store, error) {
+	if err := db.AutoMigrate(&webhook{}, &webhookDelivery{}); err != nil {
+		return nil, err
+	}
+
+	return &store{db: db}, nil
+}
+
+func (s *store) hooks() ([]HookParameters, error) {
+	var rows []webhook
+	if err := s.db.Order("name asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hooks := make([]HookParameters, 0, len(rows))
+	for _, row := range rows {
+		var params HookParameters
+		if err := json.Unmarshal(row.Parameters, &params); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, params)
+	}
+
+	return hooks, nil
+}
+
+func (s *store) addHook(params HookParameters) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Create(&webhook{Name: params.Name, Parameters: data}).Error
+}
+
+// deleteHook removes the hook together with its pending deliveries.
+func (s *store) deleteHook(name string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hook = ?", name).Delete(&webhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("name = ?", name).Delete(&webhook{}).Error
+	})
+}
+
+// enqueue writes the deliveries with the given connection, which is either the database of the store or a transaction.
+func (s *store) enqueue(db *gorm.DB, deliveries []*webhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return db.Create(deliveries).Error
+}
+
+// dueDeliveries returns the oldest pending delivery of every hook, if it is due.
+// Later deliveries of a hook wait for the earlier ones, so every hook receives the milestones in order.
+func (s *store) dueDeliveries(now time.Time) ([]*webhookDelivery, error) {
+	oldest := s.db.Model(&webhookDelivery{}).Select("MIN(id)").Group("hook")
+
+	var deliveries []*webhookDelivery
+	if err := s.db.Where("id IN (?) AND next_attempt <= ?", oldest, now).Order("id asc").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *store) pendingDeliveries(hook string) (int64, error) {
+	var count int64
+	if err := s.db.Model(&webhookDelivery{}).Where("hook = ?", hook).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *store) deleteDelivery(id uint64) error {
+	return s.db.Where("id = ?", id).Delete(&webhookDelivery{}).Error
+}
+
+func (s *store) retryDelivery(delivery *webhookDelivery) error {
+	return s.db.Model(delivery).Updates(map[string]interface{}{
+		"attempts":     delivery.Attempts,
+		"next_attempt": delivery.NextAttempt,
+		"last_error":   delivery.LastError,
+	}).Error
+}
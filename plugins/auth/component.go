@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/dig"
+	"gorm.io/gorm"
+
+	"github.com/iotaledger/hive.go/core/app"
+	indexerComponent "github.com/iotaledger/inx-indexer/core/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
+	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Auth",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsAuth.Enabled
+		},
+	}
+}
+
+type dependencies struct {
+	dig.In
+	Authenticator *auth.Authenticator
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+
+	// authDatabase is the connection used for the table of the API keys, it is kept if the indexer is cleared.
+	authDatabase *gorm.DB
+)
+
+func provide(c *dig.Container) error {
+	// the indexer has to be created first, it creates the database if it does not exist yet
+	return c.Provide(func(_ *indexer.Indexer) (*auth.Authenticator, error) {
+		dbParams, err := indexerComponent.ParamsIndexer.DatabaseParams()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkMaxPageSizes(); err != nil {
+			return nil, err
+		}
+
+		authDatabase, _, err = database.NewWithDefaultSettings(dbParams, false, Plugin.Logger())
+		if err != nil {
+			return nil, err
+		}
+
+		return auth.NewAuthenticator(authDatabase, Plugin.Logger(),
+			auth.WithKeys(configuredKeys()),
+			auth.WithJWT([]byte(ParamsAuth.JWT.Secret), ParamsAuth.JWT.RateLimit, ParamsAuth.JWT.Burst, ParamsAuth.JWT.MaxPageSize),
+			// the page size of a JWT can not exceed the page size of the API
+			auth.WithJWTMaximums(ParamsAuth.JWT.MaxRateLimit, ParamsAuth.JWT.MaxBurst, indexerComponent.ParamsRestAPI.MaxPageSize),
+			auth.WithRefreshInterval(ParamsAuth.RefreshInterval),
+		)
+	})
+}
+
+// checkMaxPageSizes checks that the maximum page sizes of the config file are allowed by the maximum query cost of the API.
+// The page sizes of the API keys in the database can't be checked at startup, they are added at runtime.
+func checkMaxPageSizes() error {
+	maxCost := indexerComponent.ParamsRestAPI.QueryCost.MaxCost
+
+	if err := indexer.CheckMaxQueryCost(maxCost, ParamsAuth.JWT.MaxPageSize); err != nil {
+		return errors.WithMessage(err, "invalid auth.jwt.maxPageSize")
+	}
+
+	for _, key := range ParamsAuth.Keys {
+		if err := indexer.CheckMaxQueryCost(maxCost, key.MaxPageSize); err != nil {
+			return errors.WithMessagef(err, "invalid maxPageSize of API key %s", key.Name)
+		}
+	}
+
+	return nil
+}
+
+// configuredKeys converts the API keys of the config file to the parameters of the authenticator.
+func configuredKeys() []auth.KeyParameters {
+	keys := make([]auth.KeyParameters, 0, len(ParamsAuth.Keys))
+	for _, key := range ParamsAuth.Keys {
+		keys = append(keys, auth.KeyParameters{
+			Name:        key.Name,
+			Key:         key.Key,
+			RateLimit:   key.RateLimit,
+			Burst:       key.Burst,
+			MaxPageSize: key.MaxPageSize,
+		})
+	}
+
+	return keys
+}
+
+func configure() error {
+	Plugin.LogInfof("Loaded %d API keys", deps.Authenticator.Keys())
+	if ParamsAuth.JWT.Secret != "" {
+		Plugin.LogInfo("Accepting JWTs signed with HS256")
+	}
+
+	return nil
+}
+
+func run() error {
+	return Plugin.Daemon().BackgroundWorker("Auth", func(ctx context.Context) {
+		Plugin.LogInfo("Starting Auth ... done")
+		defer func() {
+			sqlDB, err := authDatabase.DB()
+			if err == nil {
+				err = sqlDB.Close()
+			}
+			if err != nil {
+				Plugin.LogErrorf("Failed to close database: %s", err.Error())
+			}
+		}()
+
+		deps.Authenticator.Run(ctx)
+
+		Plugin.LogInfo("Stopping Auth ... done")
+	}, daemon.PriorityStopAuth)
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/core/app"
+)
+
+// KeyConfig defines an API key in the config file.
+type KeyConfig struct {
+	// Name is the unique name of the key.
+	Name string `usage:"the unique name of the key"`
+	// Key is the secret the client sends with its requests.
+	Key string `usage:"the secret the client sends in the X-API-Key header or as bearer token"`
+	// RateLimit is the amount of requests per second the client may send.
+	RateLimit float64 `usage:"the amount of requests per second the client may send (0 = unlimited)"`
+	// Burst is the amount of requests the client may send at once.
+	Burst int `usage:"the amount of requests the client may send at once"`
+	// MaxPageSize is the maximum amount of results per page for the client.
+	MaxPageSize int `usage:"the maximum amount of results per page for the client, overrides restAPI.maxPageSize (0 = restAPI.maxPageSize)"`
+}
+
+// ParametersAuth contains the definition of the parameters used by the authentication of the API.
+type ParametersAuth struct {
+	// Enabled defines whether the API requires an API key or JWT.
+	Enabled bool `default:"false" usage:"whether the API requires an API key or JWT, the health routes are always available"`
+	// Keys defines the API keys of the config file.
+	Keys []KeyConfig `noflag:"true" usage:"the API keys of the config file"`
+	// RefreshInterval defines the interval in which the API keys are read from the database.
+	RefreshInterval time.Duration `default:"1m" usage:"the interval in which the API keys are read from the api_keys table of the database"`
+
+	JWT struct {
+		// Secret defines the key of the HMAC signature of the JWTs.
+		Secret string `default:"" usage:"the key of the HS256 signature of the JWTs (JWTs are not accepted if empty)"`
+		// RateLimit defines the amount of requests per second a client with a JWT may send.
+		RateLimit float64 `default:"10" usage:"the amount of requests per second a client with a JWT may send if the JWT has no rateLimit claim (0 = unlimited)"`
+		// Burst defines the amount of requests a client with a JWT may send at once.
+		Burst int `default:"20" usage:"the amount of requests a client with a JWT may send at once if the JWT has no burst claim"`
+		// MaxPageSize defines the maximum amount of results per page for a client with a JWT.
+		MaxPageSize int `default:"0" usage:"the maximum amount of results per page for a client with a JWT if the JWT has no maxPageSize claim (0 = restAPI.maxPageSize)"`
+		// MaxRateLimit defines the highest rate limit the rateLimit claim of a JWT can grant.
+		MaxRateLimit float64 `default:"100" usage:"the highest amount of requests per second the rateLimit claim of a JWT can grant, a claim of 0 is limited as well (0 = no maximum)"`
+		// MaxBurst defines the highest burst the burst claim of a JWT can grant.
+		MaxBurst int `default:"200" usage:"the highest amount of requests at once the burst claim of a JWT can grant (0 = no maximum)"`
+	} `name:"jwt"`
+}
+
+var ParamsAuth = &ParametersAuth{
+	Keys: []KeyConfig{},
+}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"auth": ParamsAuth,
+	},
+	Masked: []string{"auth.keys", "auth.jwt.secret"},
+}
@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/dig"
+
+	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/outbox"
+)
+
+const (
+	// PublisherFile appends the messages to a file.
+	PublisherFile = "file"
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Outbox",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsOutbox.Enabled
+		},
+	}
+}
+
+type dependencies struct {
+	dig.In
+	Indexer   *indexer.Indexer
+	Publisher outbox.Publisher
+	Relay     *outbox.Relay
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+)
+
+func provide(c *dig.Container) error {
+
+	if err := c.Provide(func() (outbox.Publisher, error) {
+		switch ParamsOutbox.Publisher {
+		case PublisherFile:
+			return outbox.NewFilePublisher(ParamsOutbox.File.Path)
+		default:
+			return nil, fmt.Errorf("unknown outbox publisher: %s, supported publishers: %s", ParamsOutbox.Publisher, PublisherFile)
+		}
+	}); err != nil {
+		return err
+	}
+
+	return c.Provide(func(idx *indexer.Indexer, publisher outbox.Publisher) *outbox.Relay {
+		return outbox.NewRelay(idx, publisher, Plugin.Logger(),
+			outbox.WithPollInterval(ParamsOutbox.PollInterval),
+			outbox.WithRetryInterval(ParamsOutbox.RetryInterval),
+			outbox.WithBatchSize(ParamsOutbox.BatchSize),
+		)
+	})
+}
+
+func configure() error {
+	// the outbox has to be enabled before the indexer applies the first milestone
+	if err := deps.Indexer.EnableOutbox(); err != nil {
+		return err
+	}
+
+	deps.Indexer.Events.LedgerUpdated.Hook(event.NewClosure(func(_ *nodebridge.LedgerUpdate) {
+		deps.Relay.Wakeup()
+	}))
+
+	return nil
+}
+
+func run() error {
+	return Plugin.Daemon().BackgroundWorker("Outbox", func(ctx context.Context) {
+		Plugin.LogInfo("Starting Outbox ... done")
+		defer func() {
+			if err := deps.Publisher.Close(); err != nil {
+				Plugin.LogWarnf("Closing outbox publisher failed: %s", err)
+			}
+		}()
+
+		deps.Relay.Run(ctx)
+
+		Plugin.LogInfo("Stopping Outbox ... done")
+	}, daemon.PriorityStopOutbox)
+}
@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/core/app"
+)
+
+// ParametersOutbox contains the definition of the parameters used by the outbox.
+type ParametersOutbox struct {
+	// Enabled defines whether the outbox plugin is enabled.
+	Enabled bool `default:"false" usage:"whether the outbox plugin is enabled"`
+	// Publisher defines the publisher of the outbox messages.
+	Publisher string `default:"file" usage:"the publisher of the outbox messages (file)"`
+	// PollInterval defines the interval in which the outbox is checked for new messages.
+	PollInterval time.Duration `default:"1s" usage:"the interval in which the outbox is checked for new messages"`
+	// RetryInterval defines the time until a message is published again after publishing failed.
+	RetryInterval time.Duration `default:"5s" usage:"the time until a message is published again after publishing failed"`
+	// BatchSize defines the maximum amount of messages read from the outbox at once.
+	BatchSize int `default:"100" usage:"the maximum amount of messages read from the outbox at once"`
+
+	File struct {
+		// Path defines the path of the file the messages are appended to.
+		Path string `default:"outbox.ndjson" usage:"the path of the file the messages are appended to"`
+	} `name:"file"`
+}
+
+var ParamsOutbox = &ParametersOutbox{}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"outbox": ParamsOutbox,
+	},
+	Masked: nil,
+}
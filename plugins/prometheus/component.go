@@ -16,7 +16,10 @@ import (
 	"go.uber.org/dig"
 
 	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
 	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
 )
 
 func init() {
@@ -39,6 +42,9 @@ type dependencies struct {
 	dig.In
 	Echo           *echo.Echo
 	PrometheusEcho *echo.Echo `name:"prometheusEcho"`
+	Indexer        *indexer.Indexer
+	NodeBridge     *nodebridge.NodeBridge
+	Authenticator  *auth.Authenticator `optional:"true"`
 }
 
 var (
@@ -65,6 +71,9 @@ func provide(c *dig.Container) error {
 }
 
 func configure() error {
+	if ParamsPrometheus.IndexerMetrics && ParamsPrometheus.TableRowsInterval <= 0 {
+		return errors.New("prometheus.tableRowsInterval has to be greater than zero")
+	}
 
 	registry := registerMetrics()
 
@@ -90,6 +99,14 @@ func configure() error {
 }
 
 func run() error {
+	if ParamsPrometheus.IndexerMetrics {
+		if err := Plugin.Daemon().BackgroundWorker("Prometheus table rows", func(ctx context.Context) {
+			runTableRowsUpdates(ctx, ParamsPrometheus.TableRowsInterval)
+		}, daemon.PriorityStopPrometheus); err != nil {
+			return err
+		}
+	}
+
 	return Plugin.Daemon().BackgroundWorker("Prometheus exporter", func(ctx context.Context) {
 		Plugin.LogInfo("Starting Prometheus exporter ... done")
 
@@ -131,12 +148,18 @@ func registerMetrics() *prometheus.Registry {
 		registry.MustRegister(grpcprometheus.DefaultClientMetrics)
 	}
 
+	if ParamsPrometheus.IndexerMetrics {
+		configureIndexerMetrics(registry)
+	}
+
 	if ParamsPrometheus.RestAPIMetrics {
 		p := echoprometheus.NewPrometheus("iota_restapi", nil)
 		for _, m := range p.MetricsList {
 			registry.MustRegister(m.MetricCollector)
 		}
 		deps.Echo.Use(p.HandlerFunc)
+
+		configureRestAPIMetrics(registry)
 	}
 
 	return registry
@@ -0,0 +1,212 @@
+package prometheus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+)
+
+const (
+	// tableRowsTimeout is the timeout for reading the row counts of the tables.
+	tableRowsTimeout = 30 * time.Second
+)
+
+var (
+	ledgerUpdateDuration prometheus.Histogram
+	createdOutputs       prometheus.Histogram
+	consumedOutputs      prometheus.Histogram
+	tableRows            *tableRowsCollector
+)
+
+// tableRowsCollector reports the row counts of the tables that were last read by update.
+// Counting the rows takes long on big tables, so they are not read on a scrape.
+type tableRowsCollector struct {
+	desc *prometheus.Desc
+
+	mutex  sync.RWMutex
+	counts map[string]int64
+	err    error
+}
+
+func (c *tableRowsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *tableRowsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, c.err)
+
+		return
+	}
+
+	for table, count := range c.counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), table)
+	}
+}
+
+// update reads the row counts of the tables.
+func (c *tableRowsCollector) update(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, tableRowsTimeout)
+	defer cancel()
+
+	counts, err := deps.Indexer.TableRowCounts(ctx)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// the node is shutting down, keep the last counts
+		return
+	}
+	c.counts = counts
+	c.err = err
+}
+
+// runTableRowsUpdates updates the row counts of the tables in the given interval until the context is done.
+func runTableRowsUpdates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		tableRows.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func configureIndexerMetrics(registry *prometheus.Registry) {
+	registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "ledger_index",
+			Help:      "The latest ledger index written to the database.",
+		},
+		func() float64 {
+			return float64(deps.Indexer.LedgerIndex())
+		},
+	))
+
+	registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "ledger_index_lag",
+			Help:      "The amount of milestones the ledger index lags behind the confirmed milestone of the node.",
+		},
+		func() float64 {
+			confirmedIndex := deps.NodeBridge.ConfirmedMilestoneIndex()
+			ledgerIndex := deps.Indexer.LedgerIndex()
+			if ledgerIndex >= confirmedIndex {
+				return 0
+			}
+
+			return float64(confirmedIndex - ledgerIndex)
+		},
+	))
+
+	ledgerUpdateDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "ledger_update_duration_seconds",
+			Help:      "The time it took to commit the changes of a milestone.",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+	)
+	registry.MustRegister(ledgerUpdateDuration)
+
+	createdOutputs = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "milestone_created_outputs",
+			Help:      "The amount of outputs created per milestone.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 9),
+		},
+	)
+	registry.MustRegister(createdOutputs)
+
+	consumedOutputs = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "milestone_consumed_outputs",
+			Help:      "The amount of outputs consumed per milestone.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 9),
+		},
+	)
+	registry.MustRegister(consumedOutputs)
+
+	tableRows = &tableRowsCollector{
+		desc: prometheus.NewDesc(
+			"iota_indexer_table_rows",
+			"The amount of rows of the output tables (estimated on PostgreSQL), updated every prometheus.tableRowsInterval.",
+			[]string{"table"},
+			nil,
+		),
+	}
+	registry.MustRegister(tableRows)
+
+	registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "import_running",
+			Help:      "Whether the import of the initial ledger is running.",
+		},
+		func() float64 {
+			if deps.Indexer.ImportProgress().Running {
+				return 1
+			}
+
+			return 0
+		},
+	))
+
+	registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "import_outputs_received",
+			Help:      "The amount of outputs received from the node by the running or the last import of the initial ledger.",
+		},
+		func() float64 {
+			return float64(deps.Indexer.ImportProgress().OutputsAdded)
+		},
+	))
+
+	registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "indexer",
+			Name:      "import_outputs_inserted",
+			Help:      "The amount of outputs inserted into the database by the running or the last import of the initial ledger.",
+		},
+		func() float64 {
+			return float64(deps.Indexer.ImportProgress().OutputsInserted)
+		},
+	))
+
+	deps.Indexer.Events.LedgerUpdateDuration.Attach(event.NewClosure(func(duration time.Duration) {
+		ledgerUpdateDuration.Observe(duration.Seconds())
+	}))
+
+	deps.Indexer.Events.LedgerUpdated.Attach(event.NewClosure(func(update *nodebridge.LedgerUpdate) {
+		createdOutputs.Observe(float64(len(update.Created)))
+		consumedOutputs.Observe(float64(len(update.Consumed)))
+	}))
+}
@@ -1,6 +1,8 @@
 package prometheus
 
 import (
+	"time"
+
 	"github.com/iotaledger/hive.go/core/app"
 )
 
@@ -14,6 +16,10 @@ type ParametersPrometheus struct {
 	GoMetrics bool `default:"false" usage:"whether to include go metrics"`
 	// ProcessMetrics defines whether to include process metrics.
 	ProcessMetrics bool `default:"false" usage:"whether to include process metrics"`
+	// IndexerMetrics defines whether to include indexer metrics.
+	IndexerMetrics bool `default:"true" usage:"whether to include indexer metrics"`
+	// TableRowsInterval defines the interval in which the row counts of the output tables are updated.
+	TableRowsInterval time.Duration `default:"1m" usage:"the interval in which the row counts of the output tables are updated"`
 	// RestAPIMetrics include restAPI metrics.
 	RestAPIMetrics bool `default:"true" usage:"whether to include restAPI metrics"`
 	// INXMetrics defines whether to include INXMetrics metrics.
@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-indexer/pkg/auth"
+	"github.com/iotaledger/inx-indexer/pkg/server"
+)
+
+var (
+	queryTimeouts    prometheus.Counter
+	queryDuration    *prometheus.HistogramVec
+	rejectedRequests *prometheus.CounterVec
+)
+
+func configureRestAPIMetrics(registry *prometheus.Registry) {
+	queryTimeouts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "iota",
+			Subsystem: "restapi",
+			Name:      "query_timeouts_total",
+			Help:      "The total number of queries that exceeded the maximum query duration.",
+		},
+	)
+	registry.MustRegister(queryTimeouts)
+
+	deps.Indexer.Events.QueryTimedOut.Attach(event.NewClosure(func(_ time.Duration) {
+		queryTimeouts.Inc()
+	}))
+
+	queryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "iota",
+			Subsystem: "restapi",
+			Name:      "query_duration_seconds",
+			Help:      "The duration of the requests per route and combination of filters.",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"method", "route", "filters"},
+	)
+	registry.MustRegister(queryDuration)
+
+	deps.Echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ts := time.Now()
+			err := next(c)
+
+			// unknown routes are not observed, otherwise every requested path would create a new series
+			if c.Path() != "" {
+				queryDuration.WithLabelValues(c.Request().Method, c.Path(), server.FilterSet(c)).Observe(time.Since(ts).Seconds())
+			}
+
+			return err
+		}
+	})
+
+	if deps.Authenticator != nil {
+		rejectedRequests = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "iota",
+				Subsystem: "restapi",
+				Name:      "rejected_requests_total",
+				Help:      "The total number of requests rejected due to missing or invalid credentials or an exceeded rate limit.",
+			},
+			[]string{"reason", "client"},
+		)
+		registry.MustRegister(rejectedRequests)
+
+		deps.Authenticator.Events.RequestRejected.Attach(event.NewClosure(func(rejected *auth.RejectedRequest) {
+			rejectedRequests.WithLabelValues(rejected.Reason, rejected.Client).Inc()
+		}))
+	}
+}
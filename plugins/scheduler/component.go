@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/dig"
+
+	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/scheduler"
+)
+
+const (
+	// milestoneRequestTimeout is the timeout for reading the timestamp of a milestone from the node.
+	milestoneRequestTimeout = 5 * time.Second
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Scheduler",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsScheduler.Enabled
+		},
+	}
+}
+
+type dependencies struct {
+	dig.In
+	NodeBridge *nodebridge.NodeBridge
+	Indexer    *indexer.Indexer
+	Scheduler  *scheduler.Scheduler
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+)
+
+func provide(c *dig.Container) error {
+	return c.Provide(func(idx *indexer.Indexer) (*scheduler.Scheduler, error) {
+		return scheduler.New(idx, milestoneTimestamp, Plugin.Logger(), scheduler.WithExpiringSoonWindow(ParamsScheduler.ExpiringSoonWindow))
+	})
+}
+
+func configure() error {
+	// the milestones are enqueued by the indexer after they were committed, so they are applied in order.
+	// The queries of the scheduler run in its worker, the ledger updates do not wait for them.
+	deps.Indexer.Events.LedgerUpdated.Hook(event.NewClosure(func(update *nodebridge.LedgerUpdate) {
+		deps.Scheduler.Enqueue(update.MilestoneIndex, ledgerUpdateTimestamp(update))
+	}))
+
+	logEvent := func(name string) *event.Closure[*scheduler.Event] {
+		return event.NewClosure(func(e *scheduler.Event) {
+			Plugin.LogDebugf("Milestone %d: output %s %s at %d", e.MilestoneIndex, e.Output.OutputID.ToHex(), name, e.Output.Time.Unix())
+		})
+	}
+	deps.Scheduler.Events.ExpiringSoon.Attach(logEvent("expires"))
+	deps.Scheduler.Events.Expired.Attach(logEvent("expired"))
+	deps.Scheduler.Events.TimelockReleased.Attach(logEvent("timelock released"))
+
+	return nil
+}
+
+func run() error {
+	return Plugin.Daemon().BackgroundWorker("Scheduler", func(ctx context.Context) {
+		Plugin.LogInfo("Starting Scheduler ... done")
+		deps.Scheduler.Run(ctx)
+		Plugin.LogInfo("Stopping Scheduler ... done")
+	}, daemon.PriorityStopScheduler)
+}
+
+// ledgerUpdateTimestamp returns the timestamp of the milestone of the ledger update.
+// The outputs of the update carry the timestamp, it is zero if the milestone did not change the ledger,
+// so the scheduler asks the node instead.
+func ledgerUpdateTimestamp(update *nodebridge.LedgerUpdate) time.Time {
+	if len(update.Created) > 0 {
+		return time.Unix(int64(update.Created[0].GetMilestoneTimestampBooked()), 0)
+	}
+
+	if len(update.Consumed) > 0 {
+		return time.Unix(int64(update.Consumed[0].GetMilestoneTimestampSpent()), 0)
+	}
+
+	return time.Time{}
+}
+
+// milestoneTimestamp reads the timestamp of the milestone with the given index from the node.
+func milestoneTimestamp(ctx context.Context, index uint32) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, milestoneRequestTimeout)
+	defer cancel()
+
+	milestone, err := deps.NodeBridge.Milestone(ctx, index)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if milestone == nil {
+		return time.Time{}, errors.Errorf("milestone %d not found", index)
+	}
+
+	return time.Unix(int64(milestone.Milestone.Timestamp), 0), nil
+}
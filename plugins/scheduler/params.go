@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/core/app"
+)
+
+// ParametersScheduler contains the definition of the parameters used by the scheduler.
+type ParametersScheduler struct {
+	// Enabled defines whether the scheduler plugin is enabled.
+	Enabled bool `default:"false" usage:"whether the scheduler plugin is enabled"`
+	// ExpiringSoonWindow defines how long before an expiration the expiring soon event is issued.
+	ExpiringSoonWindow time.Duration `default:"24h" usage:"how long before an expiration the expiring soon event is issued (0 = disabled)"`
+}
+
+var ParamsScheduler = &ParametersScheduler{}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"scheduler": ParamsScheduler,
+	},
+	Masked: nil,
+}
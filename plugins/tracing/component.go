@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.uber.org/dig"
+
+	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
+)
+
+const (
+	// ExporterOTLP sends the spans to an OpenTelemetry collector.
+	ExporterOTLP = "otlp"
+	// ExporterFile appends the spans to a file.
+	ExporterFile = "file"
+	// ExporterStdout prints the spans to stdout.
+	ExporterStdout = "stdout"
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Tracing",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsTracing.Enabled
+		},
+	}
+}
+
+type dependencies struct {
+	dig.In
+	Indexer        *indexer.Indexer
+	Echo           *echo.Echo
+	TracerProvider *sdktrace.TracerProvider
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+)
+
+func provide(c *dig.Container) error {
+	return c.Provide(func() (*sdktrace.TracerProvider, error) {
+		exporter, err := newExporter()
+		if err != nil {
+			return nil, err
+		}
+
+		return sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceName(ParamsTracing.ServiceName),
+				semconv.ServiceVersion(Plugin.App().Info().Version),
+			)),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ParamsTracing.SampleRatio))),
+		), nil
+	})
+}
+
+func newExporter() (sdktrace.SpanExporter, error) {
+	switch ParamsTracing.Exporter {
+	case ExporterOTLP:
+		return tracing.NewOTLPExporter(context.Background(), ParamsTracing.OTLP.Protocol, ParamsTracing.OTLP.Endpoint, ParamsTracing.OTLP.Insecure)
+	case ExporterFile:
+		return tracing.NewFileExporter(ParamsTracing.File.Path)
+	case ExporterStdout:
+		return tracing.NewStdoutExporter()
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s, supported exporters: %s, %s, %s", ParamsTracing.Exporter, ExporterOTLP, ExporterFile, ExporterStdout)
+	}
+}
+
+func configure() error {
+	// the spans of the indexer are created by the global tracer provider, until now they were discarded
+	otel.SetTracerProvider(deps.TracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		Plugin.LogWarnf("Exporting spans failed: %s", err)
+	}))
+
+	if err := deps.Indexer.EnableTracing(); err != nil {
+		return err
+	}
+
+	deps.Echo.Use(restAPIMiddleware())
+
+	return nil
+}
+
+func run() error {
+	return Plugin.Daemon().BackgroundWorker("Tracing", func(ctx context.Context) {
+		Plugin.LogInfof("Starting Tracing ... done, exporting spans to %s", ParamsTracing.Exporter)
+
+		<-ctx.Done()
+		Plugin.LogInfo("Stopping Tracing ...")
+
+		shutdownCtx, shutdownCtxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCtxCancel()
+
+		// the remaining spans are exported before the exporter is closed
+		//nolint:contextcheck // false positive
+		if err := deps.TracerProvider.Shutdown(shutdownCtx); err != nil {
+			Plugin.LogWarn(err)
+		}
+
+		Plugin.LogInfo("Stopping Tracing ... done")
+	}, daemon.PriorityStopTracing)
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"github.com/iotaledger/hive.go/core/app"
+)
+
+// ParametersTracing contains the definition of the parameters used by the tracing.
+type ParametersTracing struct {
+	// Enabled defines whether the tracing plugin is enabled.
+	Enabled bool `default:"false" usage:"whether the tracing plugin is enabled"`
+	// ServiceName defines the name of the service in the traces.
+	ServiceName string `default:"inx-indexer" usage:"the name of the service in the traces"`
+	// Exporter defines where the spans are exported to.
+	Exporter string `default:"otlp" usage:"where the spans are exported to (otlp, file, stdout)"`
+	// SampleRatio defines the ratio of the traces that are recorded.
+	SampleRatio float64 `default:"1.0" usage:"the ratio of the traces that are recorded (0.0 - 1.0), traces started by a sampled parent are always recorded"`
+
+	OTLP struct {
+		// Protocol defines the protocol used to send the spans to the collector.
+		Protocol string `default:"grpc" usage:"the protocol used to send the spans to the collector (grpc, http)"`
+		// Endpoint defines the address of the collector.
+		Endpoint string `default:"localhost:4317" usage:"the address of the collector"`
+		// Insecure defines whether the connection to the collector is not encrypted.
+		Insecure bool `default:"true" usage:"whether the connection to the collector is not encrypted"`
+	} `name:"otlp"`
+
+	File struct {
+		// Path defines the path of the file the spans are appended to.
+		Path string `default:"traces.json" usage:"the path of the file the spans are appended to"`
+	} `name:"file"`
+}
+
+var ParamsTracing = &ParametersTracing{}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"tracing": ParamsTracing,
+	},
+	Masked: nil,
+}
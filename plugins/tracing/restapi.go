@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iotaledger/inx-indexer/pkg/server"
+	"github.com/iotaledger/inx-indexer/pkg/tracing"
+)
+
+// HeaderTraceID is the header with the ID of the trace of a request, so slow requests can be looked up in the tracing backend.
+const HeaderTraceID = "X-Trace-Id"
+
+// restAPIMiddleware creates a span for every request, the spans of the queries are its children.
+// A trace context sent by the client is continued.
+func restAPIMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			// the route is used instead of the path, otherwise every output ID would create a span name of its own
+			spanName := req.Method
+			if c.Path() != "" {
+				spanName += " " + c.Path()
+			}
+
+			ctx, span := tracing.Tracer().Start(ctx, spanName,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethodKey.String(req.Method),
+					semconv.HTTPRouteKey.String(c.Path()),
+					attribute.String("indexer.filters", server.FilterSet(c)),
+				),
+			)
+			defer span.End()
+
+			if span.SpanContext().HasTraceID() {
+				c.Response().Header().Set(HeaderTraceID, span.SpanContext().TraceID().String())
+			}
+
+			c.SetRequest(req.WithContext(ctx))
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				// the error is written to the response by the error handler after the middleware returned
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+				span.RecordError(err)
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return err
+		}
+	}
+}
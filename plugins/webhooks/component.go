@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/dig"
+
+	"github.com/iotaledger/hive.go/core/app"
+	"github.com/iotaledger/hive.go/core/generics/event"
+	"github.com/iotaledger/inx-app/pkg/httpserver"
+	"github.com/iotaledger/inx-app/pkg/nodebridge"
+	"github.com/iotaledger/inx-indexer/pkg/daemon"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/scheduler"
+	"github.com/iotaledger/inx-indexer/pkg/webhooks"
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Webhooks",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsWebhooks.Enabled
+		},
+	}
+}
+
+type dependencies struct {
+	dig.In
+	Indexer   *indexer.Indexer
+	Webhooks  *webhooks.Manager
+	Scheduler *scheduler.Scheduler `optional:"true"`
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+)
+
+func provide(c *dig.Container) error {
+	// the tables of the webhooks share the connection pool of the indexer, they are kept if the indexer is cleared
+	return c.Provide(func(idx *indexer.Indexer, nodeBridge *nodebridge.NodeBridge) (*webhooks.Manager, error) {
+		manager, err := webhooks.NewManager(idx.Database(), nodeBridge.ProtocolParameters().Bech32HRP, Plugin.Logger(),
+			webhooks.WithRequestTimeout(ParamsWebhooks.RequestTimeout),
+			webhooks.WithRetryBackoff(ParamsWebhooks.Retry.InitialBackoff, ParamsWebhooks.Retry.MaxBackoff),
+			webhooks.WithMaxAttempts(ParamsWebhooks.Retry.MaxAttempts),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		hooks, err := configuredHooks()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := manager.AddConfiguredHooks(hooks); err != nil {
+			return nil, err
+		}
+
+		return manager, nil
+	})
+}
+
+// configuredHooks converts the webhooks of the config file to the parameters of the manager.
+func configuredHooks() ([]webhooks.HookParameters, error) {
+	hooks := make([]webhooks.HookParameters, 0, len(ParamsWebhooks.Hooks))
+	for _, hook := range ParamsWebhooks.Hooks {
+		params := webhooks.HookParameters{
+			Name:   hook.Name,
+			URL:    hook.URL,
+			Secret: hook.Secret,
+			Events: hook.Events,
+		}
+
+		// the filter uses the same JSON encoding as the admin API
+		filterJSON, err := json.Marshal(hook.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(filterJSON, &params.Filter); err != nil {
+			return nil, errors.Wrapf(err, "invalid filter of webhook %s", hook.Name)
+		}
+
+		hooks = append(hooks, params)
+	}
+
+	return hooks, nil
+}
+
+func configure() error {
+	// the deliveries are written in the transaction of the milestone, so they are committed together with the ledger
+	// and every hook receives the milestones in order
+	deps.Indexer.AddLedgerUpdateWriter(deps.Webhooks.WriteLedgerUpdate)
+	deps.Indexer.Events.LedgerUpdated.Hook(event.NewClosure(func(_ *nodebridge.LedgerUpdate) {
+		deps.Webhooks.Wakeup()
+	}))
+
+	if deps.Scheduler != nil {
+		// the deliveries of the scheduled events are written together with the progress of the scheduler,
+		// so the events of a milestone are delivered exactly once, even if the application stops in between
+		deps.Scheduler.AddEventWriter(deps.Webhooks.WriteScheduledEvents)
+		wakeup := event.NewClosure(func(_ *scheduler.Event) {
+			deps.Webhooks.Wakeup()
+		})
+		deps.Scheduler.Events.ExpiringSoon.Hook(wakeup)
+		deps.Scheduler.Events.Expired.Hook(wakeup)
+		deps.Scheduler.Events.TimelockReleased.Hook(wakeup)
+	}
+
+	for _, hook := range deps.Webhooks.Hooks() {
+		Plugin.LogInfof("Registered webhook %s: %s", hook.Name, hook.URL)
+	}
+
+	return nil
+}
+
+func run() error {
+	if err := Plugin.Daemon().BackgroundWorker("Webhooks", func(ctx context.Context) {
+		Plugin.LogInfo("Starting Webhooks ... done")
+		deps.Webhooks.Run(ctx)
+
+		Plugin.LogInfo("Stopping Webhooks ... done")
+	}, daemon.PriorityStopWebhooks); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+
+	if !ParamsWebhooks.Admin.Enabled {
+		return nil
+	}
+
+	return Plugin.Daemon().BackgroundWorker("Webhooks admin API", func(ctx context.Context) {
+		e := httpserver.NewEcho(Plugin.Logger(), nil, false)
+		deps.Webhooks.ConfigureRoutes(e.Group(""))
+
+		go func() {
+			Plugin.LogInfof("You can now access the webhooks admin API using: http://%s", ParamsWebhooks.Admin.BindAddress)
+			if err := e.Start(ParamsWebhooks.Admin.BindAddress); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				Plugin.LogWarnf("Stopped webhooks admin API due to an error (%s)", err)
+			}
+		}()
+
+		<-ctx.Done()
+		Plugin.LogInfo("Stopping webhooks admin API ...")
+
+		shutdownCtx, shutdownCtxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCtxCancel()
+
+		//nolint:contextcheck // false positive
+		if err := e.Shutdown(shutdownCtx); err != nil {
+			Plugin.LogWarn(err)
+		}
+
+		Plugin.LogInfo("Stopping webhooks admin API ... done")
+	}, daemon.PriorityStopIndexerAPI)
+}
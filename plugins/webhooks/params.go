@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/iotaledger/hive.go/core/app"
+)
+
+// HookConfig defines a webhook in the config file.
+type HookConfig struct {
+	// Name is the unique name of the webhook.
+	Name string `usage:"the unique name of the webhook"`
+	// URL is the URL the matching outputs are POSTed to.
+	URL string `name:"url" usage:"the URL the matching outputs are POSTed to"`
+	// Secret is the key of the HMAC signature of the requests.
+	Secret string `usage:"the key of the HMAC signature of the requests (optional)"`
+	// Events are the events the webhook subscribes to.
+	Events []string `usage:"the events the webhook subscribes to (created, consumed, expiringSoon, expired, timelockReleased), created and consumed if empty"`
+	// Filter are the criteria the outputs have to match.
+	Filter map[string]any `usage:"the criteria the outputs have to match (outputTypes, addresses, senders, issuers, tags, hasNativeTokens, hasStorageDepositReturn, hasExpiration, hasTimelock)"`
+}
+
+// ParametersWebhooks contains the definition of the parameters used by the webhooks.
+type ParametersWebhooks struct {
+	// Enabled defines whether the webhooks plugin is enabled.
+	Enabled bool `default:"false" usage:"whether the webhooks plugin is enabled"`
+	// Hooks defines the webhooks of the config file.
+	Hooks []HookConfig `noflag:"true" usage:"the webhooks of the config file"`
+	// RequestTimeout defines the timeout of a single request to a webhook.
+	RequestTimeout time.Duration `default:"10s" usage:"the timeout of a single request to a webhook"`
+
+	Retry struct {
+		// InitialBackoff defines the time until the first retry of a failed delivery.
+		InitialBackoff time.Duration `default:"5s" usage:"the time until the first retry of a failed delivery, it doubles with every attempt"`
+		// MaxBackoff defines the maximum time between two attempts of a delivery.
+		MaxBackoff time.Duration `default:"1h" usage:"the maximum time between two attempts of a delivery"`
+		// MaxAttempts defines the amount of attempts after which a delivery is dropped.
+		MaxAttempts uint32 `default:"20" usage:"the amount of attempts after which a delivery is dropped (0 = unlimited)"`
+	} `name:"retry"`
+
+	Admin struct {
+		// Enabled defines whether the admin API to manage the webhooks is enabled.
+		Enabled bool `default:"false" usage:"whether the admin API to manage the webhooks is enabled"`
+		// BindAddress defines the bind address on which the admin API listens.
+		BindAddress string `default:"localhost:9092" usage:"the bind address on which the admin API listens"`
+	} `name:"admin"`
+}
+
+var ParamsWebhooks = &ParametersWebhooks{
+	Hooks: []HookConfig{},
+}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"webhooks": ParamsWebhooks,
+	},
+	Masked: []string{"webhooks.hooks"},
+}
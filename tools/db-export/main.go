@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/logger"
+	indexerComponent "github.com/iotaledger/inx-indexer/core/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const (
+	Name = "db-export"
+)
+
+func main() {
+	if err := export(); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Printf("\nERROR: %s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+}
+
+type ParametersExport struct {
+	FilePath string `default:"indexer_dump.json.gz" usage:"the path of the dump file, it must not exist yet"`
+}
+
+func export() error {
+
+	indexerParams := indexerComponent.ParametersIndexer{}
+	dbParams := &indexerParams.Database
+	exportParams := &ParametersExport{}
+
+	config := configuration.New()
+	fs := configuration.NewUnsortedFlagSet("db", flag.ExitOnError)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", Name)
+		fs.PrintDefaults()
+	}
+
+	config.BindParameters(fs, "db", dbParams)
+	config.BindParameters(fs, "export", exportParams)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := config.LoadFlagSet(fs); err != nil {
+		return err
+	}
+	config.UpdateBoundParameters()
+	if err := logger.InitGlobalLogger(config); err != nil {
+		return err
+	}
+
+	log := logger.NewLogger(Name)
+
+	if _, err := database.EngineFromStringAllowed(dbParams.Engine, database.EngineSQLite, database.EnginePostgreSQL); err != nil {
+		return err
+	}
+
+	indexerDBParams, err := indexerParams.DatabaseParams()
+	if err != nil {
+		return err
+	}
+
+	config.Print()
+
+	idx, err := indexer.NewIndexer(indexerDBParams, log)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = idx.CloseDatabase() }()
+
+	if !idx.IsInitialized() {
+		return errors.New("the database contains no indexer tables")
+	}
+
+	//nolint:nosnakecase // false positive, system symbol.
+	file, err := os.OpenFile(exportParams.FilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0660)
+	if err != nil {
+		return err
+	}
+
+	ts := time.Now()
+	log.Infof("> Writing dump to %s ...", exportParams.FilePath)
+
+	writer := bufio.NewWriter(file)
+	header, err := idx.WriteDump(context.Background(), writer, func(table *indexer.DumpTable) {
+		log.Infof("> Wrote table %s with %d rows", table.Name, table.Rows)
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// an incomplete dump must not be loaded by accident
+		_ = os.Remove(exportParams.FilePath)
+
+		return err
+	}
+
+	log.Infof("> Wrote dump of ledger index %d with database version %d, took %s", header.LedgerIndex, header.DatabaseVersion, time.Since(ts).Truncate(time.Millisecond))
+	if len(header.ExcludedTables) > 0 {
+		log.Warnf("> The tables %s are not part of the dump, the API keys and webhooks have to be created again on the target database", strings.Join(header.ExcludedTables, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/logger"
+	indexerComponent "github.com/iotaledger/inx-indexer/core/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+)
+
+const (
+	Name = "db-import"
+)
+
+func main() {
+	if err := load(); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Printf("\nERROR: %s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+}
+
+type ParametersImport struct {
+	FilePath  string `default:"indexer_dump.json.gz" usage:"the path of the dump file written by db-export"`
+	Overwrite bool   `default:"false" usage:"whether the ledger of a database that already contains one is replaced"`
+}
+
+func load() error {
+
+	indexerParams := indexerComponent.ParametersIndexer{}
+	dbParams := &indexerParams.Database
+	importParams := &ParametersImport{}
+
+	config := configuration.New()
+	fs := configuration.NewUnsortedFlagSet("db", flag.ExitOnError)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", Name)
+		fs.PrintDefaults()
+	}
+
+	config.BindParameters(fs, "db", dbParams)
+	config.BindParameters(fs, "import", importParams)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := config.LoadFlagSet(fs); err != nil {
+		return err
+	}
+	config.UpdateBoundParameters()
+	if err := logger.InitGlobalLogger(config); err != nil {
+		return err
+	}
+
+	log := logger.NewLogger(Name)
+
+	if _, err := database.EngineFromStringAllowed(dbParams.Engine, database.EngineSQLite, database.EnginePostgreSQL); err != nil {
+		return err
+	}
+
+	indexerDBParams, err := indexerParams.DatabaseParams()
+	if err != nil {
+		return err
+	}
+
+	config.Print()
+
+	//nolint:nosnakecase // false positive, system symbol.
+	file, err := os.OpenFile(importParams.FilePath, os.O_RDONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	header, decoder, err := indexer.ReadDumpHeader(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+
+	log.Infof("> Dump of ledger index %d of network %s, written from %s at %s", header.LedgerIndex, header.NetworkName, header.Engine, header.CreatedAt.Format(time.RFC3339))
+
+	if header.DatabaseVersion != indexerComponent.DBVersion {
+		return errors.Errorf("the dump has database version %d, but this version of the indexer uses %d, the ledger has to be imported from the node instead", header.DatabaseVersion, indexerComponent.DBVersion)
+	}
+
+	idx, err := indexer.NewIndexer(indexerDBParams, log)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = idx.CloseDatabase() }()
+
+	if idx.IsInitialized() {
+		status, err := idx.Status()
+		switch {
+		case err == nil && !importParams.Overwrite:
+			return errors.Errorf("the database already contains the ledger of index %d, use --import.overwrite to replace it", status.LedgerIndex)
+		case err != nil && !errors.Is(err, indexer.ErrNotFound):
+			return err
+		}
+
+		if err := idx.CheckDumpOutbox(context.Background(), header); err != nil {
+			return err
+		}
+
+		log.Info("> Clearing database ...")
+		if err := idx.Clear(); err != nil {
+			return err
+		}
+	} else {
+		log.Info("> Creating tables ...")
+		if err := idx.CreateTables(); err != nil {
+			return err
+		}
+	}
+
+	ts := time.Now()
+	log.Infof("> Loading dump from %s ...", importParams.FilePath)
+
+	if err := idx.LoadDump(context.Background(), header, decoder, func(table *indexer.DumpTable) {
+		log.Infof("> Loaded table %s with %d rows", table.Name, table.Rows)
+	}); err != nil {
+		return err
+	}
+
+	log.Infof("> Loaded dump of ledger index %d and rebuilt the indexes, took %s", header.LedgerIndex, time.Since(ts).Truncate(time.Millisecond))
+	if len(header.ExcludedTables) > 0 {
+		log.Warnf("> The tables %s of the source database are not part of the dump, the API keys and webhooks have to be created again", strings.Join(header.ExcludedTables, ", "))
+	}
+
+	return nil
+}
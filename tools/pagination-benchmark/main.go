@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/core/configuration"
+	"github.com/iotaledger/hive.go/core/logger"
+	indexerComponent "github.com/iotaledger/inx-indexer/core/indexer"
+	"github.com/iotaledger/inx-indexer/pkg/database"
+	"github.com/iotaledger/inx-indexer/pkg/indexer"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	Name = "pagination-benchmark"
+
+	// firstMilestoneTimestamp is the creation time of the first generated output.
+	firstMilestoneTimestamp = 1_680_000_000
+)
+
+func main() {
+	if err := benchmark(); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Printf("\nERROR: %s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+}
+
+var (
+	// errDeepPagesSlower is returned if the deep pages of a filter are slower than its first page by more than the max. slowdown.
+	errDeepPagesSlower = errors.New("deep pages are slower than the first page")
+	// errPagesInconsistent is returned if the pages of a filter skip or repeat outputs.
+	errPagesInconsistent = errors.New("pages are inconsistent")
+)
+
+type ParametersBenchmark struct {
+	Outputs         int     `default:"3000000" usage:"the amount of basic outputs that are generated if the database is empty"`
+	Addresses       int     `default:"100000" usage:"the amount of addresses the generated outputs are distributed to"`
+	OutputsPerBlock int     `default:"100" usage:"the amount of generated outputs that share the same creation time"`
+	PageSize        uint32  `default:"1000" usage:"the page size of the queries"`
+	Pages           int     `default:"0" usage:"the max. amount of pages that are read per filter (0 = all)"`
+	Repetitions     int     `default:"5" usage:"how often the first and the last page are read again, the median of the durations is compared"`
+	MaxSlowdown     float64 `default:"3" usage:"the max. factor the p99 and the last page may be slower than the first page"`
+	CompareTimings  bool    `default:"true" usage:"whether the durations of the deep pages are compared with the first page, the row counts of the pages are always checked"`
+	Seed            int64   `default:"42" usage:"the seed of the generated data"`
+}
+
+// pageTimings contains the durations of the queries of all pages of a filter.
+type pageTimings []time.Duration
+
+func (t pageTimings) percentile(p float64) time.Duration {
+	sorted := make(pageTimings, len(t))
+	copy(sorted, t)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// pages are the cursors of all pages of a filter together with the durations of their queries.
+type pages struct {
+	cursors []*string
+	timings pageTimings
+	// outputs is the amount of outputs of all pages.
+	outputs int
+}
+
+func benchmark() error {
+
+	indexerParams := indexerComponent.ParametersIndexer{}
+	dbParams := &indexerParams.Database
+	benchmarkParams := &ParametersBenchmark{}
+
+	config := configuration.New()
+	fs := configuration.NewUnsortedFlagSet("db", flag.ExitOnError)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", Name)
+		fs.PrintDefaults()
+	}
+
+	config.BindParameters(fs, "db", dbParams)
+	config.BindParameters(fs, "benchmark", benchmarkParams)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := config.LoadFlagSet(fs); err != nil {
+		return err
+	}
+	config.UpdateBoundParameters()
+	if err := logger.InitGlobalLogger(config); err != nil {
+		return err
+	}
+
+	log := logger.NewLogger(Name)
+
+	if _, err := database.EngineFromStringAllowed(dbParams.Engine, database.EngineSQLite, database.EnginePostgreSQL); err != nil {
+		return err
+	}
+
+	if benchmarkParams.Outputs <= 0 || benchmarkParams.Addresses <= 0 || benchmarkParams.OutputsPerBlock <= 0 || benchmarkParams.PageSize == 0 || benchmarkParams.Repetitions <= 0 {
+		return errors.New("outputs, addresses, outputsPerBlock, pageSize and repetitions have to be bigger than 0")
+	}
+	if benchmarkParams.MaxSlowdown < 1 {
+		return errors.New("maxSlowdown has to be at least 1")
+	}
+
+	indexerDBParams, err := indexerParams.DatabaseParams()
+	if err != nil {
+		return err
+	}
+
+	config.Print()
+
+	idx, err := indexer.NewIndexer(indexerDBParams, log)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = idx.CloseDatabase() }()
+
+	//nolint:gosec // the data only has to be reproducible, not secure.
+	rnd := rand.New(rand.NewSource(benchmarkParams.Seed))
+	addresses := make([]iotago.Address, benchmarkParams.Addresses)
+	for i := range addresses {
+		address := &iotago.Ed25519Address{}
+		rnd.Read(address[:])
+		addresses[i] = address
+	}
+
+	if !idx.IsInitialized() {
+		if err := generateOutputs(log, idx, rnd, addresses, benchmarkParams); err != nil {
+			return err
+		}
+	} else {
+		log.Info("> Using the existing database, make sure it was generated with the same seed and amount of outputs and addresses")
+	}
+
+	// the first address receives the most outputs, see generateOutputs.
+	// The amount of outputs of the address depends on the random distribution of the other half, so it is not checked.
+	filters := []struct {
+		name    string
+		filters []indexer.BasicOutputFilterOption
+		// outputs is the expected amount of outputs of the filter, -1 if unknown.
+		outputs int
+	}{
+		{"no filter", nil, benchmarkParams.Outputs},
+		{"address", []indexer.BasicOutputFilterOption{indexer.BasicOutputUnlockableByAddress(addresses[0])}, -1},
+		{"hasTimelock=false", []indexer.BasicOutputFilterOption{indexer.BasicOutputHasTimelockCondition(false)}, benchmarkParams.Outputs - (benchmarkParams.Outputs+9)/10},
+	}
+
+	var slowFilters []string
+	for _, filter := range filters {
+		filterPages, err := readPages(idx, benchmarkParams, filter.filters)
+		if err != nil {
+			return errors.WithMessagef(err, "filter %s", filter.name)
+		}
+
+		if benchmarkParams.Pages == 0 && filter.outputs >= 0 && filterPages.outputs != filter.outputs {
+			return errors.Wrapf(errPagesInconsistent, "filter %s: expected %d outputs, got %d", filter.name, filter.outputs, filterPages.outputs)
+		}
+
+		if !benchmarkParams.CompareTimings {
+			log.Infof("> %s: %d pages of %d, %d outputs", filter.name, len(filterPages.timings), benchmarkParams.PageSize, filterPages.outputs)
+
+			continue
+		}
+
+		// single queries are too noisy to compare them, so the first and the last page are read again
+		first, err := medianPageTiming(idx, benchmarkParams, filter.filters, filterPages.cursors[0])
+		if err != nil {
+			return err
+		}
+		last, err := medianPageTiming(idx, benchmarkParams, filter.filters, filterPages.cursors[len(filterPages.cursors)-1])
+		if err != nil {
+			return err
+		}
+		p99 := filterPages.timings.percentile(0.99)
+
+		log.Infof("> %s: %d pages of %d, first %s, median %s, p99 %s, last %s",
+			filter.name, len(filterPages.timings), benchmarkParams.PageSize,
+			first.Truncate(time.Microsecond),
+			filterPages.timings.percentile(0.5).Truncate(time.Microsecond),
+			p99.Truncate(time.Microsecond),
+			last.Truncate(time.Microsecond),
+		)
+
+		maxDuration := time.Duration(float64(first) * benchmarkParams.MaxSlowdown)
+		if p99 > maxDuration || last > maxDuration {
+			log.Warnf("> %s: deep pages are more than %.1f times slower than the first page", filter.name, benchmarkParams.MaxSlowdown)
+			slowFilters = append(slowFilters, filter.name)
+		}
+	}
+
+	if len(slowFilters) > 0 {
+		return errors.Wrapf(errDeepPagesSlower, "filters: %s", strings.Join(slowFilters, ", "))
+	}
+
+	return nil
+}
+
+// generateOutputs fills the database with basic outputs.
+// Half of the outputs belong to the first address, the others are distributed randomly.
+func generateOutputs(log *logger.Logger, idx *indexer.Indexer, rnd *rand.Rand, addresses []iotago.Address, params *ParametersBenchmark) error {
+	log.Info("> Creating tables ...")
+	if err := idx.CreateTables(); err != nil {
+		return err
+	}
+
+	log.Info("> Dropping indexes to speed up insertion ...")
+	if err := idx.DropIndexes(); err != nil {
+		return err
+	}
+
+	ts := time.Now()
+	log.Infof("> Generating %d outputs ...", params.Outputs)
+
+	importer := idx.ImportTransaction(context.Background())
+	for i := 0; i < params.Outputs; i++ {
+		outputID := iotago.OutputID{}
+		rnd.Read(outputID[:])
+
+		address := addresses[0]
+		if i%2 == 1 {
+			address = addresses[rnd.Intn(len(addresses))]
+		}
+
+		output := &iotago.BasicOutput{
+			Amount: 1_000_000,
+			Conditions: iotago.UnlockConditions{
+				&iotago.AddressUnlockCondition{Address: address},
+			},
+		}
+		if i%10 == 0 {
+			output.Conditions = append(output.Conditions, &iotago.TimelockUnlockCondition{UnixTime: firstMilestoneTimestamp})
+		}
+
+		if err := importer.AddOutput(outputID, output, uint32(firstMilestoneTimestamp+i/params.OutputsPerBlock)); err != nil {
+			return err
+		}
+	}
+
+	protoParams := &iotago.ProtocolParameters{Version: 2, NetworkName: Name}
+	if err := importer.Finalize(1, protoParams, indexerComponent.DBVersion); err != nil {
+		return err
+	}
+
+	log.Info("> Creating indexes ...")
+	if err := idx.AutoMigrate(); err != nil {
+		return err
+	}
+
+	log.Info("> Updating query planner statistics ...")
+	if err := idx.Analyze(); err != nil {
+		return err
+	}
+
+	log.Infof("> Generating %d outputs took %s", params.Outputs, time.Since(ts).Truncate(time.Millisecond))
+
+	return nil
+}
+
+// readPage reads the page of the filter the cursor points to, the first page if the cursor is nil.
+func readPage(idx *indexer.Indexer, params *ParametersBenchmark, filters []indexer.BasicOutputFilterOption, cursor *string) (*indexer.IndexerResult, time.Duration, error) {
+	pageFilters := append([]indexer.BasicOutputFilterOption{indexer.BasicOutputPageSize(params.PageSize)}, filters...)
+	if cursor != nil {
+		pageFilters = append(pageFilters, indexer.BasicOutputCursor(*cursor))
+	}
+
+	ts := time.Now()
+	result := idx.BasicOutputsWithFilters(context.Background(), pageFilters...)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return result, time.Since(ts), nil
+}
+
+// readPages follows the cursors through all pages of the filter and returns the duration of each query.
+// Every page except the last one has to be full and no output may be returned twice.
+func readPages(idx *indexer.Indexer, params *ParametersBenchmark, filters []indexer.BasicOutputFilterOption) (*pages, error) {
+	filterPages := &pages{}
+	seen := make(map[iotago.OutputID]struct{})
+	var cursor *string
+
+	for params.Pages == 0 || len(filterPages.timings) < params.Pages {
+		result, duration, err := readPage(idx, params, filters, cursor)
+		if err != nil {
+			return nil, err
+		}
+		filterPages.cursors = append(filterPages.cursors, cursor)
+		filterPages.timings = append(filterPages.timings, duration)
+
+		for _, outputID := range result.OutputIDs {
+			if _, exists := seen[outputID]; exists {
+				return nil, errors.Wrapf(errPagesInconsistent, "output %s is returned twice", outputID.ToHex())
+			}
+			seen[outputID] = struct{}{}
+		}
+		filterPages.outputs += len(result.OutputIDs)
+
+		if result.Cursor == nil {
+			break
+		}
+		if uint32(len(result.OutputIDs)) != params.PageSize {
+			return nil, errors.Wrapf(errPagesInconsistent, "page %d has a cursor but only %d of %d outputs", len(filterPages.timings), len(result.OutputIDs), params.PageSize)
+		}
+		cursor = result.Cursor
+	}
+
+	return filterPages, nil
+}
+
+// medianPageTiming reads the page the cursor points to several times and returns the median duration of the queries.
+func medianPageTiming(idx *indexer.Indexer, params *ParametersBenchmark, filters []indexer.BasicOutputFilterOption, cursor *string) (time.Duration, error) {
+	timings := make(pageTimings, 0, params.Repetitions)
+	for i := 0; i < params.Repetitions; i++ {
+		_, duration, err := readPage(idx, params, filters, cursor)
+		if err != nil {
+			return 0, err
+		}
+		timings = append(timings, duration)
+	}
+
+	return timings.percentile(0.5), nil
+}
@@ -65,7 +65,11 @@ func convert() error {
 
 	log := logger.NewLogger("snap-to-db")
 
-	engine, err := database.EngineFromStringAllowed(dbParams.Engine, database.EngineSQLite, database.EnginePostgreSQL)
+	if _, err := database.EngineFromStringAllowed(dbParams.Engine, database.EngineSQLite, database.EnginePostgreSQL); err != nil {
+		return err
+	}
+
+	indexerDBParams, err := indexerParams.DatabaseParams()
 	if err != nil {
 		return err
 	}
@@ -77,23 +81,6 @@ func convert() error {
 		return err
 	}
 
-	indexerDBParams := database.Params{
-		Engine: engine,
-	}
-
-	//nolint:exhaustive // we already checked the values is one of the valid ones
-	switch engine {
-	case database.EngineSQLite:
-		indexerDBParams.Path = dbParams.SQLite.Path
-
-	case database.EnginePostgreSQL:
-		indexerDBParams.Host = dbParams.PostgreSQL.Host
-		indexerDBParams.Port = dbParams.PostgreSQL.Port
-		indexerDBParams.Database = dbParams.PostgreSQL.Database
-		indexerDBParams.Username = dbParams.PostgreSQL.Username
-		indexerDBParams.Password = dbParams.PostgreSQL.Password
-	}
-
 	config.Print()
 
 	idx, err := indexer.NewIndexer(indexerDBParams, log)